@@ -3,6 +3,7 @@ package api
 import (
 	application "jxt-evidence-system/process-management/internal/application/service"
 	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+	infra_grpc "jxt-evidence-system/process-management/internal/infrastructure/grpc"
 	persistence "jxt-evidence-system/process-management/internal/infrastructure/persistence/gorm"
 	infra_ws "jxt-evidence-system/process-management/internal/infrastructure/websocket"
 	"jxt-evidence-system/process-management/internal/interfaces/rest/api"
@@ -18,6 +19,7 @@ func init() {
 	// jiyuanjie 添加依赖注入
 	Registrations = append(Registrations, infra_ws.RegisterDependencies)
 	Registrations = append(Registrations, persistence.RegisterDependencies)
+	Registrations = append(Registrations, infra_grpc.RegisterDependencies)
 	Registrations = append(Registrations, domain_service.RegisterDependencies)
 	Registrations = append(Registrations, application.RegisterDependencies)
 	Registrations = append(Registrations, api.RegisterDependencies)
@@ -19,6 +19,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
 
+	"jxt-evidence-system/process-management/internal/application/service/port"
 	ws "jxt-evidence-system/process-management/internal/domain/aggregate/task/websocket"
 	"jxt-evidence-system/process-management/shared/common/database"
 	"jxt-evidence-system/process-management/shared/common/di"
@@ -169,6 +170,12 @@ func run() error {
 	for _, f := range Registrations {
 		f()
 	}
+	// 启动定时器调度器（步骤超时等）
+	if err := di.Invoke(func(scheduler port.TimerScheduler) {
+		scheduler.Start()
+	}); err != nil {
+		log.Printf("Failed to start timer scheduler: %v", err)
+	}
 	// 同时启动HTTP和gRPC服务
 	errChan := make(chan error, 2)
 	sigChan := make(chan os.Signal, 1)
@@ -337,6 +344,11 @@ func gracefulShutdown() error {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
+	// 停止定时器调度器，需在关闭数据库之前
+	_ = di.Invoke(func(scheduler port.TimerScheduler) {
+		scheduler.Stop()
+	})
+
 	// 关闭 Database
 	if err := database.Close(shutdownCtx); err != nil {
 		log.Printf("Error closing database: %v\n", err)
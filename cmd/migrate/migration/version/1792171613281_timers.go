@@ -0,0 +1,31 @@
+package version
+
+import (
+	"runtime"
+
+	"jxt-evidence-system/process-management/cmd/migrate/migration"
+	timer_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/timer"
+	models "jxt-evidence-system/process-management/shared/common/models"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	_, fileName, _, _ := runtime.Caller(0)
+	migration.Migrate.SetVersion(migration.GetFilename(fileName), _1792171613281Timers)
+}
+
+// _1792171613281Timers 创建定时器表
+func _1792171613281Timers(db *gorm.DB, version string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AutoMigrate(
+			&timer_aggregate.Timer{},
+		); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.Migration{
+			Version: version,
+		}).Error
+	})
+}
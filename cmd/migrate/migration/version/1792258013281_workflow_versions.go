@@ -0,0 +1,35 @@
+package version
+
+import (
+	"runtime"
+
+	"jxt-evidence-system/process-management/cmd/migrate/migration"
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	workflow_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/workflow"
+	models "jxt-evidence-system/process-management/shared/common/models"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	_, fileName, _, _ := runtime.Caller(0)
+	migration.Migrate.SetVersion(migration.GetFilename(fileName), _1792258013281WorkflowVersions)
+}
+
+// _1792258013281WorkflowVersions 创建工作流版本表，工作流和实例增加版本号字段
+// 已有实例的版本号为 0，引擎对其回退使用工作流当前定义
+func _1792258013281WorkflowVersions(db *gorm.DB, version string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AutoMigrate(
+			&workflow_aggregate.WorkflowVersion{},
+			&workflow_aggregate.Workflow{},
+			&instance_aggregate.WorkflowInstance{},
+		); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.Migration{
+			Version: version,
+		}).Error
+	})
+}
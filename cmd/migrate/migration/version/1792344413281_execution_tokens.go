@@ -0,0 +1,34 @@
+package version
+
+import (
+	"runtime"
+
+	"jxt-evidence-system/process-management/cmd/migrate/migration"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	models "jxt-evidence-system/process-management/shared/common/models"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	_, fileName, _, _ := runtime.Caller(0)
+	migration.Migrate.SetVersion(migration.GetFilename(fileName), _1792344413281ExecutionTokens)
+}
+
+// _1792344413281ExecutionTokens 创建执行令牌表，任务增加令牌字段
+// 已有任务的令牌为空，引擎在其完成时补建根令牌
+func _1792344413281ExecutionTokens(db *gorm.DB, version string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AutoMigrate(
+			&token_aggregate.ExecutionToken{},
+			&task_aggregate.Task{},
+		); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.Migration{
+			Version: version,
+		}).Error
+	})
+}
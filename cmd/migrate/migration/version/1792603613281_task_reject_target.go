@@ -0,0 +1,29 @@
+package version
+
+import (
+	"runtime"
+
+	"jxt-evidence-system/process-management/cmd/migrate/migration"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	models "jxt-evidence-system/process-management/shared/common/models"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	_, fileName, _, _ := runtime.Caller(0)
+	migration.Migrate.SetVersion(migration.GetFilename(fileName), _1792603613281TaskRejectTarget)
+}
+
+// _1792603613281TaskRejectTarget 任务增加驳回目标和直接提交回驳回步骤的字段
+func _1792603613281TaskRejectTarget(db *gorm.DB, version string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AutoMigrate(&task_aggregate.Task{}); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.Migration{
+			Version: version,
+		}).Error
+	})
+}
@@ -0,0 +1,29 @@
+package version
+
+import (
+	"runtime"
+
+	"jxt-evidence-system/process-management/cmd/migrate/migration"
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	models "jxt-evidence-system/process-management/shared/common/models"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	_, fileName, _, _ := runtime.Caller(0)
+	migration.Migrate.SetVersion(migration.GetFilename(fileName), _1792690013281InstanceSuspend)
+}
+
+// _1792690013281InstanceSuspend 实例增加挂起时间字段
+func _1792690013281InstanceSuspend(db *gorm.DB, version string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AutoMigrate(&instance_aggregate.WorkflowInstance{}); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.Migration{
+			Version: version,
+		}).Error
+	})
+}
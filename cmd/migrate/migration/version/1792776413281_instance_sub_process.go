@@ -0,0 +1,29 @@
+package version
+
+import (
+	"runtime"
+
+	"jxt-evidence-system/process-management/cmd/migrate/migration"
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	models "jxt-evidence-system/process-management/shared/common/models"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	_, fileName, _, _ := runtime.Caller(0)
+	migration.Migrate.SetVersion(migration.GetFilename(fileName), _1792776413281InstanceSubProcess)
+}
+
+// _1792776413281InstanceSubProcess 实例增加父实例、根实例和父任务字段
+func _1792776413281InstanceSubProcess(db *gorm.DB, version string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AutoMigrate(&instance_aggregate.WorkflowInstance{}); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.Migration{
+			Version: version,
+		}).Error
+	})
+}
@@ -0,0 +1,31 @@
+package version
+
+import (
+	"runtime"
+
+	"jxt-evidence-system/process-management/cmd/migrate/migration"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	models "jxt-evidence-system/process-management/shared/common/models"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	_, fileName, _, _ := runtime.Caller(0)
+	migration.Migrate.SetVersion(migration.GetFilename(fileName), _1792862813281TaskCandidates)
+}
+
+// _1792862813281TaskCandidates 创建任务候选人表
+func _1792862813281TaskCandidates(db *gorm.DB, version string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AutoMigrate(
+			&task_aggregate.TaskCandidate{},
+		); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.Migration{
+			Version: version,
+		}).Error
+	})
+}
@@ -0,0 +1,33 @@
+package version
+
+import (
+	"runtime"
+
+	"jxt-evidence-system/process-management/cmd/migrate/migration"
+	delegation_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/delegation"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	models "jxt-evidence-system/process-management/shared/common/models"
+
+	"gorm.io/gorm"
+)
+
+func init() {
+	_, fileName, _, _ := runtime.Caller(0)
+	migration.Migrate.SetVersion(migration.GetFilename(fileName), _1793035613281DelegationRules)
+}
+
+// _1793035613281DelegationRules 创建委托规则表，任务增加原处理人字段
+func _1793035613281DelegationRules(db *gorm.DB, version string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AutoMigrate(
+			&delegation_aggregate.DelegationRule{},
+			&task_aggregate.Task{},
+		); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.Migration{
+			Version: version,
+		}).Error
+	})
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"time"
+
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+)
+
+// CreateDelegationCommand 创建委托规则命令，委托人为当前登录用户
+type CreateDelegationCommand struct {
+	UserID      int                      `json:"-"`
+	DelegateID  int                      `json:"delegateId" binding:"required"`
+	StartAt     time.Time                `json:"startAt" binding:"required"`
+	EndAt       time.Time                `json:"endAt" binding:"required"`
+	WorkflowIDs []valueobject.WorkflowID `json:"workflowIds"` // 为空时适用于全部工作流
+	Reason      string                   `json:"reason"`
+}
+
+// UpdateDelegationCommand 修改委托规则命令，只能修改自己的规则
+type UpdateDelegationCommand struct {
+	ID          valueobject.DelegationID `uri:"id" binding:"required"`
+	UserID      int                      `json:"-"`
+	DelegateID  int                      `json:"delegateId"`
+	StartAt     time.Time                `json:"startAt"`
+	EndAt       time.Time                `json:"endAt"`
+	WorkflowIDs []valueobject.WorkflowID `json:"workflowIds"`
+	Reason      string                   `json:"reason"`
+}
+
+// DeleteDelegationCommand 删除委托规则命令，只能删除自己的规则
+type DeleteDelegationCommand struct {
+	ID     valueobject.DelegationID `uri:"id" binding:"required"`
+	UserID int                      `json:"-"`
+}
+
+// GetDelegationCommand 获取委托规则命令
+type GetDelegationCommand struct {
+	ID valueobject.DelegationID `uri:"id" binding:"required"`
+}
@@ -3,13 +3,17 @@ package command
 import (
 	"encoding/json"
 	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	common "jxt-evidence-system/process-management/shared/common/models"
 	"jxt-evidence-system/process-management/shared/common/query"
+	"jxt-evidence-system/process-management/shared/common/status"
 	"time"
 )
 
-// CancelInstanceCommand 删除工作流实例命令
+// CancelInstanceCommand 取消工作流实例命令
 type CancelInstanceCommand struct {
-	ID valueobject.InstanceID `uri:"id" binding:"required"`
+	ID     valueobject.InstanceID `uri:"id" binding:"required"`
+	Reason string                 `form:"reason"`
+	common.ControlBy
 }
 
 // DeleteInstanceCommand 删除工作流实例命令
@@ -23,8 +27,9 @@ func (s *DeleteInstanceCommand) GetId() valueobject.InstanceID {
 
 // StartWorkflowInstanceCommand 启动工作流实例命令
 type StartWorkflowInstanceCommand struct {
-	ID    valueobject.WorkflowID `json:"id" binding:"required"`
-	Input json.RawMessage        `json:"input"`
+	ID          valueobject.WorkflowID `json:"id" binding:"required"`
+	Input       json.RawMessage        `json:"input"`
+	InitiatorID int                    `json:"-"` // 发起人，取自请求的登录用户
 }
 
 // InstancePagedQuery 工作流实例分页查询命令
@@ -36,12 +41,34 @@ type InstancePagedQuery struct {
 	StartedAtEnd     *time.Time             `form:"startedAtEnd" search:"type:lte;column:started_at;table:workflow_instances"`
 	CompletedAtStart *time.Time             `form:"completedAtStart" search:"type:gte;column:completed_at;table:workflow_instances"`
 	CompletedAtEnd   *time.Time             `form:"completedAtEnd" search:"type:lte;column:completed_at;table:workflow_instances"`
+	InitiatorID      int                    `form:"initiatorId" search:"type:exact;column:initiator_id;table:workflow_instances"`
 }
 
 func (q *InstancePagedQuery) GetNeedSearch() interface{} {
 	return *q
 }
 
+// CurrentStep 实例当前所处的步骤（待处理或等待中的任务）
+type CurrentStep struct {
+	StepID   string             `json:"stepId"`
+	StepName string             `json:"stepName"`
+	TaskID   valueobject.TaskID `json:"taskId"`
+	Assignee int                `json:"assignee"`
+}
+
+// StartedInstanceItem 我发起的实例，附带当前步骤
+type StartedInstanceItem struct {
+	InstanceID   valueobject.InstanceID `json:"instanceId"`
+	InstanceNo   string                 `json:"instanceNo"`
+	WorkflowID   valueobject.WorkflowID `json:"workflowId"`
+	WorkflowName string                 `json:"workflowName"`
+	Status       status.InstanceStatus  `json:"status"`
+	ErrorMessage string                 `json:"errorMessage"`
+	StartedAt    time.Time              `json:"startedAt"`
+	CompletedAt  *time.Time             `json:"completedAt"`
+	CurrentSteps []CurrentStep          `json:"currentSteps"` // 实例结束后为空，并行分支时有多个
+}
+
 type GetInstancesByWorkflowPagedQuery struct {
 	query.Pagination `search:"-"`
 	ID               valueobject.WorkflowID `form:"id" search:"type:exact;column:workflow_id;table:workflow_instances"`
@@ -62,3 +89,88 @@ type GetInstanceCommand struct {
 func (s *GetInstanceCommand) GetId() valueobject.InstanceID {
 	return s.ID
 }
+
+// MigrateInstancesCommand 实例版本迁移命令
+type MigrateInstancesCommand struct {
+	WorkflowID  valueobject.WorkflowID   `uri:"id" binding:"required"`
+	InstanceIDs []valueobject.InstanceID `json:"instanceIds"` // 为空时迁移该工作流全部运行中的实例
+	ToVersion   int                      `json:"toVersion"`   // 目标版本，0 表示当前发布版本
+	StepMapping map[string]string        `json:"stepMapping"` // 旧步骤ID -> 新步骤ID，未列出的步骤按相同ID映射
+	DryRun      bool                     `json:"dryRun"`      // 仅校验并返回迁移报告，不做修改
+	common.ControlBy
+}
+
+// 实例迁移结果状态
+const (
+	MigrationStatusMigrated = "migrated" // 已迁移
+	MigrationStatusPlanned  = "planned"  // 试运行：校验通过，可迁移
+	MigrationStatusSkipped  = "skipped"  // 已在目标版本
+	MigrationStatusFailed   = "failed"   // 校验失败，未迁移
+)
+
+// TaskMigration 未处理任务（待处理或等待中）的步骤映射
+type TaskMigration struct {
+	TaskID  valueobject.TaskID `json:"taskId"`
+	FromKey string             `json:"fromKey"`
+	ToKey   string             `json:"toKey"`
+}
+
+// TokenMigration 执行令牌的步骤映射
+type TokenMigration struct {
+	TokenID    valueobject.TokenID `json:"tokenId"`
+	FromStepID string              `json:"fromStepId"`
+	ToStepID   string              `json:"toStepId"`
+}
+
+// InstanceMigrationResult 单个实例的迁移结果
+type InstanceMigrationResult struct {
+	InstanceID  valueobject.InstanceID `json:"instanceId"`
+	FromVersion int                    `json:"fromVersion"`
+	ToVersion   int                    `json:"toVersion"`
+	Status      string                 `json:"status"`
+	Tasks       []TaskMigration        `json:"tasks"`
+	Tokens      []TokenMigration       `json:"tokens"`
+	Errors      []string               `json:"errors"`
+}
+
+// InstanceMigrationReport 实例迁移报告
+type InstanceMigrationReport struct {
+	WorkflowID valueobject.WorkflowID    `json:"workflowId"`
+	ToVersion  int                       `json:"toVersion"`
+	DryRun     bool                      `json:"dryRun"`
+	Total      int                       `json:"total"`
+	Migrated   int                       `json:"migrated"`
+	Skipped    int                       `json:"skipped"`
+	Failed     int                       `json:"failed"`
+	Results    []InstanceMigrationResult `json:"results"`
+}
+
+// JumpInstanceCommand 实例跳转命令：运维人员将实例跳转到指定步骤
+type JumpInstanceCommand struct {
+	ID           valueobject.InstanceID `uri:"id" binding:"required"`
+	TargetStepID string                 `json:"targetStepId" binding:"required"`
+	Reason       string                 `json:"reason"`
+	common.ControlBy
+}
+
+// SuspendInstanceCommand 挂起实例命令（如证据案件的法律保全）
+type SuspendInstanceCommand struct {
+	ID     valueobject.InstanceID `uri:"id" binding:"required"`
+	Reason string                 `json:"reason" binding:"required"`
+	common.ControlBy
+}
+
+// ResumeInstanceCommand 恢复挂起实例命令
+type ResumeInstanceCommand struct {
+	ID     valueobject.InstanceID `uri:"id" binding:"required"`
+	Reason string                 `json:"reason" binding:"required"`
+	common.ControlBy
+}
+
+// InstanceJumpResult 实例跳转结果
+type InstanceJumpResult struct {
+	InstanceID     valueobject.InstanceID `json:"instanceId"`
+	FromSteps      []string               `json:"fromSteps"`      // 跳转前令牌所在的步骤
+	ToStepID       string                 `json:"toStepId"`       // 跳转目标步骤
+	CancelledTasks []string               `json:"cancelledTasks"` // 被取消的待办任务
+}
@@ -15,6 +15,12 @@ type CompleteTaskCommand struct {
 	Comment          string            `json:"comment"`
 	NextTaskApprover int               `json:"nextTaskApprover"`
 	Result           status.TaskResult `json:"result"`
+
+	// 驳回时指定回到的步骤或直接驳回到发起人，都不指定时回到上一个已完成的步骤
+	TargetStepID string `json:"targetStepId"`
+	ToInitiator  bool   `json:"toInitiator"`
+	// 处理被驳回产生的任务时，直接提交回发起驳回的步骤
+	DirectResubmit bool `json:"directResubmit"`
 }
 
 // UnmarshalJSON 自定义 JSON 解组，处理字符串化的 output
@@ -58,6 +64,36 @@ type DelegateTaskCommand struct {
 	Comment  string             `json:"comment"`
 }
 
+// AddSignerCommand 加签命令
+type AddSignerCommand struct {
+	ID       valueobject.TaskID `uri:"id" binding:"required"`
+	UserID   int                `json:"userId"`
+	SignerID int                `json:"signerId"`
+	Position string             `json:"position"` // before, after, parallel
+	Comment  string             `json:"comment"`
+}
+
+// ClaimTaskCommand 认领任务命令
+type ClaimTaskCommand struct {
+	ID         valueobject.TaskID `uri:"id" binding:"required"`
+	UserID     int                `json:"-"`
+	UserGroups []int              `json:"-"` // 用户所属的组（组织机构），用于匹配候选组
+}
+
+// UnclaimTaskCommand 放弃认领命令
+type UnclaimTaskCommand struct {
+	ID      valueobject.TaskID `uri:"id" binding:"required"`
+	UserID  int                `json:"-"`
+	Comment string             `json:"comment"`
+}
+
+// WithdrawTaskCommand 撤回任务命令
+type WithdrawTaskCommand struct {
+	ID      valueobject.TaskID `uri:"id" binding:"required"`
+	UserID  int                `json:"userId"`
+	Comment string             `json:"comment"`
+}
+
 // DeleteTaskCommand 删除任务命令
 type DeleteTaskCommand struct {
 	ID valueobject.TaskID `uri:"id" binding:"required"`
@@ -111,12 +147,17 @@ type TodoTaskPagedQuery struct {
 	WorkflowName     string `form:"workflowName"`
 }
 
+// ClaimableTaskPagedQuery 待领任务分页查询
 type ClaimableTaskPagedQuery struct {
 	query.Pagination `search:"-"`
 	TaskName         string `form:"taskName" search:"type:contains;column:task_name;table:tasks"`
 	WorkflowName     string `form:"workflowName"`
 }
 
+func (q *ClaimableTaskPagedQuery) GetNeedSearch() interface{} {
+	return *q
+}
+
 func (q *TodoTaskPagedQuery) GetNeedSearch() interface{} {
 	return *q
 }
@@ -151,4 +192,7 @@ type TaskHistoryItem struct {
 	Output      map[string]interface{} `json:"output"`
 	CompletedAt string                 `json:"completedAt"`
 	CreatedAt   string                 `json:"createdAt"`
+
+	Action       string `json:"action,omitempty"`       // 操作记录的动作，如 add_sign
+	SignPosition string `json:"signPosition,omitempty"` // 加签产生的任务或加签记录的加签位置
 }
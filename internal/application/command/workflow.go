@@ -10,6 +10,7 @@ import (
 // ActivateWorkflowCommand 激活工作流命令
 type ActivateWorkflowCommand struct {
 	ID valueobject.WorkflowID `uri:"id" binding:"required"`
+	common.ControlBy
 }
 
 // CreateWorkflowCommand 创建工作流命令
@@ -62,3 +63,73 @@ type WorkflowPagedQuery struct {
 func (q *WorkflowPagedQuery) GetNeedSearch() interface{} {
 	return *q
 }
+
+// ValidateWorkflowCommand 校验工作流定义命令
+type ValidateWorkflowCommand struct {
+	Definition string `json:"definition" binding:"required"`
+}
+
+// GetWorkflowVersionsCommand 获取工作流版本列表命令
+type GetWorkflowVersionsCommand struct {
+	ID valueobject.WorkflowID `uri:"id" binding:"required"`
+}
+
+// GetWorkflowVersionCommand 获取工作流指定版本命令
+type GetWorkflowVersionCommand struct {
+	ID      valueobject.WorkflowID `uri:"id" binding:"required"`
+	Version int                    `uri:"version" binding:"required,min=1"`
+}
+
+// CompareWorkflowVersionsCommand 比较工作流两个版本命令
+type CompareWorkflowVersionsCommand struct {
+	ID   valueobject.WorkflowID `uri:"id" binding:"required"`
+	From int                    `form:"from" binding:"required,min=1"`
+	To   int                    `form:"to" binding:"required,min=1"`
+}
+
+// WorkflowVersionDiff 两个版本定义的差异（按步骤ID比较）
+type WorkflowVersionDiff struct {
+	From          int          `json:"from"`
+	To            int          `json:"to"`
+	ChangedFields []string     `json:"changedFields"` // 定义级别变化的字段，如 name、description
+	AddedSteps    []string     `json:"addedSteps"`
+	RemovedSteps  []string     `json:"removedSteps"`
+	ChangedSteps  []StepChange `json:"changedSteps"`
+}
+
+// StepChange 步骤变化
+type StepChange struct {
+	StepID string   `json:"stepId"`
+	Fields []string `json:"fields"` // 发生变化的字段（JSON 字段名）
+}
+
+// ImportBpmnCommand 导入 BPMN 2.0 XML 命令，转换后创建草稿工作流
+type ImportBpmnCommand struct {
+	Name        string `json:"name"`        // 为空时使用 BPMN 流程名称
+	Description string `json:"description"` // 为空时使用 BPMN 流程文档
+	Xml         string `json:"xml" binding:"required"`
+	DryRun      bool   `json:"dryRun"` // 只返回转换结果，不创建工作流
+	common.ControlBy
+}
+
+// ExportBpmnCommand 导出 BPMN 2.0 XML 命令
+type ExportBpmnCommand struct {
+	ID      valueobject.WorkflowID `uri:"id" binding:"required"`
+	Version int                    `form:"version"` // 为0时导出工作流当前定义
+}
+
+// BpmnUnsupportedElement 导入时无法转换或被忽略的 BPMN 元素
+type BpmnUnsupportedElement struct {
+	ElementID   string `json:"elementId"`
+	ElementType string `json:"elementType"`
+	Name        string `json:"name"`
+	Reason      string `json:"reason"`
+}
+
+// BpmnImportResult BPMN 导入结果
+type BpmnImportResult struct {
+	WorkflowID  string                   `json:"workflowId"` // DryRun 时为空
+	Name        string                   `json:"name"`
+	Definition  string                   `json:"definition"`
+	Unsupported []BpmnUnsupportedElement `json:"unsupported"`
+}
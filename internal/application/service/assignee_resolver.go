@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+)
+
+// InMemoryAssigneeResolver 内存处理人解析器
+// 未接入用户服务时的默认实现，也用于测试；用户和组织通过 AddUser、AddOrg 注册，组织和用户均以ID引用
+type InMemoryAssigneeResolver struct {
+	mu        sync.RWMutex
+	userOrgs  map[int]int            // 用户ID -> 所属组织ID
+	policeNos map[string]int         // 警号 -> 用户ID
+	roles     map[string]map[int]int // 组织ID@角色 -> 担任该角色的用户ID（保持注册顺序）
+	orgs      map[int]inMemoryOrg    // 组织ID -> 组织
+}
+
+type inMemoryOrg struct {
+	parentID int
+	leader   int
+}
+
+// NewInMemoryAssigneeResolver 创建内存处理人解析器
+func NewInMemoryAssigneeResolver() *InMemoryAssigneeResolver {
+	return &InMemoryAssigneeResolver{
+		userOrgs:  make(map[int]int),
+		policeNos: make(map[string]int),
+		roles:     make(map[string]map[int]int),
+		orgs:      make(map[int]inMemoryOrg),
+	}
+}
+
+// AddUser 注册用户及其所属组织、警号和在组织中担任的角色
+func (r *InMemoryAssigneeResolver) AddUser(userID, orgID int, policeNo string, roles ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.userOrgs[userID] = orgID
+	if policeNo != "" {
+		r.policeNos[policeNo] = userID
+	}
+	for _, role := range roles {
+		key := roleKey(orgID, role)
+		if r.roles[key] == nil {
+			r.roles[key] = make(map[int]int)
+		}
+		if _, ok := r.roles[key][userID]; !ok {
+			r.roles[key][userID] = len(r.roles[key])
+		}
+	}
+}
+
+// AddOrg 注册组织及其上级组织和负责人
+func (r *InMemoryAssigneeResolver) AddOrg(orgID, parentID, leader int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orgs[orgID] = inMemoryOrg{parentID: parentID, leader: leader}
+}
+
+// Resolve 解析处理人表达式
+func (r *InMemoryAssigneeResolver) Resolve(ctx context.Context, expr *domain_service.AssigneeExpression) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []int
+	switch expr.Kind {
+	case domain_service.AssigneeKindPoliceNo:
+		if userID, ok := r.policeNos[expr.PoliceNo]; ok {
+			users = []int{userID}
+		}
+	case domain_service.AssigneeKindLeaderOf:
+		userID, err := strconv.Atoi(expr.User)
+		if err != nil {
+			userID = r.policeNos[expr.User]
+		}
+		if orgID, ok := r.userOrgs[userID]; ok {
+			users = r.leaderOf(orgID)
+		}
+	case domain_service.AssigneeKindOrgHead:
+		orgID, err := strconv.Atoi(expr.Org)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: org must be an ID", errors_.ErrAssigneeNotResolved, expr.String())
+		}
+		if expr.Parent {
+			orgID = r.orgs[orgID].parentID
+		}
+		users = r.leaderOf(orgID)
+	case domain_service.AssigneeKindRole:
+		orgID, err := strconv.Atoi(expr.Org)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: org must be an ID", errors_.ErrAssigneeNotResolved, expr.String())
+		}
+		members := r.roles[roleKey(orgID, expr.Role)]
+		users = make([]int, len(members))
+		for userID, order := range members {
+			users[order] = userID
+		}
+	default:
+		return nil, fmt.Errorf("unknown assignee expression kind %q", expr.Kind)
+	}
+
+	if len(users) == 0 {
+		return nil, fmt.Errorf("%w: %s", errors_.ErrAssigneeNotResolved, expr.String())
+	}
+	return users, nil
+}
+
+// leaderOf 组织负责人，组织不存在或未设置负责人时返回空
+func (r *InMemoryAssigneeResolver) leaderOf(orgID int) []int {
+	if org, ok := r.orgs[orgID]; ok && org.leader != 0 {
+		return []int{org.leader}
+	}
+	return nil
+}
+
+func roleKey(orgID int, role string) string {
+	return fmt.Sprintf("%d@%s", orgID, role)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+
+	"jxt-evidence-system/process-management/internal/application/command"
+	delegation_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/delegation"
+	delegation_repository "jxt-evidence-system/process-management/internal/domain/aggregate/delegation/repository"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	"jxt-evidence-system/process-management/shared/common/errors"
+)
+
+// delegationService 委托规则服务
+type delegationService struct {
+	delegationRepo delegation_repository.DelegationRepository
+}
+
+// CreateDelegation 创建委托规则
+func (h *delegationService) CreateDelegation(ctx context.Context, cmd *command.CreateDelegationCommand) (string, error) {
+	rule, err := delegation_aggregate.NewDelegationRule(cmd.UserID, cmd.DelegateID, cmd.StartAt, cmd.EndAt, cmd.WorkflowIDs, cmd.Reason)
+	if err != nil {
+		return "", err
+	}
+	if err := h.delegationRepo.Save(ctx, rule); err != nil {
+		return "", err
+	}
+	return rule.RuleID.String(), nil
+}
+
+// UpdateDelegation 修改委托规则
+func (h *delegationService) UpdateDelegation(ctx context.Context, cmd *command.UpdateDelegationCommand) error {
+	rule, err := h.delegationRepo.FindByID(ctx, cmd.ID)
+	if err != nil {
+		return err
+	}
+	if rule.UserID != cmd.UserID {
+		return errors.ErrUnauthorized
+	}
+	if err := rule.Update(cmd.DelegateID, cmd.StartAt, cmd.EndAt, cmd.WorkflowIDs, cmd.Reason); err != nil {
+		return err
+	}
+	return h.delegationRepo.Update(ctx, rule)
+}
+
+// DeleteDelegation 删除委托规则
+func (h *delegationService) DeleteDelegation(ctx context.Context, cmd *command.DeleteDelegationCommand) error {
+	rule, err := h.delegationRepo.FindByID(ctx, cmd.ID)
+	if err != nil {
+		return err
+	}
+	if rule.UserID != cmd.UserID {
+		return errors.ErrUnauthorized
+	}
+	return h.delegationRepo.Delete(ctx, cmd.ID)
+}
+
+// GetDelegationByID 获取委托规则
+func (h *delegationService) GetDelegationByID(ctx context.Context, id valueobject.DelegationID) (*delegation_aggregate.DelegationRule, error) {
+	return h.delegationRepo.FindByID(ctx, id)
+}
+
+// GetDelegationsByUser 获取用户设置的全部委托规则
+func (h *delegationService) GetDelegationsByUser(ctx context.Context, userID int) ([]*delegation_aggregate.DelegationRule, error) {
+	return h.delegationRepo.FindByUserID(ctx, userID)
+}
@@ -1,12 +1,17 @@
 package service
 
 import (
+	"os"
+	"strings"
 	"sync"
 
 	"jxt-evidence-system/process-management/internal/application/service/port"
+	delegation_repository "jxt-evidence-system/process-management/internal/domain/aggregate/delegation/repository"
 	instance_repository "jxt-evidence-system/process-management/internal/domain/aggregate/instance/repository"
 	task_repository "jxt-evidence-system/process-management/internal/domain/aggregate/task/repository"
 	websocket "jxt-evidence-system/process-management/internal/domain/aggregate/task/websocket"
+	timer_repository "jxt-evidence-system/process-management/internal/domain/aggregate/timer/repository"
+	token_repository "jxt-evidence-system/process-management/internal/domain/aggregate/token/repository"
 	workflow_repository "jxt-evidence-system/process-management/internal/domain/aggregate/workflow/repository"
 	domain_service "jxt-evidence-system/process-management/internal/domain/service"
 	"jxt-evidence-system/process-management/shared/common/di"
@@ -32,25 +37,36 @@ func RegisterDependencies() {
 func init() {
 	registrations = append(registrations,
 		registerTaskServiceDependencies,
+		registerDelegationServiceDependencies,
 		registerWorkflowServiceDependencies,
 		registerInstanceServiceDependencies,
 		registerNotificationServiceDependencies,
+		registerProcessHandlerRegistryDependencies,
 		registerWorkflowEngineServiceDependencies,
+		registerTimerSchedulerDependencies,
 	)
 }
 
 func registerTaskServiceDependencies() {
 	err := di.Provide(func(
 		taskRepo task_repository.TaskRepository,
+		instanceRepo instance_repository.WorkflowInstanceRepository,
 		historyRepo task_repository.TaskHistoryRepository,
 		workflowRepo workflow_repository.WorkflowRepository,
+		timerRepo timer_repository.TimerRepository,
 		engineService port.WorkflowEngineService,
+		userDirectory port.UserDirectory,
+		notificationSvc port.NotificationService,
 	) port.TaskService {
 		return &taskService{
-			taskRepo:      taskRepo,
-			historyRepo:   historyRepo,
-			workflowRepo:  workflowRepo,
-			engineService: engineService,
+			taskRepo:        taskRepo,
+			instanceRepo:    instanceRepo,
+			historyRepo:     historyRepo,
+			workflowRepo:    workflowRepo,
+			timerRepo:       timerRepo,
+			engineService:   engineService,
+			userDirectory:   userDirectory,
+			notificationSvc: notificationSvc,
 		}
 	})
 	if err != nil {
@@ -58,12 +74,29 @@ func registerTaskServiceDependencies() {
 	}
 }
 
+func registerDelegationServiceDependencies() {
+	err := di.Provide(func(delegationRepo delegation_repository.DelegationRepository) port.DelegationService {
+		return &delegationService{
+			delegationRepo: delegationRepo,
+		}
+	})
+	if err != nil {
+		logger.Fatalf("Failed to provide DelegationService: %v", err)
+	}
+}
+
 func registerWorkflowServiceDependencies() {
 	err := di.Provide(func(
 		workflowRepo workflow_repository.WorkflowRepository,
+		versionRepo workflow_repository.WorkflowVersionRepository,
+		domainService *domain_service.WorkflowDomainService,
+		txManager port.TransactionManager,
 	) port.WorkflowService {
 		return &workflowService{
-			repo: workflowRepo,
+			repo:          workflowRepo,
+			versionRepo:   versionRepo,
+			domainService: domainService,
+			txManager:     txManager,
 		}
 	})
 	if err != nil {
@@ -75,16 +108,26 @@ func registerInstanceServiceDependencies() {
 	err := di.Provide(func(
 		workflowService port.WorkflowService,
 		instanceRepo instance_repository.WorkflowInstanceRepository,
+		taskRepo task_repository.TaskRepository,
+		historyRepo task_repository.TaskHistoryRepository,
+		timerRepo timer_repository.TimerRepository,
+		tokenRepo token_repository.TokenRepository,
 		engineService port.WorkflowEngineService,
 		taskService port.TaskService,
 		domainService *domain_service.WorkflowDomainService,
+		txManager port.TransactionManager,
 	) port.InstanceService {
 		return &instanceService{
 			workflowService: workflowService,
 			instanceRepo:    instanceRepo,
+			taskRepo:        taskRepo,
+			historyRepo:     historyRepo,
+			timerRepo:       timerRepo,
+			tokenRepo:       tokenRepo,
 			engineService:   engineService,
 			taskService:     taskService,
 			domainService:   *domainService,
+			txManager:       txManager,
 		}
 	})
 	if err != nil {
@@ -101,17 +144,56 @@ func registerNotificationServiceDependencies() {
 	}
 }
 
+// 自动化步骤处理器的依赖注入
+// httpCall 只能访问 PROCESS_HTTP_ALLOWED_HOSTS 中配置的主机（逗号分隔），未配置时拒绝所有请求
+func registerProcessHandlerRegistryDependencies() {
+	err := di.Provide(func() port.ProcessHandlerRegistry {
+		return NewProcessHandlerRegistry(strings.Split(os.Getenv("PROCESS_HTTP_ALLOWED_HOSTS"), ","))
+	})
+	if err != nil {
+		logger.Fatalf("Failed to provide ProcessHandlerRegistry: %v", err)
+	}
+}
+
 func registerWorkflowEngineServiceDependencies() {
 	err := di.Provide(func(
 		workflowRepo workflow_repository.WorkflowRepository,
+		versionRepo workflow_repository.WorkflowVersionRepository,
 		instanceRepo instance_repository.WorkflowInstanceRepository,
 		taskRepo task_repository.TaskRepository,
+		historyRepo task_repository.TaskHistoryRepository,
+		timerRepo timer_repository.TimerRepository,
+		tokenRepo token_repository.TokenRepository,
 		domainService *domain_service.WorkflowDomainService,
 		notificationSvc port.NotificationService,
+		processHandlers port.ProcessHandlerRegistry,
+		txManager port.TransactionManager,
+		assigneeResolver port.AssigneeResolver,
+		delegationRepo delegation_repository.DelegationRepository,
 	) port.WorkflowEngineService {
-		return NewWorkflowEngineServiceWithNotification(workflowRepo, instanceRepo, taskRepo, *domainService, notificationSvc)
+		engine := NewWorkflowEngineServiceWithNotification(workflowRepo, versionRepo, instanceRepo, taskRepo, historyRepo, timerRepo, tokenRepo, *domainService, notificationSvc)
+		engine.SetProcessHandlerRegistry(processHandlers)
+		engine.SetTransactionManager(txManager)
+		engine.SetDelegationRepository(delegationRepo)
+		// 未接入用户服务时保留默认的内存解析器
+		if assigneeResolver != nil {
+			engine.SetAssigneeResolver(assigneeResolver)
+		}
+		return engine
 	})
 	if err != nil {
 		logger.Fatalf("Failed to provide WorkflowEngineService: %v", err)
 	}
 }
+
+func registerTimerSchedulerDependencies() {
+	err := di.Provide(func(
+		timerRepo timer_repository.TimerRepository,
+		engineService port.WorkflowEngineService,
+	) port.TimerScheduler {
+		return NewTimerScheduler(timerRepo, engineService)
+	})
+	if err != nil {
+		logger.Fatalf("Failed to provide TimerScheduler: %v", err)
+	}
+}
@@ -2,11 +2,16 @@ package service
 
 import (
 	"context"
+	"fmt"
 
 	"jxt-evidence-system/process-management/internal/application/command"
 	"jxt-evidence-system/process-management/internal/application/service/port"
 	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
 	instance_repository "jxt-evidence-system/process-management/internal/domain/aggregate/instance/repository"
+	task_repository "jxt-evidence-system/process-management/internal/domain/aggregate/task/repository"
+	timer_repository "jxt-evidence-system/process-management/internal/domain/aggregate/timer/repository"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	token_repository "jxt-evidence-system/process-management/internal/domain/aggregate/token/repository"
 	domain_service "jxt-evidence-system/process-management/internal/domain/service"
 	"jxt-evidence-system/process-management/internal/domain/valueobject"
 	"jxt-evidence-system/process-management/shared/common/errors"
@@ -18,21 +23,25 @@ import (
 type instanceService struct {
 	workflowService port.WorkflowService
 	instanceRepo    instance_repository.WorkflowInstanceRepository
+	taskRepo        task_repository.TaskRepository
+	historyRepo     task_repository.TaskHistoryRepository
+	timerRepo       timer_repository.TimerRepository
+	tokenRepo       token_repository.TokenRepository
 	taskService     port.TaskService
 	engineService   port.WorkflowEngineService
 	domainService   domain_service.WorkflowDomainService
+	txManager       port.TransactionManager
 }
 
-// CancelInstance 取消运行中的实例（仅标记状态，不删除记录）
+// CancelInstance 取消运行中或挂起的实例（不删除记录），未处理的任务和定时器一并取消
 func (h *instanceService) CancelInstance(ctx context.Context, cmd *command.CancelInstanceCommand) error {
-	instance, err := h.instanceRepo.FindByID(ctx, cmd.ID)
-	if err != nil {
-		return err
+	if h.engineService == nil {
+		return fmt.Errorf("workflow engine is not available")
 	}
-	if err := instance.Cancel(); err != nil {
+	if err := h.engineService.CancelInstance(ctx, cmd.ID, cmd.UpdateBy, cmd.Reason); err != nil {
 		return err
 	}
-	return h.instanceRepo.Update(ctx, instance)
+	return h.saveInstanceHistory(ctx, cmd.ID, "流程取消", cmd.UpdateBy, "cancel_instance", cmd.Reason)
 }
 
 // Handle 处理命令
@@ -68,7 +77,19 @@ func (h *instanceService) GetInstanceDetailByID(ctx context.Context, id valueobj
 	if err != nil {
 		return nil, err
 	}
-	return h.domainService.BuildInstanceDetail(tasks), nil
+	histories, err := h.historyRepo.FindByInstanceID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return h.domainService.BuildInstanceDetail(tasks, histories), nil
+}
+
+// GetInstanceTokens 获取实例的执行令牌，状态为 active 的令牌所在步骤即实例当前正在执行的步骤
+func (h *instanceService) GetInstanceTokens(ctx context.Context, id valueobject.InstanceID) ([]*token_aggregate.ExecutionToken, error) {
+	if _, err := h.instanceRepo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return h.tokenRepo.FindByInstanceID(ctx, id)
 }
 
 // ListInstancesByWorkflowID 列出工作流的所有实例
@@ -83,6 +104,48 @@ func (h *instanceService) GetPage(ctx context.Context, query *command.InstancePa
 	return h.instanceRepo.GetPage(ctx, query)
 }
 
+// GetStartedInstances 查询用户发起的实例及其当前步骤，query.InitiatorID 为发起人
+func (h *instanceService) GetStartedInstances(ctx context.Context, query *command.InstancePagedQuery) ([]*command.StartedInstanceItem, int, error) {
+	instances, total, err := h.instanceRepo.GetPage(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]*command.StartedInstanceItem, 0, len(instances))
+	for _, instance := range instances {
+		item := &command.StartedInstanceItem{
+			InstanceID:   instance.InstanceId,
+			InstanceNo:   instance.InstanceNo,
+			WorkflowID:   instance.WorkflowID,
+			WorkflowName: instance.WorkflowName,
+			Status:       instance.Status,
+			ErrorMessage: instance.ErrorMessage,
+			StartedAt:    instance.StartedAt,
+			CompletedAt:  instance.CompletedAt,
+			CurrentSteps: []command.CurrentStep{},
+		}
+		if instance.Status == status.InstanceStatusRunning || instance.Status == status.InstanceStatusSuspended {
+			tasks, err := h.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+			if err != nil {
+				return nil, 0, err
+			}
+			for _, task := range tasks {
+				if task.Status != status.TaskStatusPending && task.Status != status.TaskStatusWaiting {
+					continue
+				}
+				item.CurrentSteps = append(item.CurrentSteps, command.CurrentStep{
+					StepID:   task.TaskKey,
+					StepName: task.TaskName,
+					TaskID:   task.TaskID,
+					Assignee: task.Assignee,
+				})
+			}
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
 // CountInstanceByWorkflow 统计工作流的实例数量
 func (h *instanceService) CountInstanceByWorkflow(ctx context.Context, workflowID valueobject.WorkflowID) (int64, error) {
 	return h.instanceRepo.CountByWorkflowID(ctx, workflowID)
@@ -98,8 +161,9 @@ func (h *instanceService) StartWorkflowInstance(ctx context.Context, cmd *comman
 		return "", errors.ErrInvalidStatusTransition
 	}
 
-	// 创建工作流实例
-	instance := instance_aggregate.NewWorkflowInstance(cmd.ID, cmd.Input)
+	// 创建工作流实例，绑定当前发布的版本
+	instance := instance_aggregate.NewWorkflowInstance(cmd.ID, wf.CurrentVersion, cmd.Input)
+	instance.InitiatorID = cmd.InitiatorID
 
 	// 保存实例
 	if err := h.instanceRepo.Save(ctx, instance); err != nil {
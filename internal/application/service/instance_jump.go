@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"jxt-evidence-system/process-management/internal/application/command"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+)
+
+// JumpInstance 运维人员将实例跳转到指定步骤，并记录操作人、跳转前后位置和原因
+func (h *instanceService) JumpInstance(ctx context.Context, cmd *command.JumpInstanceCommand) (*command.InstanceJumpResult, error) {
+	if h.engineService == nil {
+		return nil, fmt.Errorf("workflow engine is not available")
+	}
+
+	result, err := h.engineService.JumpToStep(ctx, cmd.ID, cmd.TargetStepID)
+	if result == nil {
+		return nil, err
+	}
+
+	// 审计记录：实例级历史，不关联具体任务；目标步骤执行失败时跳转也已生效，同样记录
+	history := task_aggregate.NewTaskHistory(valueobject.TaskID{}, cmd.ID, "流程跳转", fmt.Sprintf("%d", cmd.UpdateBy), "jump")
+	history.Comment = cmd.Reason
+	history.Output, _ = json.Marshal(map[string]interface{}{
+		"from":           result.FromSteps,
+		"to":             result.ToStepID,
+		"cancelledTasks": result.CancelledTasks,
+	})
+	if saveErr := h.historyRepo.Save(ctx, history); saveErr != nil {
+		return nil, fmt.Errorf("failed to save jump history: %w", saveErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[InstanceService] Instance %s jumped to step %s by user %d", cmd.ID.String(), cmd.TargetStepID, cmd.UpdateBy)
+	return result, nil
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"jxt-evidence-system/process-management/internal/application/command"
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// MigrateInstances 将运行中的实例迁移到工作流的指定版本
+// 每个待办任务的 TaskKey 都必须能映射到目标版本的步骤，否则该实例不迁移；
+// DryRun 时只返回迁移报告，不做任何修改
+func (h *instanceService) MigrateInstances(ctx context.Context, cmd *command.MigrateInstancesCommand) (*command.InstanceMigrationReport, error) {
+	wf, err := h.workflowService.GetWorkflowByID(ctx, cmd.WorkflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	toVersion := cmd.ToVersion
+	if toVersion == 0 {
+		toVersion = wf.CurrentVersion
+	}
+	if toVersion == 0 {
+		return nil, fmt.Errorf("%w: workflow has no published version", errors_.ErrInvalidMigrationPlan)
+	}
+	version, err := h.workflowService.GetVersion(ctx, wf.WorkflowID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+	var target domain_service.WorkflowDefinitionStruct
+	if err := json.Unmarshal([]byte(version.Definition), &target); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow definition of version %d: %w", toVersion, err)
+	}
+	if err := h.domainService.ValidateStepMapping(cmd.StepMapping, &target); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors_.ErrInvalidMigrationPlan, err)
+	}
+
+	instances, err := h.findInstancesToMigrate(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &command.InstanceMigrationReport{
+		WorkflowID: wf.WorkflowID,
+		ToVersion:  toVersion,
+		DryRun:     cmd.DryRun,
+		Total:      len(instances),
+		Results:    make([]command.InstanceMigrationResult, 0, len(instances)),
+	}
+	for _, instance := range instances {
+		result := h.migrateInstance(ctx, instance, cmd, toVersion, &target)
+		switch result.Status {
+		case command.MigrationStatusMigrated, command.MigrationStatusPlanned:
+			report.Migrated++
+		case command.MigrationStatusSkipped:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	log.Printf("[InstanceService] Migration of workflow %s to version %d (dryRun=%v): %d migrated, %d skipped, %d failed",
+		wf.WorkflowID.String(), toVersion, cmd.DryRun, report.Migrated, report.Skipped, report.Failed)
+	return report, nil
+}
+
+// findInstancesToMigrate 指定实例时逐个加载，否则取工作流下全部运行中的实例
+func (h *instanceService) findInstancesToMigrate(ctx context.Context, cmd *command.MigrateInstancesCommand) ([]*instance_aggregate.WorkflowInstance, error) {
+	if len(cmd.InstanceIDs) == 0 {
+		return h.instanceRepo.FindRunningByWorkflowID(ctx, cmd.WorkflowID)
+	}
+
+	instances := make([]*instance_aggregate.WorkflowInstance, 0, len(cmd.InstanceIDs))
+	for _, id := range cmd.InstanceIDs {
+		instance, err := h.instanceRepo.FindByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find instance %s: %w", id.String(), err)
+		}
+		if !instance.WorkflowID.Equals(cmd.WorkflowID) {
+			return nil, fmt.Errorf("%w: instance %s does not belong to workflow %s", errors_.ErrInvalidMigrationPlan, id.String(), cmd.WorkflowID.String())
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// migrateInstance 校验并迁移单个实例
+func (h *instanceService) migrateInstance(ctx context.Context, instance *instance_aggregate.WorkflowInstance, cmd *command.MigrateInstancesCommand, toVersion int, target *domain_service.WorkflowDefinitionStruct) command.InstanceMigrationResult {
+	result := command.InstanceMigrationResult{
+		InstanceID:  instance.InstanceId,
+		FromVersion: instance.WorkflowVersion,
+		ToVersion:   toVersion,
+		Tasks:       []command.TaskMigration{},
+		Tokens:      []command.TokenMigration{},
+		Errors:      []string{},
+	}
+	fail := func(format string, args ...interface{}) command.InstanceMigrationResult {
+		result.Status = command.MigrationStatusFailed
+		result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+		return result
+	}
+
+	if instance.Status != status.InstanceStatusRunning {
+		return fail("instance is %s", instance.Status)
+	}
+	if instance.WorkflowVersion == toVersion {
+		result.Status = command.MigrationStatusSkipped
+		return result
+	}
+
+	tasks, err := h.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return fail("failed to find tasks: %v", err)
+	}
+	plan, problems := h.domainService.PlanTaskMigration(tasks, cmd.StepMapping, target)
+	result.Tasks = plan
+
+	tokens, err := h.tokenRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return fail("failed to find tokens: %v", err)
+	}
+	tokenPlan, tokenProblems := h.domainService.PlanTokenMigration(tokens, cmd.StepMapping, target)
+	result.Tokens = tokenPlan
+	problems = append(problems, tokenProblems...)
+
+	if len(problems) > 0 {
+		result.Status = command.MigrationStatusFailed
+		result.Errors = append(result.Errors, problems...)
+		return result
+	}
+	if cmd.DryRun {
+		result.Status = command.MigrationStatusPlanned
+		return result
+	}
+
+	if err := h.applyMigration(ctx, instance, tasks, plan, tokens, tokenPlan, toVersion, target, cmd.UpdateBy); err != nil {
+		return fail("%v", err)
+	}
+	result.Status = command.MigrationStatusMigrated
+	return result
+}
+
+// applyMigration 在一个事务中更新未处理任务和令牌的步骤、实例版本号，并写入审计记录
+// 任一写入失败时整体回滚，实例不会一部分停在旧版本、一部分在新版本
+func (h *instanceService) applyMigration(ctx context.Context, instance *instance_aggregate.WorkflowInstance, tasks []*task_aggregate.Task, plan []command.TaskMigration, tokens []*token_aggregate.ExecutionToken, tokenPlan []command.TokenMigration, toVersion int, target *domain_service.WorkflowDefinitionStruct, operator int) error {
+	byID := make(map[valueobject.TaskID]*task_aggregate.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.TaskID] = t
+	}
+
+	fromVersion := instance.WorkflowVersion
+	migrated := *instance
+	migrated.WorkflowVersion = toVersion
+	migrated.UpdatedAt = time.Now()
+	if operator != 0 {
+		migrated.UpdateBy = operator
+	}
+
+	err := runInTransaction(ctx, h.txManager, func(ctx context.Context) error {
+		for _, m := range plan {
+			if m.FromKey == m.ToKey {
+				continue
+			}
+			t := byID[m.TaskID]
+			step := h.domainService.FindStepOrParallelTaskByID(m.ToKey, target)
+			t.TaskKey = step.ID
+			if step.Name != "" {
+				t.TaskName = step.Name
+			}
+			t.UpdatedAt = time.Now()
+			if err := h.taskRepo.Update(ctx, t); err != nil {
+				return fmt.Errorf("failed to update task %s: %w", t.TaskID.String(), err)
+			}
+		}
+
+		if err := h.migrateTokens(ctx, tokens, tokenPlan); err != nil {
+			return err
+		}
+
+		if err := h.instanceRepo.Update(ctx, &migrated); err != nil {
+			return fmt.Errorf("failed to update instance: %w", err)
+		}
+
+		// 审计记录：实例级历史，不关联具体任务
+		history := task_aggregate.NewTaskHistory(valueobject.TaskID{}, instance.InstanceId, "版本迁移", fmt.Sprintf("%d", operator), "migrate")
+		history.Comment = fmt.Sprintf("workflow version %d -> %d", fromVersion, toVersion)
+		if output, err := json.Marshal(plan); err == nil {
+			history.Output = output
+		}
+		if err := h.historyRepo.Save(ctx, history); err != nil {
+			return fmt.Errorf("failed to save migration history: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*instance = migrated
+	return nil
+}
+
+// migrateTokens 按迁移计划更新令牌所在步骤，分支令牌的 ForkStepID 随父令牌所在的分支步骤一起映射
+func (h *instanceService) migrateTokens(ctx context.Context, tokens []*token_aggregate.ExecutionToken, plan []command.TokenMigration) error {
+	stepMapping := make(map[string]string, len(plan))
+	for _, m := range plan {
+		stepMapping[m.FromStepID] = m.ToStepID
+	}
+
+	for _, t := range tokens {
+		if !t.IsLive() {
+			continue
+		}
+		changed := false
+		if to, ok := stepMapping[t.StepID]; ok && to != t.StepID {
+			t.StepID = to
+			changed = true
+		}
+		if to, ok := stepMapping[t.ForkStepID]; ok && to != t.ForkStepID {
+			t.ForkStepID = to
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		t.UpdatedAt = time.Now()
+		if err := h.tokenRepo.Update(ctx, t); err != nil {
+			return fmt.Errorf("failed to update token %s: %w", t.TokenID.String(), err)
+		}
+	}
+	return nil
+}
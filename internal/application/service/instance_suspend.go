@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"jxt-evidence-system/process-management/internal/application/command"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+)
+
+// SuspendInstance 挂起实例并记录操作人和原因
+func (h *instanceService) SuspendInstance(ctx context.Context, cmd *command.SuspendInstanceCommand) error {
+	if h.engineService == nil {
+		return fmt.Errorf("workflow engine is not available")
+	}
+	if err := h.engineService.SuspendInstance(ctx, cmd.ID, cmd.Reason); err != nil {
+		return err
+	}
+	if err := h.saveInstanceHistory(ctx, cmd.ID, "流程挂起", cmd.UpdateBy, "suspend", cmd.Reason); err != nil {
+		return err
+	}
+
+	log.Printf("[InstanceService] Instance %s suspended by user %d", cmd.ID.String(), cmd.UpdateBy)
+	return nil
+}
+
+// ResumeInstance 恢复挂起的实例并记录操作人和原因
+func (h *instanceService) ResumeInstance(ctx context.Context, cmd *command.ResumeInstanceCommand) error {
+	if h.engineService == nil {
+		return fmt.Errorf("workflow engine is not available")
+	}
+	if err := h.engineService.ResumeInstance(ctx, cmd.ID, cmd.Reason); err != nil {
+		return err
+	}
+	if err := h.saveInstanceHistory(ctx, cmd.ID, "流程恢复", cmd.UpdateBy, "resume", cmd.Reason); err != nil {
+		return err
+	}
+
+	log.Printf("[InstanceService] Instance %s resumed by user %d", cmd.ID.String(), cmd.UpdateBy)
+	return nil
+}
+
+// saveInstanceHistory 记录不关联具体任务的实例级操作历史
+func (h *instanceService) saveInstanceHistory(ctx context.Context, instanceID valueobject.InstanceID, name string, operator int, action string, comment string) error {
+	history := task_aggregate.NewTaskHistory(valueobject.TaskID{}, instanceID, name, fmt.Sprintf("%d", operator), action)
+	history.Comment = comment
+	if err := h.historyRepo.Save(ctx, history); err != nil {
+		return fmt.Errorf("failed to save %s history: %w", action, err)
+	}
+	return nil
+}
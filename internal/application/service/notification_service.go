@@ -41,9 +41,13 @@ func (s *DefaultNotificationService) NotifyTaskCreated(ctx context.Context, task
 		"description": task.Description,
 	}
 
-	// 通知受让人
+	// 通知受让人，未指定处理人时通知候选用户认领
 	if task.Assignee != 0 {
 		s.wsNotifier.SendToUser(task.Assignee, "task_created", data)
+		return
+	}
+	for _, userID := range task.CandidateUsers() {
+		s.wsNotifier.SendToUser(userID, "task_claimable", data)
 	}
 
 }
@@ -96,6 +100,66 @@ func (s *DefaultNotificationService) NotifyTaskCompleted(ctx context.Context, ta
 	// TODO: 通知流程发起人
 }
 
+// NotifyTaskWithdrawn 通知任务因上一步撤回被收回
+func (s *DefaultNotificationService) NotifyTaskWithdrawn(ctx context.Context, task *task_aggregate.Task) {
+	if s.wsNotifier == nil {
+		return
+	}
+
+	log.Printf("[NotificationService] Notifying task withdrawn: %s", task.TaskID.String())
+
+	data := map[string]interface{}{
+		"taskId":     task.TaskID.String(),
+		"taskName":   task.TaskName,
+		"taskKey":    task.TaskKey,
+		"instanceId": task.InstanceID.String(),
+		"workflowId": task.WorkflowID.String(),
+		"assignee":   task.Assignee,
+		"status":     task.Status,
+	}
+
+	if task.Assignee != 0 {
+		s.wsNotifier.SendToUser(task.Assignee, "task_withdrawn", data)
+	}
+}
+
+// NotifyTaskSuspended 通知任务因实例挂起暂停处理
+func (s *DefaultNotificationService) NotifyTaskSuspended(ctx context.Context, task *task_aggregate.Task, reason string) {
+	s.notifyInstanceState(task, "task_suspended", reason)
+}
+
+// NotifyTaskResumed 通知任务因实例恢复可以继续处理
+func (s *DefaultNotificationService) NotifyTaskResumed(ctx context.Context, task *task_aggregate.Task, reason string) {
+	s.notifyInstanceState(task, "task_resumed", reason)
+}
+
+// NotifyTaskCancelled 通知任务因实例取消被关闭
+func (s *DefaultNotificationService) NotifyTaskCancelled(ctx context.Context, task *task_aggregate.Task, reason string) {
+	s.notifyInstanceState(task, "task_cancelled", reason)
+}
+
+// notifyInstanceState 实例挂起、恢复或取消时通知任务处理人
+func (s *DefaultNotificationService) notifyInstanceState(task *task_aggregate.Task, event string, reason string) {
+	if s.wsNotifier == nil || task.Assignee == 0 {
+		return
+	}
+
+	log.Printf("[NotificationService] Notifying %s: %s", event, task.TaskID.String())
+
+	data := map[string]interface{}{
+		"taskId":     task.TaskID.String(),
+		"taskName":   task.TaskName,
+		"taskKey":    task.TaskKey,
+		"instanceId": task.InstanceID.String(),
+		"workflowId": task.WorkflowID.String(),
+		"assignee":   task.Assignee,
+		"status":     task.Status,
+		"reason":     reason,
+	}
+
+	s.wsNotifier.SendToUser(task.Assignee, event, data)
+}
+
 // NotifyWorkflowCompleted 通知工作流已完成
 func (s *DefaultNotificationService) NotifyWorkflowCompleted(ctx context.Context, instance *instance_aggregate.WorkflowInstance) {
 	if s.wsNotifier == nil {
@@ -104,15 +168,18 @@ func (s *DefaultNotificationService) NotifyWorkflowCompleted(ctx context.Context
 
 	log.Printf("[NotificationService] Notifying workflow completed: %s", instance.InstanceId.String())
 
-	_ = map[string]interface{}{
+	data := map[string]interface{}{
 		"instanceId":  instance.InstanceId.String(),
+		"instanceNo":  instance.InstanceNo,
 		"workflowId":  instance.WorkflowID.String(),
 		"status":      instance.Status,
 		"completedAt": instance.CompletedAt,
 	}
 
-	// TODO: 通知流程发起人和相关人员
-	// 这里需要从实例输入中获取发起人信息
+	// 通知流程发起人
+	if instance.InitiatorID != 0 {
+		s.wsNotifier.SendToUser(instance.InitiatorID, "workflow_completed", data)
+	}
 	log.Printf("[NotificationService] Workflow completed notification sent")
 }
 
@@ -132,5 +199,17 @@ func (s *NoOpNotificationService) NotifyTaskAssigned(ctx context.Context, task *
 func (s *NoOpNotificationService) NotifyTaskCompleted(ctx context.Context, task *task_aggregate.Task) {
 }
 
+func (s *NoOpNotificationService) NotifyTaskWithdrawn(ctx context.Context, task *task_aggregate.Task) {
+}
+
+func (s *NoOpNotificationService) NotifyTaskSuspended(ctx context.Context, task *task_aggregate.Task, reason string) {
+}
+
+func (s *NoOpNotificationService) NotifyTaskResumed(ctx context.Context, task *task_aggregate.Task, reason string) {
+}
+
+func (s *NoOpNotificationService) NotifyTaskCancelled(ctx context.Context, task *task_aggregate.Task, reason string) {
+}
+
 func (s *NoOpNotificationService) NotifyWorkflowCompleted(ctx context.Context, instance *instance_aggregate.WorkflowInstance) {
 }
@@ -0,0 +1,14 @@
+package port
+
+import (
+	"context"
+
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+)
+
+// AssigneeResolver 处理人解析器
+// 通过用户/组织服务将 role:、leaderOf:、orgHead:、policeNo: 等处理人表达式解析为用户
+type AssigneeResolver interface {
+	// Resolve 解析处理人表达式，返回用户ID列表；角色表达式可能对应多个用户，解析不到用户时返回错误
+	Resolve(ctx context.Context, expr *domain_service.AssigneeExpression) ([]int, error)
+}
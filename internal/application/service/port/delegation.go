@@ -0,0 +1,27 @@
+package port
+
+import (
+	"context"
+
+	"jxt-evidence-system/process-management/internal/application/command"
+	delegation_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/delegation"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+)
+
+// DelegationService 委托规则服务
+type DelegationService interface {
+	// CreateDelegation 创建委托规则，返回规则ID
+	CreateDelegation(ctx context.Context, cmd *command.CreateDelegationCommand) (string, error)
+
+	// UpdateDelegation 修改委托规则
+	UpdateDelegation(ctx context.Context, cmd *command.UpdateDelegationCommand) error
+
+	// DeleteDelegation 删除委托规则
+	DeleteDelegation(ctx context.Context, cmd *command.DeleteDelegationCommand) error
+
+	// GetDelegationByID 获取委托规则
+	GetDelegationByID(ctx context.Context, id valueobject.DelegationID) (*delegation_aggregate.DelegationRule, error)
+
+	// GetDelegationsByUser 获取用户设置的全部委托规则
+	GetDelegationsByUser(ctx context.Context, userID int) ([]*delegation_aggregate.DelegationRule, error)
+}
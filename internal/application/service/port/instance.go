@@ -5,6 +5,7 @@ import (
 
 	"jxt-evidence-system/process-management/internal/application/command"
 	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
 	"jxt-evidence-system/process-management/internal/domain/valueobject"
 )
 
@@ -13,8 +14,14 @@ type InstanceService interface {
 	CancelInstance(ctx context.Context, cmd *command.CancelInstanceCommand) error
 	GetInstanceByID(ctx context.Context, id valueobject.InstanceID) (*instance_aggregate.WorkflowInstance, error)
 	GetInstanceDetailByID(ctx context.Context, id valueobject.InstanceID) ([]command.TaskHistoryItem, error)
+	GetInstanceTokens(ctx context.Context, id valueobject.InstanceID) ([]*token_aggregate.ExecutionToken, error)
 	GetInstancesByWorkflow(ctx context.Context, query *command.GetInstancesByWorkflowPagedQuery) ([]*instance_aggregate.WorkflowInstance, int, error)
 	GetPage(ctx context.Context, query *command.InstancePagedQuery) ([]*instance_aggregate.WorkflowInstance, int, error)
+	GetStartedInstances(ctx context.Context, query *command.InstancePagedQuery) ([]*command.StartedInstanceItem, int, error)
 	StartWorkflowInstance(ctx context.Context, cmd *command.StartWorkflowInstanceCommand) (string, error)
 	CountInstanceByWorkflow(ctx context.Context, workflowID valueobject.WorkflowID) (int64, error)
+	MigrateInstances(ctx context.Context, cmd *command.MigrateInstancesCommand) (*command.InstanceMigrationReport, error)
+	JumpInstance(ctx context.Context, cmd *command.JumpInstanceCommand) (*command.InstanceJumpResult, error)
+	SuspendInstance(ctx context.Context, cmd *command.SuspendInstanceCommand) error
+	ResumeInstance(ctx context.Context, cmd *command.ResumeInstanceCommand) error
 }
@@ -17,6 +17,18 @@ type NotificationService interface {
 	// NotifyTaskCompleted 通知任务已完成
 	NotifyTaskCompleted(ctx context.Context, task *task_aggregate.Task)
 
+	// NotifyTaskWithdrawn 通知任务因上一步撤回被收回
+	NotifyTaskWithdrawn(ctx context.Context, task *task_aggregate.Task)
+
+	// NotifyTaskSuspended 通知任务因实例挂起暂停处理
+	NotifyTaskSuspended(ctx context.Context, task *task_aggregate.Task, reason string)
+
+	// NotifyTaskResumed 通知任务因实例恢复可以继续处理
+	NotifyTaskResumed(ctx context.Context, task *task_aggregate.Task, reason string)
+
+	// NotifyTaskCancelled 通知任务因实例取消被关闭
+	NotifyTaskCancelled(ctx context.Context, task *task_aggregate.Task, reason string)
+
 	// NotifyWorkflowCompleted 通知工作流已完成
 	NotifyWorkflowCompleted(ctx context.Context, instance *instance_aggregate.WorkflowInstance)
 }
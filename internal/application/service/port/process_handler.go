@@ -0,0 +1,36 @@
+package port
+
+import (
+	"context"
+
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+)
+
+// ProcessContext 自动化步骤执行上下文
+type ProcessContext struct {
+	InstanceID  valueobject.InstanceID
+	WorkflowID  valueobject.WorkflowID
+	StepID      string
+	Params      map[string]interface{}            // 步骤参数
+	Input       map[string]interface{}            // 实例输入
+	StepOutputs map[string]map[string]interface{} // 已完成步骤的输出，按步骤ID索引
+}
+
+// ProcessHandler 自动化步骤处理器
+// 由步骤参数 params.handler 选择，返回的输出写入 Task.Output，后续条件可通过 ${step_id.field} 引用
+type ProcessHandler interface {
+	// Name 处理器名称，对应 params.handler
+	Name() string
+
+	// Handle 执行处理逻辑，返回错误时按步骤的 retries 配置重试
+	Handle(ctx context.Context, pctx *ProcessContext) (map[string]interface{}, error)
+}
+
+// ProcessHandlerRegistry 自动化步骤处理器注册表
+type ProcessHandlerRegistry interface {
+	// Register 注册处理器，名称重复时返回错误
+	Register(handler ProcessHandler) error
+
+	// Get 根据名称获取处理器
+	Get(name string) (ProcessHandler, bool)
+}
@@ -19,6 +19,18 @@ type TaskService interface {
 
 	// 处理转办任务命令
 	DelegateTask(ctx context.Context, cmd *command.DelegateTaskCommand) error
+
+	// AddSigner 加签，返回加签人的任务
+	AddSigner(ctx context.Context, cmd *command.AddSignerCommand) (*task_aggregate.Task, error)
+
+	// ClaimTask 认领任务
+	ClaimTask(ctx context.Context, cmd *command.ClaimTaskCommand) error
+
+	// UnclaimTask 放弃认领
+	UnclaimTask(ctx context.Context, cmd *command.UnclaimTaskCommand) error
+
+	// WithdrawTask 撤回已处理的任务
+	WithdrawTask(ctx context.Context, cmd *command.WithdrawTaskCommand) error
 	// Handle 处理创建任务命令
 	CreateTask(ctx context.Context, cmd *command.CreateTaskCommand) (string, error)
 
@@ -31,6 +43,9 @@ type TaskService interface {
 	// GetTodoTasks 查询待办任务
 	GetTodoTasks(ctx context.Context, userID int, query *command.TodoTaskPagedQuery) ([]*task_aggregate.Task, int, error)
 
+	// GetClaimableTasks 查询用户可认领的任务
+	GetClaimableTasks(ctx context.Context, userID int, userGroups []int, query *command.ClaimableTaskPagedQuery) ([]*task_aggregate.Task, int, error)
+
 	// GetDoneTasks 查询已办任务
 	GetDoneTasks(ctx context.Context, userID int, query *command.DoneTaskPagedQuery) ([]*task_aggregate.Task, int, error)
 
@@ -0,0 +1,8 @@
+package port
+
+// TimerScheduler 定时器调度器
+// 随服务启动，周期性扫描到期的定时器并交给工作流引擎处理
+type TimerScheduler interface {
+	Start()
+	Stop()
+}
@@ -0,0 +1,9 @@
+package port
+
+import "context"
+
+// TransactionManager 事务管理器
+// fn 内通过 ctx 调用的仓储操作在同一个数据库事务中执行
+type TransactionManager interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
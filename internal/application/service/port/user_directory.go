@@ -0,0 +1,9 @@
+package port
+
+import "context"
+
+// UserDirectory 用户目录，通过用户服务校验用户
+type UserDirectory interface {
+	// UserExists 用户是否存在
+	UserExists(ctx context.Context, userID int) (bool, error)
+}
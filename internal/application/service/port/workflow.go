@@ -5,6 +5,7 @@ import (
 
 	"jxt-evidence-system/process-management/internal/application/command"
 	workflow_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/workflow"
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
 	"jxt-evidence-system/process-management/internal/domain/valueobject"
 )
 
@@ -22,4 +23,14 @@ type WorkflowService interface {
 	GetAllWorkflow(ctx context.Context) ([]*workflow_aggregate.Workflow, error)
 	CountWorkflows(ctx context.Context) (int64, error)
 	UpdateWorkflow(ctx context.Context, cmd *command.UpdateWorkflowCommand) error
+	ValidateDefinition(ctx context.Context, definition string) []domain_service.DefinitionError
+
+	// 版本管理
+	GetVersions(ctx context.Context, id valueobject.WorkflowID) ([]*workflow_aggregate.WorkflowVersion, error)
+	GetVersion(ctx context.Context, id valueobject.WorkflowID, version int) (*workflow_aggregate.WorkflowVersion, error)
+	CompareVersions(ctx context.Context, cmd *command.CompareWorkflowVersionsCommand) (*command.WorkflowVersionDiff, error)
+
+	// BPMN 2.0 导入导出
+	ImportBpmn(ctx context.Context, cmd *command.ImportBpmnCommand) (*command.BpmnImportResult, error)
+	ExportBpmn(ctx context.Context, cmd *command.ExportBpmnCommand) ([]byte, error)
 }
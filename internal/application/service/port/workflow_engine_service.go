@@ -3,7 +3,10 @@ package port
 import (
 	"context"
 
+	"jxt-evidence-system/process-management/internal/application/command"
 	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	timer_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/timer"
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
 	"jxt-evidence-system/process-management/internal/domain/valueobject"
 )
 
@@ -12,4 +15,13 @@ type WorkflowEngineService interface {
 	StartInstance(ctx context.Context, instanceID valueobject.InstanceID) error
 	ContinueAfterTask(ctx context.Context, task *task_aggregate.Task) error
 	RejectAndGoBack(ctx context.Context, task *task_aggregate.Task) error
+	ValidateRejectTarget(ctx context.Context, task *task_aggregate.Task) error
+	FindTaskStep(ctx context.Context, task *task_aggregate.Task) (*domain_service.StepDefinition, error)
+	WithdrawTask(ctx context.Context, task *task_aggregate.Task) error
+	AddSigner(ctx context.Context, source, added *task_aggregate.Task, history *task_aggregate.TaskHistory) error
+	JumpToStep(ctx context.Context, instanceID valueobject.InstanceID, stepID string) (*command.InstanceJumpResult, error)
+	SuspendInstance(ctx context.Context, instanceID valueobject.InstanceID, reason string) error
+	ResumeInstance(ctx context.Context, instanceID valueobject.InstanceID, reason string) error
+	CancelInstance(ctx context.Context, instanceID valueobject.InstanceID, operator int, reason string) error
+	HandleTimer(ctx context.Context, timer *timer_aggregate.Timer) error
 }
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"jxt-evidence-system/process-management/internal/application/service/port"
+)
+
+// DefaultProcessHandler 未配置 params.handler 时使用的处理器
+const DefaultProcessHandler = "noop"
+
+// processHandlerRegistry 自动化步骤处理器注册表实现
+type processHandlerRegistry struct {
+	mu       sync.RWMutex
+	handlers map[string]port.ProcessHandler
+}
+
+// NewProcessHandlerRegistry 创建处理器注册表，并注册内置处理器
+// httpAllowedHosts 为 httpCall 处理器允许访问的主机白名单
+func NewProcessHandlerRegistry(httpAllowedHosts []string) port.ProcessHandlerRegistry {
+	registry := &processHandlerRegistry{
+		handlers: make(map[string]port.ProcessHandler),
+	}
+	for _, handler := range []port.ProcessHandler{
+		&noopProcessHandler{},
+		&setVariablesProcessHandler{},
+		newHTTPCallProcessHandler(httpAllowedHosts),
+	} {
+		_ = registry.Register(handler)
+	}
+	return registry
+}
+
+// Register 注册处理器
+func (r *processHandlerRegistry) Register(handler port.ProcessHandler) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.handlers[handler.Name()]; ok {
+		return fmt.Errorf("process handler already registered: %s", handler.Name())
+	}
+	r.handlers[handler.Name()] = handler
+	return nil
+}
+
+// Get 根据名称获取处理器
+func (r *processHandlerRegistry) Get(name string) (port.ProcessHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	handler, ok := r.handlers[name]
+	return handler, ok
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"jxt-evidence-system/process-management/internal/application/service/port"
+)
+
+// noopProcessHandler 空操作处理器，直接执行成功
+type noopProcessHandler struct{}
+
+func (h *noopProcessHandler) Name() string {
+	return "noop"
+}
+
+func (h *noopProcessHandler) Handle(ctx context.Context, pctx *port.ProcessContext) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+// setVariablesProcessHandler 设置变量处理器
+// params.variables 中的值支持 ${variable} 和 ${step_id.field} 引用，结果作为步骤输出
+type setVariablesProcessHandler struct{}
+
+func (h *setVariablesProcessHandler) Name() string {
+	return "setVariables"
+}
+
+func (h *setVariablesProcessHandler) Handle(ctx context.Context, pctx *port.ProcessContext) (map[string]interface{}, error) {
+	variables, ok := pctx.Params["variables"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("setVariables: params.variables must be an object")
+	}
+
+	output := make(map[string]interface{}, len(variables))
+	for k, v := range variables {
+		output[k] = resolveProcessValue(v, pctx)
+	}
+	return output, nil
+}
+
+// httpCallProcessHandler HTTP 调用处理器，用于调用本地或内网的其他微服务
+// params: url（必填）、method（默认POST）、headers、body（默认传实例输入）、timeout（秒，默认10）
+// 只允许访问白名单中的主机，白名单为空时拒绝所有请求
+type httpCallProcessHandler struct {
+	client       *http.Client
+	allowedHosts map[string]struct{}
+}
+
+const defaultHTTPCallTimeout = 10 // 秒
+
+// newHTTPCallProcessHandler 创建 HTTP 调用处理器
+// allowedHosts 为允许访问的主机，"host" 匹配该主机的任意端口，"host:port" 只匹配指定端口
+func newHTTPCallProcessHandler(allowedHosts []string) *httpCallProcessHandler {
+	h := &httpCallProcessHandler{
+		allowedHosts: make(map[string]struct{}, len(allowedHosts)),
+	}
+	for _, host := range allowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			h.allowedHosts[host] = struct{}{}
+		}
+	}
+	// 重定向目标同样需要在白名单中
+	h.client = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("httpCall: stopped after 10 redirects")
+			}
+			return h.checkURL(req.URL)
+		},
+	}
+	return h
+}
+
+// checkURL 只允许 http/https 协议和白名单中的主机
+func (h *httpCallProcessHandler) checkURL(u *neturl.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("httpCall: unsupported url scheme: %q", u.Scheme)
+	}
+	host := strings.ToLower(u.Host)
+	if _, ok := h.allowedHosts[host]; ok {
+		return nil
+	}
+	if _, ok := h.allowedHosts[strings.ToLower(u.Hostname())]; ok {
+		return nil
+	}
+	return fmt.Errorf("httpCall: host %s is not allowed", u.Host)
+}
+
+func (h *httpCallProcessHandler) Name() string {
+	return "httpCall"
+}
+
+func (h *httpCallProcessHandler) Handle(ctx context.Context, pctx *port.ProcessContext) (map[string]interface{}, error) {
+	url, _ := resolveProcessValue(pctx.Params["url"], pctx).(string)
+	if url == "" {
+		return nil, fmt.Errorf("httpCall: params.url is required")
+	}
+	target, err := neturl.Parse(url)
+	if err != nil {
+		return nil, fmt.Errorf("httpCall: invalid url: %w", err)
+	}
+	if err := h.checkURL(target); err != nil {
+		return nil, err
+	}
+
+	method := http.MethodPost
+	if m, ok := pctx.Params["method"].(string); ok && m != "" {
+		method = strings.ToUpper(m)
+	}
+
+	timeout := defaultHTTPCallTimeout
+	if t, ok := pctx.Params["timeout"].(float64); ok && t > 0 {
+		timeout = int(t)
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	var body io.Reader
+	if method != http.MethodGet {
+		payload := pctx.Params["body"]
+		if payload == nil {
+			payload = map[string]interface{}{
+				"instanceId": pctx.InstanceID.String(),
+				"stepId":     pctx.StepID,
+				"input":      pctx.Input,
+			}
+		}
+		data, err := json.Marshal(resolveProcessValue(payload, pctx))
+		if err != nil {
+			return nil, fmt.Errorf("httpCall: failed to marshal body: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("httpCall: failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if headers, ok := pctx.Params["headers"].(map[string]interface{}); ok {
+		for k, v := range headers {
+			req.Header.Set(k, fmt.Sprintf("%v", resolveProcessValue(v, pctx)))
+		}
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("httpCall: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("httpCall: failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("httpCall: %s %s returned %d: %s", method, url, resp.StatusCode, truncate(string(respBody), 200))
+	}
+
+	// 响应为JSON对象时作为步骤输出，否则原样放入 body 字段
+	output := make(map[string]interface{})
+	if err := json.Unmarshal(respBody, &output); err != nil || output == nil {
+		output = map[string]interface{}{"body": string(respBody)}
+	}
+	output["statusCode"] = resp.StatusCode
+	return output, nil
+}
+
+// resolveProcessValue 解析处理器参数中的变量引用
+// 字符串整体为 ${variable} 或 ${step_id.field} 时替换为对应值，对象和数组递归处理
+func resolveProcessValue(value interface{}, pctx *port.ProcessContext) interface{} {
+	switch v := value.(type) {
+	case string:
+		if !strings.HasPrefix(v, "${") || !strings.HasSuffix(v, "}") {
+			return v
+		}
+		varPath := strings.TrimSuffix(strings.TrimPrefix(v, "${"), "}")
+		if stepID, field, ok := strings.Cut(varPath, "."); ok {
+			if output, ok := pctx.StepOutputs[stepID]; ok {
+				if val, ok := output[field]; ok {
+					return val
+				}
+			}
+			return nil
+		}
+		if val, ok := pctx.Input[varPath]; ok {
+			return val
+		}
+		return nil
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			resolved[k] = resolveProcessValue(item, pctx)
+		}
+		return resolved
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved[i] = resolveProcessValue(item, pctx)
+		}
+		return resolved
+	default:
+		return v
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"jxt-evidence-system/process-management/internal/application/service/port"
+)
+
+// namedProcessHandler 只用于注册表测试的处理器
+type namedProcessHandler struct{ name string }
+
+func (h *namedProcessHandler) Name() string { return h.name }
+
+func (h *namedProcessHandler) Handle(ctx context.Context, pctx *port.ProcessContext) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+// serverHost 返回测试服务器的 host:port
+func serverHost(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	u, err := neturl.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parse server url: %v", err)
+	}
+	return u.Host
+}
+
+func TestProcessHandlerRegistry(t *testing.T) {
+	registry := NewProcessHandlerRegistry(nil)
+	for _, name := range []string{DefaultProcessHandler, "setVariables", "httpCall"} {
+		if _, ok := registry.Get(name); !ok {
+			t.Errorf("built-in handler %s not registered", name)
+		}
+	}
+	if _, ok := registry.Get("missing"); ok {
+		t.Errorf("Get(missing) found a handler")
+	}
+
+	if err := registry.Register(&namedProcessHandler{name: "custom"}); err != nil {
+		t.Fatalf("Register(custom) error = %v", err)
+	}
+	if handler, ok := registry.Get("custom"); !ok || handler.Name() != "custom" {
+		t.Errorf("Get(custom) = %v, %v", handler, ok)
+	}
+	if err := registry.Register(&namedProcessHandler{name: "noop"}); err == nil {
+		t.Errorf("Register(noop) should reject duplicate names")
+	}
+}
+
+func TestNoopProcessHandler(t *testing.T) {
+	output, err := (&noopProcessHandler{}).Handle(context.Background(), &port.ProcessContext{})
+	if err != nil || output != nil {
+		t.Fatalf("Handle() = %v, %v, want nil, nil", output, err)
+	}
+}
+
+func TestSetVariablesProcessHandler(t *testing.T) {
+	pctx := &port.ProcessContext{
+		Params: map[string]interface{}{"variables": map[string]interface{}{
+			"amount":  "${amount}",
+			"level":   "${review.level}",
+			"missing": "${review.missing}",
+			"fixed":   "归档",
+			"nested":  map[string]interface{}{"items": []interface{}{"${amount}", 1.0}},
+		}},
+		Input:       map[string]interface{}{"amount": 500.0},
+		StepOutputs: map[string]map[string]interface{}{"review": {"level": "A"}},
+	}
+
+	output, err := (&setVariablesProcessHandler{}).Handle(context.Background(), pctx)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"amount":  500.0,
+		"level":   "A",
+		"missing": nil,
+		"fixed":   "归档",
+		"nested":  map[string]interface{}{"items": []interface{}{500.0, 1.0}},
+	}
+	if !reflect.DeepEqual(output, want) {
+		t.Errorf("Handle() = %v, want %v", output, want)
+	}
+
+	if _, err := (&setVariablesProcessHandler{}).Handle(context.Background(), &port.ProcessContext{Params: map[string]interface{}{}}); err == nil {
+		t.Errorf("Handle() without params.variables should fail")
+	}
+}
+
+func TestHTTPCallProcessHandler(t *testing.T) {
+	var gotMethod, gotToken string
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotToken = r.Header.Get("X-Token")
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		switch r.URL.Path {
+		case "/json":
+			_, _ = w.Write([]byte(`{"approved":true}`))
+		case "/text":
+			_, _ = w.Write([]byte("ok"))
+		default:
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	handler := newHTTPCallProcessHandler([]string{serverHost(t, server)})
+	pctx := &port.ProcessContext{
+		StepID: "sync",
+		Params: map[string]interface{}{
+			"url":     server.URL + "/json",
+			"headers": map[string]interface{}{"X-Token": "${token}"},
+			"body":    map[string]interface{}{"amount": "${amount}"},
+		},
+		Input: map[string]interface{}{"amount": 500.0, "token": "secret"},
+	}
+
+	output, err := handler.Handle(context.Background(), pctx)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if gotMethod != http.MethodPost || gotToken != "secret" || gotBody["amount"] != 500.0 {
+		t.Errorf("request = %s %q %v", gotMethod, gotToken, gotBody)
+	}
+	if output["approved"] != true || output["statusCode"] != http.StatusOK {
+		t.Errorf("Handle() = %v", output)
+	}
+
+	pctx.Params = map[string]interface{}{"url": server.URL + "/text", "method": "get"}
+	output, err = handler.Handle(context.Background(), pctx)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if gotMethod != http.MethodGet || output["body"] != "ok" {
+		t.Errorf("GET request = %s, output = %v", gotMethod, output)
+	}
+
+	pctx.Params = map[string]interface{}{"url": server.URL + "/fail"}
+	if _, err := handler.Handle(context.Background(), pctx); err == nil || !strings.Contains(err.Error(), "returned 500") {
+		t.Errorf("Handle() error = %v, want non-2xx error", err)
+	}
+}
+
+func TestHTTPCallProcessHandlerRejectsDisallowedHosts(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+	}))
+	defer server.Close()
+	host := serverHost(t, server)
+
+	tests := []struct {
+		name    string
+		allowed []string
+		url     string
+	}{
+		{"未配置白名单", nil, server.URL},
+		{"主机不在白名单", []string{"example.com"}, server.URL},
+		{"端口不匹配", []string{"127.0.0.1:1"}, server.URL},
+		{"不支持的协议", []string{host}, "file:///etc/passwd"},
+		{"重定向到白名单外的主机", []string{host}, server.URL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newHTTPCallProcessHandler(tt.allowed)
+			pctx := &port.ProcessContext{Params: map[string]interface{}{"url": tt.url, "method": "GET"}}
+			if _, err := handler.Handle(context.Background(), pctx); err == nil || !strings.Contains(err.Error(), "not allowed") && !strings.Contains(err.Error(), "scheme") {
+				t.Errorf("Handle(%s) error = %v, want rejection", tt.url, err)
+			}
+		})
+	}
+	if requests != 1 {
+		t.Errorf("server received %d requests, want only the redirecting one", requests)
+	}
+
+	// 只配置主机名时匹配任意端口
+	handler := newHTTPCallProcessHandler([]string{"127.0.0.1"})
+	if err := handler.checkURL(&neturl.URL{Scheme: "http", Host: host}); err != nil {
+		t.Errorf("checkURL(%s) error = %v", host, err)
+	}
+}
@@ -2,11 +2,14 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 
+	instance_repository "jxt-evidence-system/process-management/internal/domain/aggregate/instance/repository"
 	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
 	task_repository "jxt-evidence-system/process-management/internal/domain/aggregate/task/repository"
+	timer_repository "jxt-evidence-system/process-management/internal/domain/aggregate/timer/repository"
 	workflow_repository "jxt-evidence-system/process-management/internal/domain/aggregate/workflow/repository"
 	"jxt-evidence-system/process-management/internal/domain/valueobject"
 	"jxt-evidence-system/process-management/shared/common/errors"
@@ -18,10 +21,14 @@ import (
 
 // ClaimTaskHandler 认领任务处理器
 type taskService struct {
-	taskRepo      task_repository.TaskRepository
-	workflowRepo  workflow_repository.WorkflowRepository
-	historyRepo   task_repository.TaskHistoryRepository
-	engineService port.WorkflowEngineService
+	taskRepo        task_repository.TaskRepository
+	instanceRepo    instance_repository.WorkflowInstanceRepository
+	workflowRepo    workflow_repository.WorkflowRepository
+	historyRepo     task_repository.TaskHistoryRepository
+	timerRepo       timer_repository.TimerRepository
+	engineService   port.WorkflowEngineService
+	userDirectory   port.UserDirectory // 可为空，为空时不校验转办目标是否存在
+	notificationSvc port.NotificationService
 }
 
 // Handle 处理完成任务命令
@@ -34,14 +41,27 @@ func (h taskService) CompleteTask(ctx context.Context, cmd *command.CompleteTask
 	if task.Assignee != cmd.UserID {
 		return errors.ErrUnauthorized
 	}
+	if err := h.checkInstanceActive(ctx, task); err != nil {
+		return err
+	}
 
 	if err := task.Complete(cmd); err != nil {
 		return err
 	}
+	// 驳回目标无效时不改变任务状态
+	if cmd.Result == status.TaskResultRejected && h.engineService != nil {
+		if err := h.engineService.ValidateRejectTarget(ctx, task); err != nil {
+			return err
+		}
+	}
 
 	if err := h.taskRepo.Update(ctx, task); err != nil {
 		return err
 	}
+	// 任务已处理，取消其超时定时器
+	if err := h.timerRepo.CancelByTaskID(ctx, task.TaskID); err != nil {
+		return err
+	}
 	ctx = context.WithValue(ctx, "next_task_approver", cmd.NextTaskApprover)
 	// 记录历史
 	history := task_aggregate.NewTaskHistory(task.TaskID, task.InstanceID, task.TaskName, fmt.Sprintf("%d", cmd.UserID), "complete")
@@ -57,16 +77,15 @@ func (h taskService) CompleteTask(ctx context.Context, cmd *command.CompleteTask
 		if cmd.Result == status.TaskResultRejected {
 			// 驳回：回退到上一个步骤
 			if err := h.engineService.RejectAndGoBack(ctx, task); err != nil {
-				// 记录错误但不影响任务完成
 				log.Printf("[CompleteTaskHandler] RejectAndGoBack failed: %v", err)
-			} else {
-				log.Printf("[CompleteTaskHandler] RejectAndGoBack succeeded")
+				return fmt.Errorf("task rejected but failed to go back: %w", err)
 			}
+			log.Printf("[CompleteTaskHandler] RejectAndGoBack succeeded")
 		} else if cmd.Result == status.TaskResultApproved || cmd.Result == status.TaskResultCompleted {
 			// 通过/完成：继续下一步
 			if err := h.engineService.ContinueAfterTask(ctx, task); err != nil {
-				// 记录错误但不影响任务完成
 				log.Printf("[CompleteTaskHandler] ContinueAfterTask failed: %v", err)
+				return fmt.Errorf("task completed but failed to continue workflow: %w", err)
 			}
 		}
 	}
@@ -74,6 +93,25 @@ func (h taskService) CompleteTask(ctx context.Context, cmd *command.CompleteTask
 	return nil
 }
 
+// checkInstanceActive 只有运行中实例的任务可以处理
+func (h *taskService) checkInstanceActive(ctx context.Context, task *task_aggregate.Task) error {
+	if h.instanceRepo == nil {
+		return nil
+	}
+	instance, err := h.instanceRepo.FindByID(ctx, task.InstanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance: %w", err)
+	}
+	switch instance.Status {
+	case status.InstanceStatusRunning:
+		return nil
+	case status.InstanceStatusSuspended:
+		return errors.ErrInstanceSuspended
+	default:
+		return fmt.Errorf("%w: instance is %s", errors.ErrInstanceNotRunning, instance.Status)
+	}
+}
+
 // Handle 处理删除任务命令
 func (h *taskService) DeleteTask(ctx context.Context, cmd *command.DeleteTaskCommand) error {
 
@@ -93,6 +131,7 @@ func (h *taskService) DeleteTask(ctx context.Context, cmd *command.DeleteTaskCom
 }
 
 // 处理转办任务命令
+// 步骤需允许转办，目标用户须存在且不能形成转办环，转办给原处理人即为转回
 func (h *taskService) DelegateTask(ctx context.Context, cmd *command.DelegateTaskCommand) error {
 
 	task, err := h.taskRepo.FindByID(ctx, cmd.ID)
@@ -103,17 +142,183 @@ func (h *taskService) DelegateTask(ctx context.Context, cmd *command.DelegateTas
 	if task.Assignee != cmd.UserID {
 		return errors.ErrUnauthorized
 	}
+	if err := h.checkInstanceActive(ctx, task); err != nil {
+		return err
+	}
+	if h.engineService == nil {
+		return fmt.Errorf("workflow engine is not available")
+	}
+
+	step, err := h.engineService.FindTaskStep(ctx, task)
+	if err != nil {
+		return err
+	}
+	if !step.DelegateAllowed() {
+		return errors.ErrDelegateNotAllowed
+	}
+
+	if cmd.TargetID != 0 && cmd.TargetID != cmd.UserID && h.userDirectory != nil {
+		exists, err := h.userDirectory.UserExists(ctx, cmd.TargetID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return errors.ErrDelegateUserNotFound
+		}
+	}
 
-	// 记录转办前的历史
+	if err := task.Delegate(cmd.UserID, cmd.TargetID, step.MaxDelegateHops); err != nil {
+		return err
+	}
+	if err := h.taskRepo.Update(ctx, task); err != nil {
+		return err
+	}
+
+	// 记录转办历史
 	history := task_aggregate.NewTaskHistory(task.TaskID, task.InstanceID, task.TaskName, fmt.Sprintf("%d", cmd.UserID), "delegate")
 	history.Comment = cmd.Comment
+	history.Output, _ = json.Marshal(map[string]int{"from": cmd.UserID, "to": cmd.TargetID})
 	if err := h.historyRepo.Save(ctx, history); err != nil {
 		return err
 	}
 
-	// 更新任务处理人
-	task.Assignee = cmd.TargetID
-	return h.taskRepo.Update(ctx, task)
+	if h.notificationSvc != nil {
+		h.notificationSvc.NotifyTaskAssigned(ctx, task, cmd.TargetID)
+	}
+	return nil
+}
+
+// AddSigner 加签：为当前处理人的待处理任务增加处理人
+func (h *taskService) AddSigner(ctx context.Context, cmd *command.AddSignerCommand) (*task_aggregate.Task, error) {
+	task, err := h.taskRepo.FindByID(ctx, cmd.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Assignee != cmd.UserID {
+		return nil, errors.ErrUnauthorized
+	}
+	if err := h.checkInstanceActive(ctx, task); err != nil {
+		return nil, err
+	}
+
+	if h.engineService == nil {
+		return nil, fmt.Errorf("workflow engine is not available")
+	}
+
+	added, err := task.AddSigner(cmd.SignerID, cmd.Position)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录加签历史
+	history := task_aggregate.NewTaskHistory(task.TaskID, task.InstanceID, task.TaskName, fmt.Sprintf("%d", cmd.UserID), "add_sign")
+	history.Comment = cmd.Comment
+	history.Output, _ = json.Marshal(map[string]interface{}{
+		"signerId": cmd.SignerID,
+		"position": cmd.Position,
+		"taskId":   added.TaskID.String(),
+	})
+	if err := h.engineService.AddSigner(ctx, task, added, history); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[AddSignerHandler] Task %s added %s signer %d", task.TaskID.String(), cmd.Position, cmd.SignerID)
+	return added, nil
+}
+
+// ClaimTask 认领任务：候选用户或候选组成员认领未分配处理人的任务
+func (h *taskService) ClaimTask(ctx context.Context, cmd *command.ClaimTaskCommand) error {
+	task, err := h.taskRepo.FindByID(ctx, cmd.ID)
+	if err != nil {
+		return err
+	}
+	if err := h.checkInstanceActive(ctx, task); err != nil {
+		return err
+	}
+
+	if err := task.Claim(cmd.UserID, cmd.UserGroups); err != nil {
+		return err
+	}
+	// 条件更新，多人同时认领时只有一人成功
+	claimed, err := h.taskRepo.Claim(ctx, task)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return errors.ErrTaskAlreadyClaimed
+	}
+
+	history := task_aggregate.NewTaskHistory(task.TaskID, task.InstanceID, task.TaskName, fmt.Sprintf("%d", cmd.UserID), "claim")
+	if err := h.historyRepo.Save(ctx, history); err != nil {
+		return err
+	}
+
+	log.Printf("[ClaimTaskHandler] Task %s claimed by user %d", task.TaskID.String(), cmd.UserID)
+	return nil
+}
+
+// UnclaimTask 放弃认领：任务退回候选人的待领列表
+func (h *taskService) UnclaimTask(ctx context.Context, cmd *command.UnclaimTaskCommand) error {
+	task, err := h.taskRepo.FindByID(ctx, cmd.ID)
+	if err != nil {
+		return err
+	}
+	if err := h.checkInstanceActive(ctx, task); err != nil {
+		return err
+	}
+
+	if err := task.Unclaim(cmd.UserID); err != nil {
+		return err
+	}
+	unclaimed, err := h.taskRepo.Unclaim(ctx, task, cmd.UserID)
+	if err != nil {
+		return err
+	}
+	if !unclaimed {
+		return errors.ErrTaskNotClaimed
+	}
+
+	history := task_aggregate.NewTaskHistory(task.TaskID, task.InstanceID, task.TaskName, fmt.Sprintf("%d", cmd.UserID), "unclaim")
+	history.Comment = cmd.Comment
+	if err := h.historyRepo.Save(ctx, history); err != nil {
+		return err
+	}
+
+	log.Printf("[UnclaimTaskHandler] Task %s unclaimed by user %d", task.TaskID.String(), cmd.UserID)
+	return nil
+}
+
+// WithdrawTask 撤回任务：处理人在后续任务未被处理前撤回已处理的任务
+func (h *taskService) WithdrawTask(ctx context.Context, cmd *command.WithdrawTaskCommand) error {
+	task, err := h.taskRepo.FindByID(ctx, cmd.ID)
+	if err != nil {
+		return err
+	}
+
+	if task.Assignee != cmd.UserID {
+		return errors.ErrUnauthorized
+	}
+	if err := h.checkInstanceActive(ctx, task); err != nil {
+		return err
+	}
+	if h.engineService == nil {
+		return fmt.Errorf("workflow engine is not available")
+	}
+
+	if err := h.engineService.WithdrawTask(ctx, task); err != nil {
+		return err
+	}
+
+	// 记录撤回历史
+	history := task_aggregate.NewTaskHistory(task.TaskID, task.InstanceID, task.TaskName, fmt.Sprintf("%d", cmd.UserID), "withdraw")
+	history.Comment = cmd.Comment
+	if err := h.historyRepo.Save(ctx, history); err != nil {
+		return err
+	}
+
+	log.Printf("[WithdrawTaskHandler] Task %s withdrawn by user %d", task.TaskID.String(), cmd.UserID)
+	return nil
 }
 
 // Handle 处理创建任务命令
@@ -125,6 +330,7 @@ func (h *taskService) CreateTask(ctx context.Context, cmd *command.CreateTaskCom
 	task.TaskKey = cmd.TaskKey
 	task.Description = cmd.Description
 	task.Assignee = cmd.Assignee
+	task.SetCandidates(int64sToInts(cmd.CandidateUsers), int64sToInts(cmd.CandidateGroups))
 
 	// 设置优先级
 	if cmd.Priority == "high" {
@@ -166,6 +372,11 @@ func (h *taskService) GetTodoTasks(ctx context.Context, userID int, query *comma
 	return h.taskRepo.FindTodoByAssignee(ctx, userID, query)
 }
 
+// GetClaimableTasks 查询用户可认领的任务
+func (h *taskService) GetClaimableTasks(ctx context.Context, userID int, userGroups []int, query *command.ClaimableTaskPagedQuery) ([]*task_aggregate.Task, int, error) {
+	return h.taskRepo.FindClaimable(ctx, userID, userGroups, query)
+}
+
 // GetDoneTasks 查询已办任务
 func (h *taskService) GetDoneTasks(ctx context.Context, userID int, query *command.DoneTaskPagedQuery) ([]*task_aggregate.Task, int, error) {
 	return h.taskRepo.FindDoneByAssignee(ctx, userID, query)
@@ -195,3 +406,12 @@ func (h *taskService) CountTasksByInstanceID(ctx context.Context, instanceId val
 	// 获取总数
 	return h.taskRepo.CountByInstanceID(ctx, instanceId)
 }
+
+// int64sToInts 转换命令中的用户、组编码
+func int64sToInts(values []int64) []int {
+	result := make([]int, 0, len(values))
+	for _, v := range values {
+		result = append(result, int(v))
+	}
+	return result
+}
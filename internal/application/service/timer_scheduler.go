@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"jxt-evidence-system/process-management/internal/application/service/port"
+	timer_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/timer"
+	timer_repository "jxt-evidence-system/process-management/internal/domain/aggregate/timer/repository"
+	"jxt-evidence-system/process-management/shared/common/global"
+)
+
+const (
+	timerPollInterval = 5 * time.Second  // 扫描间隔
+	timerBatchSize    = 100              // 每次扫描处理的最大定时器数
+	timerFireTimeout  = 30 * time.Second // 单个定时器处理超时时间
+)
+
+// timerScheduler 定时器调度器
+// 定时器持久化在数据库中，服务重启后会继续触发已到期的定时器
+type timerScheduler struct {
+	timerRepo     timer_repository.TimerRepository
+	engineService port.WorkflowEngineService
+	stopChan      chan struct{}
+	doneChan      chan struct{}
+	startOnce     sync.Once
+	stopOnce      sync.Once
+}
+
+// NewTimerScheduler 创建定时器调度器
+func NewTimerScheduler(timerRepo timer_repository.TimerRepository, engineService port.WorkflowEngineService) port.TimerScheduler {
+	return &timerScheduler{
+		timerRepo:     timerRepo,
+		engineService: engineService,
+		stopChan:      make(chan struct{}),
+		doneChan:      make(chan struct{}),
+	}
+}
+
+// Start 启动调度循环
+func (s *timerScheduler) Start() {
+	s.startOnce.Do(func() {
+		go s.run()
+		log.Printf("[TimerScheduler] Started, poll interval: %s", timerPollInterval)
+	})
+}
+
+// Stop 停止调度循环，等待正在处理的定时器完成
+func (s *timerScheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+		started := true
+		s.startOnce.Do(func() { started = false })
+		if started {
+			<-s.doneChan
+		}
+		log.Printf("[TimerScheduler] Stopped")
+	})
+}
+
+func (s *timerScheduler) run() {
+	defer close(s.doneChan)
+
+	ticker := time.NewTicker(timerPollInterval)
+	defer ticker.Stop()
+
+	// 启动时立即处理一次，补偿停机期间到期的定时器
+	s.poll()
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.poll()
+		}
+	}
+}
+
+// poll 扫描并触发到期的定时器
+func (s *timerScheduler) poll() {
+	ctx := context.WithValue(context.Background(), global.TenantIDKey, "*")
+
+	timers, err := s.timerRepo.FindDue(ctx, time.Now(), timerBatchSize)
+	if err != nil {
+		log.Printf("[TimerScheduler] Failed to find due timers: %v", err)
+		return
+	}
+
+	for _, timer := range timers {
+		select {
+		case <-s.stopChan:
+			return
+		default:
+		}
+		s.fire(ctx, timer)
+	}
+}
+
+// fire 抢占并处理单个定时器
+func (s *timerScheduler) fire(ctx context.Context, timer *timer_aggregate.Timer) {
+	claimed, err := s.timerRepo.Claim(ctx, timer.TimerID)
+	if err != nil {
+		log.Printf("[TimerScheduler] Failed to claim timer %s: %v", timer.TimerID.String(), err)
+		return
+	}
+	if !claimed {
+		// 已被其他节点处理或已取消
+		return
+	}
+	_ = timer.Fire()
+
+	fireCtx, cancel := context.WithTimeout(ctx, timerFireTimeout)
+	defer cancel()
+
+	if err := s.engineService.HandleTimer(fireCtx, timer); err != nil {
+		log.Printf("[TimerScheduler] Failed to handle timer %s: %v", timer.TimerID.String(), err)
+		timer.Fail(err.Error())
+		if err := s.timerRepo.Update(ctx, timer); err != nil {
+			log.Printf("[TimerScheduler] Failed to update timer %s: %v", timer.TimerID.String(), err)
+		}
+	}
+}
@@ -2,19 +2,27 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"jxt-evidence-system/process-management/internal/application/command"
+	"jxt-evidence-system/process-management/internal/application/service/port"
 	workflow_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/workflow"
 	workflow_repository "jxt-evidence-system/process-management/internal/domain/aggregate/workflow/repository"
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
 	"jxt-evidence-system/process-management/internal/domain/valueobject"
-	"jxt-evidence-system/process-management/shared/common/errors"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
 	"jxt-evidence-system/process-management/shared/common/status"
 )
 
 // ActivateWorkflowHandler 激活工作流处理器
 type workflowService struct {
-	repo workflow_repository.WorkflowRepository
+	repo          workflow_repository.WorkflowRepository
+	versionRepo   workflow_repository.WorkflowVersionRepository
+	domainService *domain_service.WorkflowDomainService
+	txManager     port.TransactionManager
 }
 
 func (h *workflowService) ActivateWorkflow(ctx context.Context, cmd *command.ActivateWorkflowCommand) error {
@@ -25,19 +33,52 @@ func (h *workflowService) ActivateWorkflow(ctx context.Context, cmd *command.Act
 		return err
 	}
 
+	// 结构校验，有错误的定义不允许激活
+	definition, errs := h.domainService.ValidateDefinition(wf.Definition)
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %v", errors_.ErrInvalidWorkflowDefinition, errs)
+	}
+
+	// 编译并缓存条件表达式
+	if err := h.domainService.CompileConditions(wf.WorkflowID, definition); err != nil {
+		return fmt.Errorf("%w: %v", errors_.ErrInvalidWorkflowDefinition, err)
+	}
+
 	// 激活工作流
 	if err := wf.Activate(); err != nil {
 		return err
 	}
 
-	// 保存更新
-	return h.repo.Update(ctx, wf)
+	if cmd.UpdateBy != 0 {
+		wf.UpdateBy = cmd.UpdateBy
+	}
+
+	// 发布版本：定义相对最近版本有变化时生成新的不可变版本，新实例绑定该版本
+	// 版本记录与工作流的当前版本在同一事务中保存
+	return runInTransaction(ctx, h.txManager, func(ctx context.Context) error {
+		latest, err := h.versionRepo.FindLatest(ctx, wf.WorkflowID)
+		if err != nil && !errors.Is(err, errors_.ErrWorkflowVersionNotFound) {
+			return err
+		}
+		if version := wf.PublishVersion(latest, cmd.UpdateBy); version != nil {
+			if err := h.versionRepo.Save(ctx, version); err != nil {
+				return fmt.Errorf("failed to publish workflow version: %w", err)
+			}
+		}
+
+		// 保存更新
+		return h.repo.Update(ctx, wf)
+	})
 }
 
 func (h *workflowService) CreateWorkflow(ctx context.Context, cmd *command.CreateWorkflowCommand) (string, error) {
 	// 业务规则验证
 	if cmd.Name == "" || cmd.Definition == "" {
-		return "", errors.ErrInvalidWorkflowDefinition
+		return "", errors_.ErrInvalidWorkflowDefinition
+	}
+	// 草稿只要求 JSON 合法，完整结构校验在激活时进行
+	if _, errs := domain_service.ParseDefinition(cmd.Definition); len(errs) > 0 {
+		return "", fmt.Errorf("%w: %v", errors_.ErrInvalidWorkflowDefinition, errs)
 	}
 
 	// 创建工作流
@@ -61,11 +102,15 @@ func (h *workflowService) DeleteWorkflow(ctx context.Context, cmd *command.Delet
 
 	// 业务规则验证：只能删除草稿或已取消的工作流
 	if wf.Status != status.StatusDraft && wf.Status != status.StatusCancelled {
-		return errors.ErrInvalidStatusTransition
+		return errors_.ErrInvalidStatusTransition
 	}
 
 	// 执行删除
-	return h.repo.Delete(ctx, cmd.ID)
+	if err := h.repo.Delete(ctx, cmd.ID); err != nil {
+		return err
+	}
+	h.domainService.InvalidateConditions(cmd.ID)
+	return nil
 }
 
 func (h *workflowService) FreezeWorkflow(ctx context.Context, cmd *command.FreezeWorkflowCommand) error {
@@ -93,7 +138,7 @@ func (h *workflowService) GetWorkflowByID(ctx context.Context, workflowID valueo
 	}
 
 	if wf == nil {
-		return nil, errors.ErrWorkflowNotFound
+		return nil, errors_.ErrWorkflowNotFound
 	}
 
 	return wf, nil
@@ -107,7 +152,7 @@ func (h *workflowService) GetWorkflowByName(ctx context.Context, name string) (*
 	}
 
 	if wf == nil {
-		return nil, errors.ErrWorkflowNotFound
+		return nil, errors_.ErrWorkflowNotFound
 	}
 
 	return wf, nil
@@ -137,7 +182,10 @@ func (h *workflowService) UpdateWorkflow(ctx context.Context, cmd *command.Updat
 	}
 	// 业务规则验证：只能更新草稿或冻结状态的工作流
 	if wf.Status != status.StatusDraft && wf.Status != status.StatusFrozen {
-		return errors.ErrInvalidStatusTransition
+		return errors_.ErrInvalidStatusTransition
+	}
+	if _, errs := domain_service.ParseDefinition(cmd.Definition); len(errs) > 0 {
+		return fmt.Errorf("%w: %v", errors_.ErrInvalidWorkflowDefinition, errs)
 	}
 
 	// 更新字段
@@ -148,5 +196,127 @@ func (h *workflowService) UpdateWorkflow(ctx context.Context, cmd *command.Updat
 	wf.UpdatedAt = time.Now()
 
 	// 保存更新
-	return h.repo.Update(ctx, wf)
+	if err := h.repo.Update(ctx, wf); err != nil {
+		return err
+	}
+	h.domainService.InvalidateConditions(wf.WorkflowID)
+	return nil
+}
+
+// ValidateDefinition 校验工作流定义，返回全部校验错误（无错误时为空列表）
+func (h *workflowService) ValidateDefinition(ctx context.Context, definition string) []domain_service.DefinitionError {
+	_, errs := h.domainService.ValidateDefinition(definition)
+	if errs == nil {
+		return []domain_service.DefinitionError{}
+	}
+	return errs
+}
+
+// GetVersions 列出工作流的全部已发布版本（按版本号降序）
+func (h *workflowService) GetVersions(ctx context.Context, workflowID valueobject.WorkflowID) ([]*workflow_aggregate.WorkflowVersion, error) {
+	if _, err := h.repo.FindByID(ctx, workflowID); err != nil {
+		return nil, err
+	}
+	return h.versionRepo.FindByWorkflowID(ctx, workflowID)
+}
+
+// GetVersion 获取工作流的指定版本
+func (h *workflowService) GetVersion(ctx context.Context, workflowID valueobject.WorkflowID, version int) (*workflow_aggregate.WorkflowVersion, error) {
+	return h.versionRepo.FindByVersion(ctx, workflowID, version)
+}
+
+// CompareVersions 比较工作流的两个版本
+func (h *workflowService) CompareVersions(ctx context.Context, cmd *command.CompareWorkflowVersionsCommand) (*command.WorkflowVersionDiff, error) {
+	from, err := h.loadVersionDefinition(ctx, cmd.ID, cmd.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := h.loadVersionDefinition(ctx, cmd.ID, cmd.To)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := h.domainService.CompareDefinitions(from, to)
+	diff.From = cmd.From
+	diff.To = cmd.To
+	return diff, nil
+}
+
+func (h *workflowService) loadVersionDefinition(ctx context.Context, workflowID valueobject.WorkflowID, version int) (*domain_service.WorkflowDefinitionStruct, error) {
+	v, err := h.versionRepo.FindByVersion(ctx, workflowID, version)
+	if err != nil {
+		return nil, err
+	}
+	var definition domain_service.WorkflowDefinitionStruct
+	if err := json.Unmarshal([]byte(v.Definition), &definition); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow definition of version %d: %w", version, err)
+	}
+	return &definition, nil
+}
+
+// ImportBpmn 将 BPMN 2.0 XML 转换为工作流定义并创建草稿工作流，无法转换的元素在结果中列出
+func (h *workflowService) ImportBpmn(ctx context.Context, cmd *command.ImportBpmnCommand) (*command.BpmnImportResult, error) {
+	definition, unsupported, err := h.domainService.ImportBpmn([]byte(cmd.Xml))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors_.ErrInvalidWorkflowDefinition, err)
+	}
+	if cmd.Name != "" {
+		definition.Name = cmd.Name
+	}
+	if cmd.Description != "" {
+		definition.Description = cmd.Description
+	}
+
+	data, err := json.Marshal(definition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workflow definition: %w", err)
+	}
+	result := &command.BpmnImportResult{
+		Name:        definition.Name,
+		Definition:  string(data),
+		Unsupported: unsupported,
+	}
+	if cmd.DryRun {
+		return result, nil
+	}
+
+	create := &command.CreateWorkflowCommand{
+		Name:        definition.Name,
+		Description: definition.Description,
+		Definition:  string(data),
+	}
+	create.SetCreateBy(cmd.CreateBy)
+	id, err := h.CreateWorkflow(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+	result.WorkflowID = id
+	return result, nil
+}
+
+// ExportBpmn 将工作流定义导出为 BPMN 2.0 XML，指定版本时导出该版本的定义
+func (h *workflowService) ExportBpmn(ctx context.Context, cmd *command.ExportBpmnCommand) ([]byte, error) {
+	wf, err := h.GetWorkflowByID(ctx, cmd.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var definition *domain_service.WorkflowDefinitionStruct
+	if cmd.Version > 0 {
+		definition, err = h.loadVersionDefinition(ctx, wf.WorkflowID, cmd.Version)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var errs domain_service.DefinitionErrors
+		if definition, errs = domain_service.ParseDefinition(wf.Definition); len(errs) > 0 {
+			return nil, fmt.Errorf("%w: %v", errors_.ErrInvalidWorkflowDefinition, errs)
+		}
+	}
+
+	data, err := h.domainService.ExportBpmn(wf.WorkflowID.String(), definition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export workflow %s: %w", wf.WorkflowID.String(), err)
+	}
+	return data, nil
 }
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// AddSigner 在同一事务中保存加签任务、原任务和加签历史
+// 加签任务待处理时为其创建超时定时器；前加签时原任务转为等待，取消其超时定时器，交回原处理人时重新计时
+func (s *WorkflowEngineService) AddSigner(ctx context.Context, source, added *task_aggregate.Task, history *task_aggregate.TaskHistory) error {
+	step, err := s.FindTaskStep(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.taskRepo.Save(ctx, added); err != nil {
+			return fmt.Errorf("failed to save task: %w", err)
+		}
+		if err := s.taskRepo.Update(ctx, source); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		if source.Status == status.TaskStatusWaiting && s.timerRepo != nil {
+			if err := s.timerRepo.CancelByTaskID(ctx, source.TaskID); err != nil {
+				return fmt.Errorf("failed to cancel timers: %w", err)
+			}
+		}
+		if added.Status == status.TaskStatusPending {
+			if err := s.scheduleStepTimeout(ctx, added, step); err != nil {
+				return err
+			}
+		}
+		if err := s.historyRepo.Save(ctx, history); err != nil {
+			return fmt.Errorf("failed to save history: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if added.Status == status.TaskStatusPending && s.notificationSvc != nil {
+		s.notificationSvc.NotifyTaskCreated(ctx, added)
+	}
+	return nil
+}
+
+// settleSigners 处理加签相关任务的完成，返回代表原任务继续流转的任务，返回 nil 时流程继续等待
+// 前加签人处理后交回原处理人；原处理人通过后激活后加签人；
+// 原任务及其后加签、并加签任务全部通过后原任务才继续流转，任一驳回时取消其余未处理的任务，原任务按驳回处理
+func (s *WorkflowEngineService) settleSigners(ctx context.Context, task *task_aggregate.Task) (*task_aggregate.Task, error) {
+	tasks, err := s.taskRepo.FindByInstanceID(ctx, task.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks: %w", err)
+	}
+
+	source := task
+	if !task.SourceTaskID.IsEmpty() {
+		source = nil
+		for _, t := range tasks {
+			if t.TaskID.Equals(task.SourceTaskID) {
+				source = t
+				break
+			}
+		}
+		if source == nil {
+			return nil, fmt.Errorf("source task not found: %s", task.SourceTaskID.String())
+		}
+	}
+
+	var before, signers []*task_aggregate.Task
+	for _, t := range tasks {
+		if !t.SourceTaskID.Equals(source.TaskID) {
+			continue
+		}
+		if t.SignPosition == task_aggregate.SignPositionBefore {
+			before = append(before, t)
+		} else {
+			signers = append(signers, t)
+		}
+	}
+
+	if task.SignPosition == task_aggregate.SignPositionBefore {
+		return nil, s.returnToSource(ctx, source, before)
+	}
+	if len(signers) == 0 {
+		return task, nil
+	}
+
+	if task.Status == status.TaskStatusRejected {
+		err := s.inTransaction(ctx, func(ctx context.Context) error {
+			if err := s.cancelOpenTasks(ctx, signers, "加签已驳回，任务自动取消"); err != nil {
+				return err
+			}
+			if task == source {
+				return nil
+			}
+			if source.IsOpen() && s.timerRepo != nil {
+				if err := s.timerRepo.CancelByTaskID(ctx, source.TaskID); err != nil {
+					return fmt.Errorf("failed to cancel timers: %w", err)
+				}
+			}
+			source.RejectBySigner(task)
+			if err := s.taskRepo.Update(ctx, source); err != nil {
+				return fmt.Errorf("failed to update task: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[EngineService] Signer task %s rejected, source task %s rejected", task.TaskID.String(), source.TaskID.String())
+		return source, nil
+	}
+
+	if task == source {
+		activated, err := s.activateAfterSigners(ctx, signers)
+		if err != nil || activated {
+			return nil, err
+		}
+	}
+	for _, t := range append([]*task_aggregate.Task{source}, signers...) {
+		if t.IsOpen() {
+			log.Printf("[EngineService] Task %s is %s, waiting for signers of task %s", t.TaskID.String(), t.Status, source.TaskID.String())
+			return nil, nil
+		}
+	}
+	log.Printf("[EngineService] All signers of task %s approved", source.TaskID.String())
+	return source, nil
+}
+
+// returnToSource 前加签任务全部处理完后交回原处理人
+func (s *WorkflowEngineService) returnToSource(ctx context.Context, source *task_aggregate.Task, before []*task_aggregate.Task) error {
+	for _, t := range before {
+		if t.IsOpen() {
+			return nil
+		}
+	}
+	if source.Status != status.TaskStatusWaiting {
+		return nil
+	}
+	step, err := s.FindTaskStep(ctx, source)
+	if err != nil {
+		return err
+	}
+	if err := source.Activate(); err != nil {
+		return err
+	}
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.taskRepo.Update(ctx, source); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		return s.scheduleStepTimeout(ctx, source, step)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("[EngineService] Task %s returned to assignee %d after add-sign", source.TaskID.String(), source.Assignee)
+	if s.notificationSvc != nil {
+		s.notificationSvc.NotifyTaskAssigned(ctx, source, source.Assignee)
+	}
+	return nil
+}
+
+// activateAfterSigners 原处理人通过后激活后加签任务，返回是否有任务被激活
+func (s *WorkflowEngineService) activateAfterSigners(ctx context.Context, signers []*task_aggregate.Task) (bool, error) {
+	var waiting []*task_aggregate.Task
+	for _, t := range signers {
+		if t.SignPosition == task_aggregate.SignPositionAfter && t.Status == status.TaskStatusWaiting {
+			waiting = append(waiting, t)
+		}
+	}
+	if len(waiting) == 0 {
+		return false, nil
+	}
+	step, err := s.FindTaskStep(ctx, waiting[0])
+	if err != nil {
+		return false, err
+	}
+
+	var activated []*task_aggregate.Task
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		for _, t := range waiting {
+			if err := t.Activate(); err != nil {
+				return err
+			}
+			if err := s.taskRepo.Update(ctx, t); err != nil {
+				return fmt.Errorf("failed to update task: %w", err)
+			}
+			if err := s.scheduleStepTimeout(ctx, t, step); err != nil {
+				return err
+			}
+			activated = append(activated, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, t := range activated {
+		log.Printf("[EngineService] After-signer task %s activated for assignee %d", t.TaskID.String(), t.Assignee)
+		if s.notificationSvc != nil {
+			s.notificationSvc.NotifyTaskCreated(ctx, t)
+		}
+	}
+	return len(activated) > 0, nil
+}
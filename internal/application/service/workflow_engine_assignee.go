@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+)
+
+// applyAssigneeExpression 通过处理人解析器解析步骤的处理人表达式
+// 解析出一个用户时直接分配，多个用户时作为候选用户由其认领；解析失败时保留默认处理人
+func (s *WorkflowEngineService) applyAssigneeExpression(ctx context.Context, task *task_aggregate.Task, step *StepDefinition, instance *instance_aggregate.WorkflowInstance) {
+	value, ok := step.Params["assignee"].(string)
+	if !ok || !domain_service.IsAssigneeExpression(value) || s.assigneeResolver == nil {
+		return
+	}
+
+	interpolated, err := s.domainService.InterpolateVariables(ctx, value, instance)
+	if err != nil {
+		log.Printf("[EngineService] Failed to resolve variables in assignee expression %s: %v", value, err)
+		return
+	}
+	expr, err := domain_service.ParseAssigneeExpression(interpolated)
+	if err != nil {
+		log.Printf("[EngineService] Invalid assignee expression %s: %v", interpolated, err)
+		return
+	}
+	users, err := s.assigneeResolver.Resolve(ctx, expr)
+	if err != nil {
+		log.Printf("[EngineService] Failed to resolve assignee expression %s: %v", interpolated, err)
+		return
+	}
+
+	if len(users) == 1 {
+		task.Assignee = users[0]
+		log.Printf("[EngineService] Assignee expression %s resolved to user %d", interpolated, users[0])
+		return
+	}
+	task.Assignee = 0
+	task.SetCandidates(append(task.CandidateUsers(), users...), task.CandidateGroups())
+	log.Printf("[EngineService] Assignee expression %s resolved to candidates %v", interpolated, users)
+}
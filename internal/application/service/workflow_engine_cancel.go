@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// CancelInstance 取消运行中或挂起的实例
+// 未处理的任务全部取消并记录历史，定时器取消，令牌结束，子实例一并取消，待处理任务的处理人收到取消通知
+func (s *WorkflowEngineService) CancelInstance(ctx context.Context, instanceID valueobject.InstanceID, operator int, reason string) error {
+	instance, err := s.instanceRepo.FindByID(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	if err := instance.Cancel(); err != nil {
+		return err
+	}
+
+	tasks, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks: %w", err)
+	}
+	comment := reason
+	if comment == "" {
+		comment = "流程已取消，任务自动关闭"
+	}
+	var open, notify []*task_aggregate.Task
+	for _, t := range tasks {
+		if !t.IsOpen() {
+			continue
+		}
+		open = append(open, t)
+		// 等待中的任务（顺序会签未轮到、前加签）尚未出现在处理人的待办中，不通知
+		if t.Status == status.TaskStatusPending {
+			notify = append(notify, t)
+		}
+	}
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.instanceRepo.Update(ctx, instance); err != nil {
+			return fmt.Errorf("failed to update instance: %w", err)
+		}
+		if err := s.cancelOpenTasks(ctx, open, comment); err != nil {
+			return err
+		}
+		for _, t := range open {
+			history := task_aggregate.NewTaskHistory(t.TaskID, t.InstanceID, t.TaskName, fmt.Sprintf("%d", operator), "cancel")
+			history.Comment = comment
+			if err := s.historyRepo.Save(ctx, history); err != nil {
+				return fmt.Errorf("failed to save task history: %w", err)
+			}
+		}
+		if s.timerRepo != nil {
+			if err := s.timerRepo.CancelByInstanceID(ctx, instance.InstanceId); err != nil {
+				return fmt.Errorf("failed to cancel timers: %w", err)
+			}
+		}
+		if err := s.tokenRepo.CloseByInstanceID(ctx, instance.InstanceId, status.TokenStatusCancelled); err != nil {
+			return fmt.Errorf("failed to close tokens: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 子流程等待中的子实例一并取消
+	if err := s.cancelChildren(ctx, instance, operator, comment); err != nil {
+		return err
+	}
+
+	if s.notificationSvc != nil {
+		for _, t := range notify {
+			s.notificationSvc.NotifyTaskCancelled(ctx, t, comment)
+		}
+	}
+
+	log.Printf("[EngineService] Instance %s cancelled by user %d, %d open tasks cancelled", instance.InstanceId.String(), operator, len(open))
+	return nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	delegation_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/delegation"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+)
+
+// maxAutoDelegateHops 受托人也在委托期间时继续转交的最大次数
+const maxAutoDelegateHops = 5
+
+// applyAutoDelegation 处理人有生效的委托规则时，任务交给受托人并记录原处理人
+// 受托人也在委托期间时沿委托规则继续转交，遇到环时停在环前的用户；返回需要保存的 auto_delegate 历史，未委托时返回 nil
+func (s *WorkflowEngineService) applyAutoDelegation(ctx context.Context, task *task_aggregate.Task) *task_aggregate.TaskHistory {
+	if s.delegationRepo == nil || task.Assignee == 0 {
+		return nil
+	}
+
+	original := task.Assignee
+	assignee := original
+	visited := map[int]bool{original: true}
+	now := time.Now()
+	for hop := 0; hop < maxAutoDelegateHops; hop++ {
+		rule, err := s.activeDelegation(ctx, assignee, task.WorkflowID, now)
+		if err != nil {
+			log.Printf("[EngineService] Failed to find delegation rules for user %d: %v", assignee, err)
+			break
+		}
+		if rule == nil || visited[rule.DelegateID] {
+			break
+		}
+		visited[rule.DelegateID] = true
+		assignee = rule.DelegateID
+	}
+	if assignee == original {
+		return nil
+	}
+
+	task.OriginalAssignee = original
+	task.Assignee = assignee
+	log.Printf("[EngineService] Task %s auto delegated from user %d to user %d", task.TaskID.String(), original, assignee)
+
+	history := task_aggregate.NewTaskHistory(task.TaskID, task.InstanceID, task.TaskName, strconv.Itoa(original), "auto_delegate")
+	history.Comment = fmt.Sprintf("用户 %d 委托期间，任务自动转交给用户 %d", original, assignee)
+	return history
+}
+
+// activeDelegation 用户在指定时间对工作流生效的委托规则，多条时取最近创建的
+func (s *WorkflowEngineService) activeDelegation(ctx context.Context, userID int, workflowID valueobject.WorkflowID, at time.Time) (*delegation_aggregate.DelegationRule, error) {
+	rules, err := s.delegationRepo.FindActive(ctx, userID, at)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if rule.Applies(workflowID, at) {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
+
+// FindTaskStep 查找任务所在步骤在实例绑定版本中的定义
+func (s *WorkflowEngineService) FindTaskStep(ctx context.Context, task *task_aggregate.Task) (*StepDefinition, error) {
+	instance, err := s.instanceRepo.FindByID(ctx, task.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instance: %w", err)
+	}
+	definition, err := s.loadDefinition(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	step := s.domainService.FindStepOrParallelTaskByID(task.TaskKey, definition)
+	if step == nil {
+		return nil, fmt.Errorf("step definition not found for task key: %s", task.TaskKey)
+	}
+	return step, nil
+}
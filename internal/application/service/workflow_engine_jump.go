@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"jxt-evidence-system/process-management/internal/application/command"
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// JumpToStep 将实例跳转到指定步骤，用于人工修复卡住或失败的实例
+// 取消所有未处理的任务、定时器和子流程实例，结束分支令牌，根令牌移到目标步骤后重新执行该步骤；
+// 跳转已生效但目标步骤执行失败时，同时返回跳转结果和错误
+func (s *WorkflowEngineService) JumpToStep(ctx context.Context, instanceID valueobject.InstanceID, stepID string) (*command.InstanceJumpResult, error) {
+	instance, err := s.instanceRepo.FindByID(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if instance.Status != status.InstanceStatusRunning && instance.Status != status.InstanceStatusFailed {
+		return nil, fmt.Errorf("%w: instance is %s", errors_.ErrInvalidInstanceStatusTransition, instance.Status)
+	}
+	definition, err := s.loadDefinition(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	step := s.domainService.FindStepByID(stepID, definition)
+	if step == nil {
+		return nil, fmt.Errorf("%w: %s", errors_.ErrJumpTargetNotFound, stepID)
+	}
+
+	tasks, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks: %w", err)
+	}
+	tokens, err := s.tokenRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tokens: %w", err)
+	}
+
+	result := &command.InstanceJumpResult{InstanceID: instance.InstanceId, ToStepID: step.ID, FromSteps: []string{}, CancelledTasks: []string{}}
+	var open []*task_aggregate.Task
+	for _, t := range tasks {
+		if t.IsOpen() {
+			open = append(open, t)
+			result.CancelledTasks = append(result.CancelledTasks, t.TaskID.String())
+		}
+	}
+	from := make(map[string]bool)
+	for _, t := range tokens {
+		if t.IsLive() && t.Status != status.TokenStatusForked {
+			from[t.StepID] = true
+		}
+	}
+	// 引入令牌之前启动的实例按待办任务确定当前位置
+	for _, t := range open {
+		if t.TokenID.IsEmpty() {
+			from[t.TaskKey] = true
+		}
+	}
+	for stepID := range from {
+		result.FromSteps = append(result.FromSteps, stepID)
+	}
+	sort.Strings(result.FromSteps)
+
+	var root *token_aggregate.ExecutionToken
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if instance.Status == status.InstanceStatusFailed {
+			if err := instance.Recover(); err != nil {
+				return err
+			}
+			if err := s.instanceRepo.Update(ctx, instance); err != nil {
+				return fmt.Errorf("failed to update instance: %w", err)
+			}
+		}
+		token, err := s.resetToStep(ctx, instance, open, tokens, step.ID, "管理员跳转流程，任务自动取消")
+		root = token
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 被取消的子流程任务启动的子实例一并取消
+	if err := s.cancelTaskChildren(ctx, instance, open, 0, "管理员跳转流程，子流程自动取消"); err != nil {
+		return result, err
+	}
+
+	log.Printf("[EngineService] Instance %s jumped from %v to step %s, %d tasks cancelled", instance.InstanceId.String(), result.FromSteps, step.ID, len(open))
+
+	return result, s.executeStep(ctx, instance, root, step, definition)
+}
+
+// resetToStep 取消给定的未处理任务和实例的全部定时器，结束分支令牌，根令牌移到目标步骤（没有根令牌时新建）
+// 在调用方的事务中执行，返回根令牌，目标步骤由调用方在事务提交后执行
+func (s *WorkflowEngineService) resetToStep(ctx context.Context, instance *instance_aggregate.WorkflowInstance, open []*task_aggregate.Task, tokens []*token_aggregate.ExecutionToken, stepID, comment string) (*token_aggregate.ExecutionToken, error) {
+	if err := s.cancelOpenTasks(ctx, open, comment); err != nil {
+		return nil, err
+	}
+	if s.timerRepo != nil {
+		if err := s.timerRepo.CancelByInstanceID(ctx, instance.InstanceId); err != nil {
+			return nil, fmt.Errorf("failed to cancel timers: %w", err)
+		}
+	}
+
+	var root *token_aggregate.ExecutionToken
+	for _, t := range tokens {
+		if t.IsRoot() {
+			root = t
+			continue
+		}
+		if !t.IsLive() {
+			continue
+		}
+		t.Cancel()
+		if err := s.tokenRepo.Update(ctx, t); err != nil {
+			return nil, fmt.Errorf("failed to update token: %w", err)
+		}
+	}
+
+	if root == nil {
+		root = token_aggregate.NewRootToken(instance.InstanceId, stepID)
+		if err := s.tokenRepo.Save(ctx, root); err != nil {
+			return nil, fmt.Errorf("failed to save token: %w", err)
+		}
+		return root, nil
+	}
+	root.MoveTo(stepID)
+	if err := s.tokenRepo.Update(ctx, root); err != nil {
+		return nil, fmt.Errorf("failed to update token: %w", err)
+	}
+	return root, nil
+}
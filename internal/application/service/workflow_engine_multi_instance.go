@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// executeMultiInstanceTask 执行会签步骤，为每个处理人创建一个任务
+// 并行会签的任务同时待处理；顺序会签只有第一个任务待处理，其余任务等待轮到
+func (s *WorkflowEngineService) executeMultiInstanceTask(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, step *StepDefinition, config *domain_service.MultiInstanceConfig) error {
+	assignees, err := s.domainService.ResolveAssignees(ctx, config.Assignees, instance)
+	if err != nil {
+		if failErr := s.failInstance(ctx, instance, fmt.Sprintf("会签步骤[%s]处理人解析失败: %v", step.Name, err)); failErr != nil {
+			return failErr
+		}
+		return err
+	}
+	log.Printf("[EngineService] Creating %d %s multi-instance tasks for step: %s", len(assignees), config.Mode, step.Name)
+
+	existing, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks: %w", err)
+	}
+	taskData := s.domainService.BuildTaskData(instance, s.domainService.BuildTaskHistories(existing), nil)
+
+	groupID := valueobject.NewTaskGroupID()
+	tasks := make([]*task_aggregate.Task, 0, len(assignees))
+	var delegated []*task_aggregate.TaskHistory
+	for i, assignee := range assignees {
+		task := task_aggregate.NewTask(instance.InstanceId, instance.WorkflowID)
+		task.TokenID = token.TokenID
+		s.domainService.ApplyStepParamsToTask(task, step, instance)
+		task.Assignee = assignee
+		task.GroupID = groupID
+		task.Sequence = i + 1
+		task.TaskData = taskData
+		if config.Mode == domain_service.MultiInstanceSequential && i > 0 {
+			task.Status = status.TaskStatusWaiting
+		} else if history := s.applyAutoDelegation(ctx, task); history != nil {
+			// 处理人委托期间交给受托人，顺序会签的等待任务在轮到时再检查
+			delegated = append(delegated, history)
+		}
+		tasks = append(tasks, task)
+	}
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.moveToken(ctx, token, step.ID); err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if err := s.taskRepo.Save(ctx, task); err != nil {
+				return fmt.Errorf("failed to save task: %w", err)
+			}
+			if task.Status != status.TaskStatusPending {
+				continue
+			}
+			if err := s.scheduleStepTimeout(ctx, task, step); err != nil {
+				return err
+			}
+		}
+		for _, history := range delegated {
+			if err := s.historyRepo.Save(ctx, history); err != nil {
+				return fmt.Errorf("failed to save task history: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.notificationSvc != nil {
+		for _, task := range tasks {
+			if task.Status == status.TaskStatusPending {
+				s.notificationSvc.NotifyTaskCreated(ctx, task)
+			}
+		}
+	}
+
+	log.Printf("[EngineService] Multi-instance group %s created, waiting for votes", groupID.String())
+	return nil
+}
+
+// continueMultiInstance 会签任务处理后统计投票
+// 会签未结束时等待（顺序会签激活下一个处理人）；结束后取消剩余任务，通过则继续流转，否则驳回回退
+func (s *WorkflowEngineService) continueMultiInstance(ctx context.Context, task *task_aggregate.Task) error {
+	instance, err := s.instanceRepo.FindByID(ctx, task.InstanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance: %w", err)
+	}
+	if instance.Status != status.InstanceStatusRunning {
+		log.Printf("[EngineService] Instance %s is %s, ignoring vote of task %s", instance.InstanceId.String(), instance.Status, task.TaskID.String())
+		return nil
+	}
+
+	definition, err := s.loadDefinition(ctx, instance)
+	if err != nil {
+		return err
+	}
+	step := s.domainService.FindStepOrParallelTaskByID(task.TaskKey, definition)
+	if step == nil {
+		return fmt.Errorf("step definition not found for task key: %s", task.TaskKey)
+	}
+	config, err := domain_service.ParseMultiInstance(step)
+	if err != nil || config == nil {
+		// 定义变更后步骤不再是会签，按全部通过处理已创建的任务组
+		config = &domain_service.MultiInstanceConfig{Mode: domain_service.MultiInstanceParallel, Completion: domain_service.CompletionAll}
+	}
+
+	tasks, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks: %w", err)
+	}
+	group := s.domainService.FindTaskGroup(tasks, task.GroupID)
+	tally := domain_service.TallyMultiInstance(group)
+	required := config.Required(tally.Total)
+	decided, passed := tally.Decide(required)
+
+	log.Printf("[EngineService] Multi-instance group %s: %d/%d approved, %d rejected, %d open, %d required", task.GroupID.String(), tally.Approved, tally.Total, tally.Rejected, tally.Open, required)
+
+	if !decided {
+		if config.Mode == domain_service.MultiInstanceSequential {
+			return s.activateNextSigner(ctx, group, step)
+		}
+		return nil
+	}
+
+	if err := s.cancelOpenTasks(ctx, group, "会签已结束，任务自动取消"); err != nil {
+		return err
+	}
+
+	if passed {
+		log.Printf("[EngineService] Multi-instance group %s approved", task.GroupID.String())
+		return s.continueFlow(ctx, task)
+	}
+	log.Printf("[EngineService] Multi-instance group %s rejected", task.GroupID.String())
+	return s.goBack(ctx, task)
+}
+
+// activateNextSigner 顺序会签中激活序号最小的等待任务
+func (s *WorkflowEngineService) activateNextSigner(ctx context.Context, group []*task_aggregate.Task, step *StepDefinition) error {
+	var waiting []*task_aggregate.Task
+	for _, t := range group {
+		if t.Status == status.TaskStatusPending {
+			return nil // 还有任务在处理中
+		}
+		if t.Status == status.TaskStatusWaiting && t.SourceTaskID.IsEmpty() {
+			waiting = append(waiting, t)
+		}
+	}
+	if len(waiting) == 0 {
+		return nil
+	}
+	sort.SliceStable(waiting, func(i, j int) bool { return waiting[i].Sequence < waiting[j].Sequence })
+
+	next := waiting[0]
+	if err := next.Activate(); err != nil {
+		return err
+	}
+	// 处理人委托期间交给受托人
+	delegated := s.applyAutoDelegation(ctx, next)
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.taskRepo.Update(ctx, next); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		if delegated != nil {
+			if err := s.historyRepo.Save(ctx, delegated); err != nil {
+				return fmt.Errorf("failed to save task history: %w", err)
+			}
+		}
+		return s.scheduleStepTimeout(ctx, next, step)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[EngineService] Sequential multi-instance task %s activated for assignee %d", next.TaskID.String(), next.Assignee)
+	if s.notificationSvc != nil {
+		s.notificationSvc.NotifyTaskCreated(ctx, next)
+	}
+	return nil
+}
+
+// cancelOpenTasks 取消任务组中尚未处理的任务及其超时定时器
+func (s *WorkflowEngineService) cancelOpenTasks(ctx context.Context, group []*task_aggregate.Task, comment string) error {
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		for _, t := range group {
+			if !t.IsOpen() {
+				continue
+			}
+			if err := t.Cancel(comment); err != nil {
+				return err
+			}
+			if err := s.taskRepo.Update(ctx, t); err != nil {
+				return fmt.Errorf("failed to update task: %w", err)
+			}
+			if s.timerRepo != nil {
+				if err := s.timerRepo.CancelByTaskID(ctx, t.TaskID); err != nil {
+					return fmt.Errorf("failed to cancel timers: %w", err)
+				}
+			}
+			log.Printf("[EngineService] Task %s cancelled: %s", t.TaskID.String(), comment)
+		}
+		return nil
+	})
+}
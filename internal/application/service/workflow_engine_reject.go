@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+)
+
+// rejectPlan 驳回的目标任务及回退的令牌
+type rejectPlan struct {
+	target *task_aggregate.Task
+	step   *StepDefinition
+	token  *token_aggregate.ExecutionToken // 回退到目标步骤的令牌
+	// 目标在分支之前时，回退令牌下尚未结束的子令牌一并取消
+	collapse []*token_aggregate.ExecutionToken
+}
+
+// ValidateRejectTarget 驳回前检查任务指定的驳回目标是否可用
+func (s *WorkflowEngineService) ValidateRejectTarget(ctx context.Context, task *task_aggregate.Task) error {
+	instance, err := s.instanceRepo.FindByID(ctx, task.InstanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance: %w", err)
+	}
+	definition, err := s.loadDefinition(ctx, instance)
+	if err != nil {
+		return err
+	}
+	tasks, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks: %w", err)
+	}
+	_, err = s.planReject(ctx, instance, definition, task, tasks)
+	return err
+}
+
+// planReject 按任务的驳回目标确定回到的步骤和令牌
+// 目标任务在当前令牌的祖先令牌上（并行分支之前）时，由祖先令牌回退，分支令牌全部取消
+func (s *WorkflowEngineService) planReject(ctx context.Context, instance *instance_aggregate.WorkflowInstance, definition *WorkflowDefinitionStruct, task *task_aggregate.Task, tasks []*task_aggregate.Task) (*rejectPlan, error) {
+	step := s.domainService.FindStepOrParallelTaskByID(task.TaskKey, definition)
+	if step == nil {
+		return nil, fmt.Errorf("step definition not found for task key: %s", task.TaskKey)
+	}
+
+	token, err := s.taskToken(ctx, instance, task)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.tokenRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tokens: %w", err)
+	}
+	byID := make(map[valueobject.TokenID]*token_aggregate.ExecutionToken, len(tokens))
+	for _, t := range tokens {
+		byID[t.TokenID] = t
+	}
+	onPath := map[valueobject.TokenID]bool{token.TokenID: true}
+	root := token
+	for current := token; current != nil && !current.ParentID.IsEmpty(); current = byID[current.ParentID] {
+		onPath[current.ParentID] = true
+		if parent := byID[current.ParentID]; parent != nil {
+			root = parent
+		}
+	}
+
+	target, err := s.domainService.FindRejectTarget(tasks, task, step, onPath)
+	if err != nil {
+		return nil, err
+	}
+	targetStep := s.domainService.FindStepByID(target.TaskKey, definition)
+	if targetStep == nil {
+		return nil, fmt.Errorf("step definition not found for task key: %s", target.TaskKey)
+	}
+
+	plan := &rejectPlan{target: target, step: targetStep, token: token}
+	if target.TokenID.IsEmpty() {
+		plan.token = root
+	} else if !target.TokenID.Equals(token.TokenID) {
+		plan.token = byID[target.TokenID]
+	}
+	if plan.token != token {
+		for _, t := range tokens {
+			if t.IsLive() && descendsFrom(t, plan.token.TokenID, tokens) {
+				plan.collapse = append(plan.collapse, t)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// collapseBranches 取消分支令牌及其上尚未处理的任务，返回被取消的任务
+func (s *WorkflowEngineService) collapseBranches(ctx context.Context, branches []*token_aggregate.ExecutionToken, tasks []*task_aggregate.Task) ([]*task_aggregate.Task, error) {
+	if len(branches) == 0 {
+		return nil, nil
+	}
+	cancelled := make(map[valueobject.TokenID]bool, len(branches))
+	for _, t := range branches {
+		t.Cancel()
+		if err := s.tokenRepo.Update(ctx, t); err != nil {
+			return nil, fmt.Errorf("failed to update token: %w", err)
+		}
+		cancelled[t.TokenID] = true
+	}
+
+	var open []*task_aggregate.Task
+	for _, t := range tasks {
+		if cancelled[t.TokenID] && t.IsOpen() {
+			open = append(open, t)
+		}
+	}
+	if err := s.cancelOpenTasks(ctx, open, "流程已驳回到分支之前，任务自动取消"); err != nil {
+		return nil, err
+	}
+	log.Printf("[EngineService] %d branch tokens and %d open tasks cancelled by rejection", len(branches), len(open))
+	return open, nil
+}
@@ -7,10 +7,14 @@ import (
 	"fmt"
 	"jxt-evidence-system/process-management/internal/application/command"
 	"jxt-evidence-system/process-management/internal/application/service/port"
+	delegation_repository "jxt-evidence-system/process-management/internal/domain/aggregate/delegation/repository"
 	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
 	instance_repository "jxt-evidence-system/process-management/internal/domain/aggregate/instance/repository"
 	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
 	task_repository "jxt-evidence-system/process-management/internal/domain/aggregate/task/repository"
+	timer_repository "jxt-evidence-system/process-management/internal/domain/aggregate/timer/repository"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	token_repository "jxt-evidence-system/process-management/internal/domain/aggregate/token/repository"
 	workflow_repository "jxt-evidence-system/process-management/internal/domain/aggregate/workflow/repository"
 	domain_service "jxt-evidence-system/process-management/internal/domain/service"
 	"jxt-evidence-system/process-management/internal/domain/valueobject"
@@ -18,50 +22,77 @@ import (
 	"jxt-evidence-system/process-management/shared/common/global"
 	"jxt-evidence-system/process-management/shared/common/status"
 	"log"
-	"strings"
 	"time"
 )
 
 // WorkflowEngineService 工作流引擎服务（应用层）
 // 负责工作流执行的应用协调，依赖领域服务和仓储
 type WorkflowEngineService struct {
-	workflowRepo    workflow_repository.WorkflowRepository
-	instanceRepo    instance_repository.WorkflowInstanceRepository
-	taskRepo        task_repository.TaskRepository
-	domainService   domain_service.WorkflowDomainService
-	notificationSvc port.NotificationService // 通知服务（可选）
+	workflowRepo     workflow_repository.WorkflowRepository
+	versionRepo      workflow_repository.WorkflowVersionRepository
+	instanceRepo     instance_repository.WorkflowInstanceRepository
+	taskRepo         task_repository.TaskRepository
+	historyRepo      task_repository.TaskHistoryRepository
+	timerRepo        timer_repository.TimerRepository
+	tokenRepo        token_repository.TokenRepository
+	domainService    domain_service.WorkflowDomainService
+	notificationSvc  port.NotificationService                   // 通知服务（可选）
+	processHandlers  port.ProcessHandlerRegistry                // 自动化步骤处理器
+	txManager        port.TransactionManager                    // 事务管理器（可选），令牌与任务在同一事务中更新
+	assigneeResolver port.AssigneeResolver                      // 处理人表达式解析器
+	delegationRepo   delegation_repository.DelegationRepository // 委托规则仓储（可选），分配任务时查找处理人的委托规则
 }
 
 // NewWorkflowEngineService 创建工作流引擎服务
 func NewWorkflowEngineService(
 	workflowRepo workflow_repository.WorkflowRepository,
+	versionRepo workflow_repository.WorkflowVersionRepository,
 	instanceRepo instance_repository.WorkflowInstanceRepository,
 	taskRepo task_repository.TaskRepository,
+	historyRepo task_repository.TaskHistoryRepository,
+	timerRepo timer_repository.TimerRepository,
+	tokenRepo token_repository.TokenRepository,
 	domainService domain_service.WorkflowDomainService,
 ) *WorkflowEngineService {
 	return &WorkflowEngineService{
-		workflowRepo:    workflowRepo,
-		instanceRepo:    instanceRepo,
-		taskRepo:        taskRepo,
-		domainService:   domainService,
-		notificationSvc: NewNoOpNotificationService(), // 默认使用空操作通知服务
+		workflowRepo:     workflowRepo,
+		versionRepo:      versionRepo,
+		instanceRepo:     instanceRepo,
+		taskRepo:         taskRepo,
+		historyRepo:      historyRepo,
+		timerRepo:        timerRepo,
+		tokenRepo:        tokenRepo,
+		domainService:    domainService,
+		notificationSvc:  NewNoOpNotificationService(), // 默认使用空操作通知服务
+		processHandlers:  NewProcessHandlerRegistry(nil),
+		assigneeResolver: NewInMemoryAssigneeResolver(),
 	}
 }
 
 // NewWorkflowEngineServiceWithNotification 创建工作流引擎服务（带通知服务）
 func NewWorkflowEngineServiceWithNotification(
 	workflowRepo workflow_repository.WorkflowRepository,
+	versionRepo workflow_repository.WorkflowVersionRepository,
 	instanceRepo instance_repository.WorkflowInstanceRepository,
 	taskRepo task_repository.TaskRepository,
+	historyRepo task_repository.TaskHistoryRepository,
+	timerRepo timer_repository.TimerRepository,
+	tokenRepo token_repository.TokenRepository,
 	domainService domain_service.WorkflowDomainService,
 	notificationSvc port.NotificationService,
 ) *WorkflowEngineService {
 	return &WorkflowEngineService{
-		workflowRepo:    workflowRepo,
-		instanceRepo:    instanceRepo,
-		taskRepo:        taskRepo,
-		domainService:   domainService,
-		notificationSvc: notificationSvc,
+		workflowRepo:     workflowRepo,
+		versionRepo:      versionRepo,
+		instanceRepo:     instanceRepo,
+		taskRepo:         taskRepo,
+		historyRepo:      historyRepo,
+		timerRepo:        timerRepo,
+		tokenRepo:        tokenRepo,
+		domainService:    domainService,
+		notificationSvc:  notificationSvc,
+		processHandlers:  NewProcessHandlerRegistry(nil),
+		assigneeResolver: NewInMemoryAssigneeResolver(),
 	}
 }
 
@@ -70,6 +101,39 @@ func (s *WorkflowEngineService) SetNotificationService(svc port.NotificationServ
 	s.notificationSvc = svc
 }
 
+// SetProcessHandlerRegistry 设置自动化步骤处理器注册表
+func (s *WorkflowEngineService) SetProcessHandlerRegistry(registry port.ProcessHandlerRegistry) {
+	s.processHandlers = registry
+}
+
+// SetTransactionManager 设置事务管理器
+func (s *WorkflowEngineService) SetTransactionManager(txManager port.TransactionManager) {
+	s.txManager = txManager
+}
+
+// SetAssigneeResolver 设置处理人表达式解析器
+func (s *WorkflowEngineService) SetAssigneeResolver(resolver port.AssigneeResolver) {
+	s.assigneeResolver = resolver
+}
+
+// SetDelegationRepository 设置委托规则仓储
+func (s *WorkflowEngineService) SetDelegationRepository(repo delegation_repository.DelegationRepository) {
+	s.delegationRepo = repo
+}
+
+// inTransaction 在事务中执行 fn，未配置事务管理器时直接执行
+func (s *WorkflowEngineService) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return runInTransaction(ctx, s.txManager, fn)
+}
+
+// runInTransaction 通过事务管理器执行 fn，txManager 为空时直接执行
+func runInTransaction(ctx context.Context, txManager port.TransactionManager, fn func(ctx context.Context) error) error {
+	if txManager == nil {
+		return fn(ctx)
+	}
+	return txManager.Transaction(ctx, fn)
+}
+
 // StepDefinition 步骤定义（从领域服务导入）
 type StepDefinition = domain_service.StepDefinition
 
@@ -88,70 +152,117 @@ func (s *WorkflowEngineService) StartInstance(ctx context.Context, instanceID va
 		}
 		return fmt.Errorf("failed to find instance: %w", err)
 	}
-	// 获取工作流定义
-	wf, err := s.workflowRepo.FindByID(ctx, instance.WorkflowID)
+	// 获取实例绑定版本的工作流定义
+	definition, err := s.loadDefinition(ctx, instance)
 	if err != nil {
-		if errors.Is(err, errors_.ErrWorkflowNotFound) {
-			return fmt.Errorf("workflow not found: %s", instance.WorkflowID.String())
-		}
-		return fmt.Errorf("failed to find workflow: %w", err)
-	}
-
-	// 解析工作流定义
-	var definition WorkflowDefinitionStruct
-	if err := json.Unmarshal([]byte(wf.Definition), &definition); err != nil {
-		return fmt.Errorf("failed to parse workflow definition: %w", err)
+		return err
 	}
 
 	if len(definition.Steps) == 0 {
 		return fmt.Errorf("workflow has no steps")
 	}
 
-	// 更新实例状态为运行中
+	// 更新实例状态为运行中，并创建根令牌
 	instance.Status = status.InstanceStatusRunning
 	instance.StartedAt = time.Now()
+	token := token_aggregate.NewRootToken(instance.InstanceId, definition.Steps[0].ID)
 
-	if err := s.instanceRepo.Update(ctx, instance); err != nil {
-		return fmt.Errorf("failed to update instance: %w", err)
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.instanceRepo.Update(ctx, instance); err != nil {
+			return fmt.Errorf("failed to update instance: %w", err)
+		}
+		if err := s.tokenRepo.Save(ctx, token); err != nil {
+			return fmt.Errorf("failed to save token: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	log.Printf("[EngineService] Instance started, executing first step: %s", definition.Steps[0].Name)
 
 	// 执行第一步
-	return s.executeStep(ctx, instance, &definition.Steps[0], &definition)
+	return s.executeStep(ctx, instance, token, &definition.Steps[0], definition)
+}
+
+// loadDefinition 加载并解析实例绑定版本的工作流定义
+// 版本号为 0 的实例（引入版本之前创建）回退到工作流当前定义
+func (s *WorkflowEngineService) loadDefinition(ctx context.Context, instance *instance_aggregate.WorkflowInstance) (*WorkflowDefinitionStruct, error) {
+	var raw string
+	if instance.WorkflowVersion > 0 {
+		version, err := s.versionRepo.FindByVersion(ctx, instance.WorkflowID, instance.WorkflowVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find workflow version %d: %w", instance.WorkflowVersion, err)
+		}
+		raw = version.Definition
+	} else {
+		wf, err := s.workflowRepo.FindByID(ctx, instance.WorkflowID)
+		if err != nil {
+			if errors.Is(err, errors_.ErrWorkflowNotFound) {
+				return nil, fmt.Errorf("workflow not found: %s", instance.WorkflowID.String())
+			}
+			return nil, fmt.Errorf("failed to find workflow: %w", err)
+		}
+		raw = wf.Definition
+	}
+
+	var definition WorkflowDefinitionStruct
+	if err := json.Unmarshal([]byte(raw), &definition); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow definition: %w", err)
+	}
+	return &definition, nil
 }
 
 // executeStep 执行工作流步骤
-func (s *WorkflowEngineService) executeStep(ctx context.Context, instance *instance_aggregate.WorkflowInstance, step *StepDefinition, definition *WorkflowDefinitionStruct) error {
+// token 为执行该步骤的令牌，步骤创建的任务都关联到该令牌
+func (s *WorkflowEngineService) executeStep(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, step *StepDefinition, definition *WorkflowDefinitionStruct) error {
 	log.Printf("[EngineService] Executing step: %s (type: %s) for instance: %s", step.Name, step.Type, instance.InstanceId.String())
 
 	switch step.Type {
-	case "userTask":
-		return s.executeUserTask(ctx, instance, step)
-	case "process":
-		return s.executeProcessTask(ctx, instance, step, definition)
-	case "parallel":
-		return s.executeParallelTasks(ctx, instance, step, definition)
-	case "complete":
-		return s.completeInstance(ctx, instance, step, definition)
+	case domain_service.StepTypeUserTask:
+		return s.executeUserTask(ctx, instance, token, step)
+	case domain_service.StepTypeProcess:
+		return s.executeProcessTask(ctx, instance, token, step, definition)
+	case domain_service.StepTypeSubProcess:
+		return s.executeSubProcess(ctx, instance, token, step)
+	case domain_service.StepTypeParallel:
+		return s.executeParallelTasks(ctx, instance, token, step, definition)
+	case domain_service.StepTypeExclusiveGateway, domain_service.StepTypeInclusiveGateway, domain_service.StepTypeParallelGateway:
+		return s.executeGateway(ctx, instance, token, step, definition)
+	case domain_service.StepTypeComplete:
+		return s.completeInstance(ctx, instance, token, step, definition)
 	default:
 		log.Printf("[EngineService] Unknown step type: %s, skipping", step.Type)
+		if err := s.moveToken(ctx, token, step.ID); err != nil {
+			return err
+		}
 		// 未知类型，尝试执行下一步
-		return s.executeNextStep(ctx, instance, step, definition)
+		return s.executeNextStep(ctx, instance, token, step, definition)
 	}
 }
 
 // executeUserTask 执行用户任务步骤
-func (s *WorkflowEngineService) executeUserTask(ctx context.Context, instance *instance_aggregate.WorkflowInstance, step *StepDefinition) error {
+func (s *WorkflowEngineService) executeUserTask(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, step *StepDefinition) error {
+	config, err := domain_service.ParseMultiInstance(step)
+	if err != nil {
+		return fmt.Errorf("invalid multiInstance config for step %s: %w", step.ID, err)
+	}
+	if config != nil {
+		return s.executeMultiInstanceTask(ctx, instance, token, step, config)
+	}
+
 	log.Printf("[EngineService] Creating user task for step: %s", step.Name)
 
 	// 创建用户任务
 	task := task_aggregate.NewTask(instance.InstanceId, instance.WorkflowID)
+	task.TokenID = token.TokenID
 	if userID, ok := ctx.Value(global.UserIDKey).(int); ok {
 		task.Assignee = userID
 	}
 	// 从步骤参数设置任务属性
 	s.domainService.ApplyStepParamsToTask(task, step, instance)
+	s.applyAssigneeExpression(ctx, task, step, instance)
 
 	tasks, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
 	if err != nil {
@@ -172,12 +283,30 @@ func (s *WorkflowEngineService) executeUserTask(ctx context.Context, instance *i
 		}
 	}
 
+	// 处理人委托期间交给受托人
+	delegated := s.applyAutoDelegation(ctx, task)
+
 	// 构建任务数据
 	task.TaskData = s.domainService.BuildTaskData(instance, taskHistories, nil)
 
-	// 保存任务
-	if err := s.taskRepo.Save(ctx, task); err != nil {
-		return fmt.Errorf("failed to save task: %w", err)
+	// 令牌流转、保存任务和超时定时器在同一事务中完成
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.moveToken(ctx, token, step.ID); err != nil {
+			return err
+		}
+		if err := s.taskRepo.Save(ctx, task); err != nil {
+			return fmt.Errorf("failed to save task: %w", err)
+		}
+		if delegated != nil {
+			if err := s.historyRepo.Save(ctx, delegated); err != nil {
+				return fmt.Errorf("failed to save task history: %w", err)
+			}
+		}
+		// 步骤配置了超时时间时创建超时定时器
+		return s.scheduleStepTimeout(ctx, task, step)
+	})
+	if err != nil {
+		return err
 	}
 
 	log.Printf("[EngineService] User task created: %s (ID: %s)", task.TaskName, task.TaskID.String())
@@ -193,54 +322,200 @@ func (s *WorkflowEngineService) executeUserTask(ctx context.Context, instance *i
 }
 
 // executeProcessTask 执行自动化处理任务
-func (s *WorkflowEngineService) executeProcessTask(ctx context.Context, instance *instance_aggregate.WorkflowInstance, step *StepDefinition, definition *WorkflowDefinitionStruct) error {
+func (s *WorkflowEngineService) executeProcessTask(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, step *StepDefinition, definition *WorkflowDefinitionStruct) error {
 	log.Printf("[EngineService] Executing automated process task: %s", step.Name)
 
-	// 自动化任务直接执行完成，继续下一步
-	// 这里可以根据需要添加实际的处理逻辑
-	// 创建用户任务
 	task := task_aggregate.NewTask(instance.InstanceId, instance.WorkflowID)
+	task.TokenID = token.TokenID
 
 	// 从步骤参数设置任务属性
 	s.domainService.ApplyStepParamsToTask(task, step, instance)
-	task.Status = status.TaskStatusCompleted
-	task.Result = status.TaskResultApproved
-	// 保存任务
-	if err := s.taskRepo.Save(ctx, task); err != nil {
-		return fmt.Errorf("failed to save task: %w", err)
+	// 先以待处理状态保存，执行失败时由重试定时器继续执行
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.moveToken(ctx, token, step.ID); err != nil {
+			return err
+		}
+		if err := s.taskRepo.Save(ctx, task); err != nil {
+			return fmt.Errorf("failed to save task: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.attemptProcessTask(ctx, instance, task, step, definition, 1)
+}
+
+// attemptProcessTask 第 attempt 次执行自动化任务
+// 失败且未超过重试次数时创建重试定时器，重试耗尽后实例置为失败
+func (s *WorkflowEngineService) attemptProcessTask(ctx context.Context, instance *instance_aggregate.WorkflowInstance, task *task_aggregate.Task, step *StepDefinition, definition *WorkflowDefinitionStruct, attempt int) error {
+	output, runErr := s.runProcessStep(ctx, instance, task, step)
+
+	// 记录每一次执行
+	if s.historyRepo != nil {
+		history := task_aggregate.NewTaskHistory(task.TaskID, task.InstanceID, task.TaskName, fmt.Sprintf("%d", task.Assignee), "attempt")
+		if runErr == nil {
+			history.Result = status.TaskResultApproved
+			history.Comment = fmt.Sprintf("第%d次执行成功", attempt)
+			history.Output = output
+		} else {
+			history.Comment = fmt.Sprintf("第%d次执行失败: %v", attempt, runErr)
+		}
+		if err := s.historyRepo.Save(ctx, history); err != nil {
+			return fmt.Errorf("failed to save task history: %w", err)
+		}
+	}
+
+	if runErr == nil {
+		task.Status = status.TaskStatusCompleted
+		task.Result = status.TaskResultApproved
+		task.Output = output
+		if err := s.taskRepo.Update(ctx, task); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		token, err := s.taskToken(ctx, instance, task)
+		if err != nil {
+			return err
+		}
+		return s.advance(ctx, instance, token, step, definition)
+	}
+
+	log.Printf("[EngineService] Process task %s attempt %d failed: %v", task.TaskID.String(), attempt, runErr)
+
+	if attempt <= step.Retries {
+		return s.scheduleProcessRetry(ctx, task, step, attempt)
+	}
+
+	// 重试耗尽，任务和实例在同一事务中置为失败
+	// 失败已记录在任务和实例上，不再向调用方返回错误，避免启动实例或完成上一任务的请求被当作失败
+	task.Status = status.TaskStatusFailed
+	task.Comment = runErr.Error()
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.taskRepo.Update(ctx, task); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		return s.failInstance(ctx, instance, fmt.Sprintf("步骤[%s]执行失败（共执行%d次）: %v", step.Name, attempt, runErr))
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[EngineService] Instance %s failed at step %s after %d attempts: %v", instance.InstanceId.String(), step.ID, attempt, runErr)
+
+	return nil
+}
+
+// runProcessStep 按 params.handler 调用处理器执行自动化步骤，返回的输出写入 Task.Output
+func (s *WorkflowEngineService) runProcessStep(ctx context.Context, instance *instance_aggregate.WorkflowInstance, task *task_aggregate.Task, step *StepDefinition) (json.RawMessage, error) {
+	handlerName := DefaultProcessHandler
+	if name, ok := step.Params["handler"].(string); ok && name != "" {
+		handlerName = name
+	}
+	handler, ok := s.processHandlers.Get(handlerName)
+	if !ok {
+		return nil, fmt.Errorf("process handler not found: %s", handlerName)
+	}
+
+	input, err := s.domainService.ParseInstanceInput(instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse instance input: %w", err)
+	}
+	tasks, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks: %w", err)
+	}
+
+	pctx := &port.ProcessContext{
+		InstanceID:  instance.InstanceId,
+		WorkflowID:  instance.WorkflowID,
+		StepID:      step.ID,
+		Params:      step.Params,
+		Input:       input,
+		StepOutputs: s.domainService.BuildStepOutputs(tasks),
+	}
+
+	log.Printf("[EngineService] Running process handler %s for task %s", handlerName, task.TaskID.String())
+
+	output, err := handler.Handle(ctx, pctx)
+	if err != nil {
+		return nil, err
+	}
+	if output == nil {
+		return nil, nil
 	}
 
-	return s.executeNextStep(ctx, instance, step, definition)
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal process output: %w", err)
+	}
+	return data, nil
 }
 
 // completeInstance 完成工作流实例
-func (s *WorkflowEngineService) completeInstance(ctx context.Context, instance *instance_aggregate.WorkflowInstance, step *StepDefinition, definition *WorkflowDefinitionStruct) error {
+func (s *WorkflowEngineService) completeInstance(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, step *StepDefinition, definition *WorkflowDefinitionStruct) error {
 	log.Printf("[EngineService] Completing instance: %s", instance.InstanceId.String())
 
 	task := task_aggregate.NewTask(instance.InstanceId, instance.WorkflowID)
+	task.TokenID = token.TokenID
 	// 从步骤参数设置任务属性
 	s.domainService.ApplyStepParamsToTask(task, step, instance)
 	task.Status = status.TaskStatusCompleted
 	task.Result = status.TaskResultApproved
-	// 保存任务
-	if err := s.taskRepo.Save(ctx, task); err != nil {
-		return fmt.Errorf("failed to save task: %w", err)
-	}
 	now := time.Now()
 	instance.Status = status.InstanceStatusCompleted
 	instance.CompletedAt = &now
 
-	if err := s.instanceRepo.Update(ctx, instance); err != nil {
-		return fmt.Errorf("failed to update instance: %w", err)
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.moveToken(ctx, token, step.ID); err != nil {
+			return err
+		}
+		// 保存任务
+		if err := s.taskRepo.Save(ctx, task); err != nil {
+			return fmt.Errorf("failed to save task: %w", err)
+		}
+		if err := s.instanceRepo.Update(ctx, instance); err != nil {
+			return fmt.Errorf("failed to update instance: %w", err)
+		}
+		// 结束步骤可能由某个分支到达，其余分支的令牌一并结束
+		if err := s.tokenRepo.CloseByInstanceID(ctx, instance.InstanceId, status.TokenStatusCompleted); err != nil {
+			return fmt.Errorf("failed to close tokens: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	log.Printf("[EngineService] Instance completed successfully")
 
+	// 通知流程发起人
+	if s.notificationSvc != nil {
+		s.notificationSvc.NotifyWorkflowCompleted(ctx, instance)
+	}
+
+	// 子实例完成后继续父实例
+	if instance.IsSubInstance() {
+		return s.resumeParent(ctx, instance)
+	}
 	return nil
 }
 
 // ContinueAfterTask 任务完成后继续执行流程
+// 有加签时等加签任务全部处理完，会签任务先统计投票，会签结束后才继续
 func (s *WorkflowEngineService) ContinueAfterTask(ctx context.Context, task *task_aggregate.Task) error {
+	seat, err := s.settleSigners(ctx, task)
+	if err != nil || seat == nil {
+		return err
+	}
+	if !seat.GroupID.IsEmpty() {
+		return s.continueMultiInstance(ctx, seat)
+	}
+	return s.continueFlow(ctx, seat)
+}
+
+// continueFlow 令牌从任务所在步骤继续流转
+func (s *WorkflowEngineService) continueFlow(ctx context.Context, task *task_aggregate.Task) error {
 	log.Printf("[EngineService] Continuing workflow after task completion: %s", task.TaskID.String())
 
 	// 获取实例
@@ -252,49 +527,36 @@ func (s *WorkflowEngineService) ContinueAfterTask(ctx context.Context, task *tas
 	if instance == nil {
 		return fmt.Errorf("instance not found: %s", task.InstanceID.String())
 	}
+	// 实例已取消或挂起时不再流转
+	if instance.Status != status.InstanceStatusRunning {
+		log.Printf("[EngineService] Instance %s is %s, not continuing", instance.InstanceId.String(), instance.Status)
+		return nil
+	}
 
-	// 获取工作流定义
-	wf, err := s.workflowRepo.FindByID(ctx, instance.WorkflowID)
+	// 获取实例绑定版本的工作流定义
+	definition, err := s.loadDefinition(ctx, instance)
 	if err != nil {
-		return fmt.Errorf("failed to find workflow: %w", err)
+		return err
 	}
 
-	if wf == nil {
-		return fmt.Errorf("workflow not found: %s", instance.WorkflowID.String())
+	// 当前位置以任务的令牌为准
+	token, err := s.taskToken(ctx, instance, task)
+	if err != nil {
+		return err
 	}
-
-	// 解析工作流定义
-	var definition WorkflowDefinitionStruct
-	if err := json.Unmarshal([]byte(wf.Definition), &definition); err != nil {
-		return fmt.Errorf("failed to parse workflow definition: %w", err)
+	if token.Status != status.TokenStatusActive {
+		log.Printf("[EngineService] Token %s is %s, not continuing", token.TokenID.String(), token.Status)
+		return nil
 	}
-
-	// 找到当前步骤
-	var currentStep *StepDefinition
-	for i := range definition.Steps {
-		if definition.Steps[i].ID == task.TaskKey {
-			currentStep = &definition.Steps[i]
-			break
-		}
+	if token.StepID != task.TaskKey {
+		log.Printf("[EngineService] Token %s has moved to step %s, task %s (step %s) does not continue the flow", token.TokenID.String(), token.StepID, task.TaskID.String(), task.TaskKey)
+		return nil
 	}
 
+	// 找到当前步骤（顶层步骤或并行任务）
+	currentStep := s.domainService.FindStepOrParallelTaskByID(token.StepID, definition)
 	if currentStep == nil {
-		return fmt.Errorf("current step not found: %s", task.TaskKey)
-	}
-
-	// 检查是否是并行任务的一部分
-	// 注意：只有当步骤类型是 "parallel" 时才需要检查并行任务
-	// 不能简单地根据 taskKey 包含 "_" 来判断，因为步骤ID本身就可能包含 "_"
-	if currentStep != nil && currentStep.Type == "parallel" {
-		// 检查该并行步骤的所有任务是否都已完成
-		allCompleted, err := s.checkParallelTasksCompleted(ctx, instance, currentStep.ID)
-		if err != nil {
-			log.Printf("[EngineService] Failed to check parallel tasks: %v", err)
-		} else if !allCompleted {
-			log.Printf("[EngineService] Not all parallel tasks completed yet, waiting")
-			return nil // 还有其他并行任务未完成，暂不继续
-		}
-		log.Printf("[EngineService] All parallel tasks for step %s completed", currentStep.ID)
+		return fmt.Errorf("current step not found: %s", token.StepID)
 	}
 
 	// 更新实例状态为运行中
@@ -304,14 +566,37 @@ func (s *WorkflowEngineService) ContinueAfterTask(ctx context.Context, task *tas
 		return fmt.Errorf("failed to update instance: %w", err)
 	}
 
+	// 处理驳回产生的任务时可跳过中间步骤，直接提交回发起驳回的步骤
+	if task.DirectResubmit {
+		returnStep := s.domainService.FindStepByID(task.ReturnTaskKey, definition)
+		if returnStep == nil {
+			return fmt.Errorf("return step not found: %s", task.ReturnTaskKey)
+		}
+		log.Printf("[EngineService] Task %s resubmitted directly to step %s", task.TaskID.String(), returnStep.ID)
+		return s.executeStep(ctx, instance, token, returnStep, definition)
+	}
+
 	log.Printf("[EngineService] Instance resumed, finding next step")
 
 	// 执行下一步
-	return s.executeNextStep(ctx, instance, currentStep, &definition)
+	return s.advance(ctx, instance, token, currentStep, definition)
 }
 
-// RejectAndGoBack 驳回任务并回退到上一个步骤
+// RejectAndGoBack 驳回任务并回退到驳回目标步骤
+// 前加签人驳回只交回原处理人，会签任务先统计投票，会签未通过时才回退
 func (s *WorkflowEngineService) RejectAndGoBack(ctx context.Context, task *task_aggregate.Task) error {
+	seat, err := s.settleSigners(ctx, task)
+	if err != nil || seat == nil {
+		return err
+	}
+	if !seat.GroupID.IsEmpty() {
+		return s.continueMultiInstance(ctx, seat)
+	}
+	return s.goBack(ctx, seat)
+}
+
+// goBack 令牌回退到驳回目标步骤，未指定目标时回到任务之前最后完成的步骤
+func (s *WorkflowEngineService) goBack(ctx context.Context, task *task_aggregate.Task) error {
 	log.Printf("[EngineService] Rejecting task and going back: %s", task.TaskID.String())
 
 	// 获取实例
@@ -322,20 +607,16 @@ func (s *WorkflowEngineService) RejectAndGoBack(ctx context.Context, task *task_
 	if instance == nil {
 		return fmt.Errorf("instance not found: %s", task.InstanceID.String())
 	}
-
-	// 获取工作流定义
-	wf, err := s.workflowRepo.FindByID(ctx, instance.WorkflowID)
-	if err != nil {
-		return fmt.Errorf("failed to find workflow: %w", err)
-	}
-	if wf == nil {
-		return fmt.Errorf("workflow not found: %s", instance.WorkflowID.String())
+	// 实例已取消或挂起时不再回退
+	if instance.Status != status.InstanceStatusRunning {
+		log.Printf("[EngineService] Instance %s is %s, not going back", instance.InstanceId.String(), instance.Status)
+		return nil
 	}
 
-	// 解析工作流定义
-	var definition WorkflowDefinitionStruct
-	if err := json.Unmarshal([]byte(wf.Definition), &definition); err != nil {
-		return fmt.Errorf("failed to parse workflow definition: %w", err)
+	// 获取实例绑定版本的工作流定义
+	definition, err := s.loadDefinition(ctx, instance)
+	if err != nil {
+		return err
 	}
 
 	tasks, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
@@ -347,50 +628,80 @@ func (s *WorkflowEngineService) RejectAndGoBack(ctx context.Context, task *task_
 		return fmt.Errorf("没有发现实例: %s的任务", instance.InstanceId.String())
 	}
 
-	// 找出当前任务之前最后完成的任务
-	previousTask := s.domainService.FindPreviousCompletedTask(tasks, task.TaskID)
-	if previousTask == nil {
-		return fmt.Errorf("cannot reject first task, no previous completed task found")
-	}
-
-	log.Printf("[EngineService] Found previous task: %s (TaskKey: %s, Assignee: %s)", previousTask.TaskName, previousTask.TaskKey, previousTask.Assignee)
-
-	// 从工作流定义中查找对应的步骤定义
-	previousStep := s.domainService.FindStepByID(previousTask.TaskKey, &definition)
-	if previousStep == nil {
-		return fmt.Errorf("step definition not found for task key: %s", previousTask.TaskKey)
+	// 找出驳回要回到的任务：指定的目标步骤、发起人或之前最后完成的任务
+	plan, err := s.planReject(ctx, instance, definition, task, tasks)
+	if err != nil {
+		return err
 	}
+	previousTask, previousStep, token := plan.target, plan.step, plan.token
 
+	log.Printf("[EngineService] Found previous task: %s (TaskKey: %s, Assignee: %d)", previousTask.TaskName, previousTask.TaskKey, previousTask.Assignee)
 	log.Printf("[EngineService] Found step definition: %s (%s)", previousStep.Name, previousStep.ID)
 
-	// 创建新任务回退到上一个步骤
+	// 创建新任务回退到目标步骤
 	newTask := task_aggregate.NewTask(instance.InstanceId, instance.WorkflowID)
+	newTask.TokenID = token.TokenID
 	newTask.TaskType = previousStep.Type
 	newTask.Description = previousStep.Description
 
 	s.domainService.ApplyStepParamsToTask(newTask, previousStep, instance)
+	s.applyAssigneeExpression(ctx, newTask, previousStep, instance)
 
-	// 设置任务分配：优先使用上一个任务的处理人
+	// 设置任务分配：驳回到发起人时交给实例发起人，否则优先使用上一个任务的处理人
 	previousTaskAssignee := previousTask.Assignee
-	if previousTask.Assignee != 0 {
-		newTask.Assignee = previousTask.Assignee
-		log.Printf("[EngineService] Set assignee from previous task: %s", previousTask.Assignee)
+	if task.RejectToInitiator && instance.InitiatorID != 0 {
+		previousTaskAssignee = instance.InitiatorID
+	}
+	if previousTaskAssignee != 0 {
+		newTask.Assignee = previousTaskAssignee
+		log.Printf("[EngineService] Set assignee from previous task: %d", previousTaskAssignee)
 	}
+	// 未跨越分支回退时，重新提交可直接回到发起驳回的步骤
+	if len(plan.collapse) == 0 {
+		newTask.ReturnTaskKey = task.TaskKey
+	}
+
+	// 处理人委托期间交给受托人
+	delegated := s.applyAutoDelegation(ctx, newTask)
 
 	// 构建任务历史和任务数据
 	taskHistories := s.domainService.BuildTaskHistories(tasks)
 	newTask.TaskData = s.domainService.BuildTaskData(instance, taskHistories, nil)
 
-	// 保存新任务
-	if err := s.taskRepo.Save(ctx, newTask); err != nil {
-		return fmt.Errorf("failed to save new task: %w", err)
+	// 分支取消、令牌回退、保存新任务和超时定时器在同一事务中完成
+	var collapsed []*task_aggregate.Task
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		cancelled, err := s.collapseBranches(ctx, plan.collapse, tasks)
+		if err != nil {
+			return err
+		}
+		collapsed = cancelled
+		if err := s.moveToken(ctx, token, previousStep.ID); err != nil {
+			return err
+		}
+		if err := s.taskRepo.Save(ctx, newTask); err != nil {
+			return fmt.Errorf("failed to save new task: %w", err)
+		}
+		if delegated != nil {
+			if err := s.historyRepo.Save(ctx, delegated); err != nil {
+				return fmt.Errorf("failed to save task history: %w", err)
+			}
+		}
+		return s.scheduleStepTimeout(ctx, newTask, previousStep)
+	})
+	if err != nil {
+		return err
 	}
-
 	log.Printf("[EngineService] Created new task for previous step: %s", newTask.TaskID.String())
 
+	// 被取消分支上的子流程任务启动的子实例一并取消
+	if err := s.cancelTaskChildren(ctx, instance, collapsed, task.Assignee, "流程已驳回到分支之前，子流程自动取消"); err != nil {
+		return err
+	}
+
 	// 发送通知（如果有通知服务）
 	if s.notificationSvc != nil {
-		s.notificationSvc.NotifyTaskAssigned(ctx, newTask, previousTaskAssignee)
+		s.notificationSvc.NotifyTaskAssigned(ctx, newTask, newTask.Assignee)
 	}
 
 	log.Printf("[EngineService] Task rejection and rollback completed successfully")
@@ -398,96 +709,91 @@ func (s *WorkflowEngineService) RejectAndGoBack(ctx context.Context, task *task_
 }
 
 // executeNextStep 执行下一个步骤
-func (s *WorkflowEngineService) executeNextStep(ctx context.Context, instance *instance_aggregate.WorkflowInstance, currentStep *StepDefinition, definition *WorkflowDefinitionStruct) error {
+func (s *WorkflowEngineService) executeNextStep(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, currentStep *StepDefinition, definition *WorkflowDefinitionStruct) error {
 	// 找到下一个步骤
-	nextStep := s.domainService.FindNextStep(currentStep, definition, instance)
+	nextStep := s.domainService.FindNextStep(ctx, currentStep, definition, instance)
 
 	if nextStep == nil {
-		// 没有下一步，完成流程
-		log.Printf("[EngineService] No next step found, completing instance")
-		return s.completeInstance(ctx, instance, currentStep, definition)
+		// 没有下一步，当前令牌结束（根令牌结束时完成流程）
+		log.Printf("[EngineService] No next step found, finishing token %s", token.TokenID.String())
+		return s.finishToken(ctx, instance, token, currentStep, definition)
 	}
 
 	log.Printf("[EngineService] Found next step: %s", nextStep.Name)
 
 	// 执行下一步
-	return s.executeStep(ctx, instance, nextStep, definition)
+	return s.executeStep(ctx, instance, token, nextStep, definition)
 }
 
 // executeParallelTasks 执行并行任务
-func (s *WorkflowEngineService) executeParallelTasks(ctx context.Context, instance *instance_aggregate.WorkflowInstance, step *StepDefinition, definition *WorkflowDefinitionStruct) error {
+// 每个并行任务由一个子令牌执行，全部子令牌结束后父令牌继续执行并行步骤的下一步
+func (s *WorkflowEngineService) executeParallelTasks(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, step *StepDefinition, definition *WorkflowDefinitionStruct) error {
 	log.Printf("[EngineService] Executing parallel tasks for step: %s", step.Name)
 
-	if len(step.ParallelTasks) == 0 {
-		log.Printf("[EngineService] No parallel tasks defined, continuing to next step")
-		return s.executeNextStep(ctx, instance, step, definition)
-	}
-
-	// 创建所有并行任务
-	var createdTasks []string
-	for _, parallelStep := range step.ParallelTasks {
-		// 检查条件
+	// 筛选满足条件的并行任务
+	var eligible []*StepDefinition
+	vars := s.domainService.NewVariableSnapshot(ctx, instance)
+	for i := range step.ParallelTasks {
+		parallelStep := &step.ParallelTasks[i]
 		if parallelStep.Condition != "" {
-			if !s.domainService.EvaluateCondition(parallelStep.Condition, instance) {
+			if !s.domainService.EvaluateConditionWithSnapshot(parallelStep.Condition, instance, vars) {
 				log.Printf("[EngineService] Parallel task condition not met: %s", parallelStep.Condition)
 				continue
 			}
 		}
-
-		// 执行并行步骤（通常是 user_task）
-		if parallelStep.Type == "userTask" {
-			if err := s.executeUserTask(ctx, instance, &parallelStep); err != nil {
-				log.Printf("[EngineService] Failed to create parallel task %s: %v", parallelStep.Name, err)
-				continue
-			}
-			createdTasks = append(createdTasks, parallelStep.ID)
-		}
-		if parallelStep.Type == "process" {
-			if err := s.executeProcessTask(ctx, instance, &parallelStep, definition); err != nil {
-				log.Printf("[EngineService] Failed to execute parallel task %s: %v", parallelStep.Name, err)
-				continue
-			}
-			createdTasks = append(createdTasks, parallelStep.ID)
+		if parallelStep.Type != domain_service.StepTypeUserTask && parallelStep.Type != domain_service.StepTypeProcess && parallelStep.Type != domain_service.StepTypeSubProcess {
+			log.Printf("[EngineService] Unsupported parallel task type: %s, skipping", parallelStep.Type)
+			continue
 		}
+		eligible = append(eligible, parallelStep)
 	}
 
-	log.Printf("[EngineService] Created %d parallel tasks", len(createdTasks))
-
-	// 并行任务创建后，流程暂停，等待所有任务完成
-	// 注意：需要在 ContinueAfterTask 中检查是否所有并行任务都已完成
-	return nil
-}
-
-// checkParallelTasksCompleted 检查并行任务是否全部完成
-func (s *WorkflowEngineService) checkParallelTasksCompleted(ctx context.Context, instance *instance_aggregate.WorkflowInstance, stepID string) (bool, error) {
+	if len(eligible) == 0 {
+		log.Printf("[EngineService] No parallel tasks to execute, continuing to next step")
+		return s.executeNextStep(ctx, instance, token, step, definition)
+	}
 
-	tasks, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+	branchIDs := make([]string, 0, len(eligible))
+	for _, parallelStep := range eligible {
+		branchIDs = append(branchIDs, parallelStep.ID)
+	}
+	children, err := s.forkToken(ctx, token, step.ID, branchIDs)
 	if err != nil {
-		return false, fmt.Errorf("查找实例的任务失败: %w", err)
+		return err
 	}
 
-	// 查找属于该并行步骤的所有任务
-	var parallelTasks []*task_aggregate.Task
-	for _, task := range tasks {
-		// 检查任务的 taskKey 是否以 stepID 开头（表示是该并行步骤的子任务）
-		if strings.HasPrefix(task.TaskKey, stepID+"_") {
-			parallelTasks = append(parallelTasks, task)
+	// 创建所有并行任务
+	created, failed := 0, false
+	for i, parallelStep := range eligible {
+		var err error
+		switch parallelStep.Type {
+		case domain_service.StepTypeUserTask:
+			err = s.executeUserTask(ctx, instance, children[i], parallelStep)
+		case domain_service.StepTypeSubProcess:
+			err = s.executeSubProcess(ctx, instance, children[i], parallelStep)
+		default:
+			err = s.executeProcessTask(ctx, instance, children[i], parallelStep, definition)
+		}
+		if err != nil {
+			if instance.Status == status.InstanceStatusFailed {
+				return err
+			}
+			log.Printf("[EngineService] Failed to execute parallel task %s: %v", parallelStep.Name, err)
+			children[i].Cancel()
+			if err := s.tokenRepo.Update(ctx, children[i]); err != nil {
+				return fmt.Errorf("failed to update token: %w", err)
+			}
+			failed = true
+			continue
 		}
+		created++
 	}
 
-	if len(parallelTasks) == 0 {
-		return true, nil // 没有并行任务，认为已完成
-	}
+	log.Printf("[EngineService] Created %d parallel tasks", created)
 
-	// 检查是否所有并行任务都已完成
-	// 注意：rejected 状态的任务也视为"已完成"，因为它已经被回退任务替代
-	for _, task := range parallelTasks {
-		if task.Status != status.TaskStatusCompleted && task.Status != status.TaskStatusRejected {
-			log.Printf("[EngineService] Parallel task %s not completed yet (status: %s)", task.TaskID.String(), task.Status)
-			return false, nil
-		}
+	// 有分支创建失败时，其余分支可能已经全部结束，需要检查一次汇聚
+	if failed {
+		return s.tryJoin(ctx, instance, token, definition)
 	}
-
-	log.Printf("[EngineService] All %d parallel tasks completed", len(parallelTasks))
-	return true, nil
+	return nil
 }
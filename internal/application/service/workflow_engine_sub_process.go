@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// executeSubProcess 执行子流程步骤
+// 创建等待中的子流程任务并启动被引用工作流的子实例，子实例结束后由 resumeParent 继续父实例
+func (s *WorkflowEngineService) executeSubProcess(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, step *StepDefinition) error {
+	config := step.SubProcess
+	if config == nil || config.Workflow == "" {
+		return fmt.Errorf("subProcess step %s has no workflow", step.ID)
+	}
+	log.Printf("[EngineService] Starting sub process %s for step: %s", config.Workflow, step.Name)
+
+	wf, err := s.workflowRepo.FindByName(ctx, config.Workflow)
+	if err != nil {
+		return fmt.Errorf("failed to find sub process workflow %s: %w", config.Workflow, err)
+	}
+	if err := s.checkSubProcessCycle(ctx, instance, wf.WorkflowID); err != nil {
+		return err
+	}
+	version := config.Version
+	if version == 0 {
+		version = wf.CurrentVersion
+	}
+	if version > 0 {
+		if _, err := s.versionRepo.FindByVersion(ctx, wf.WorkflowID, version); err != nil {
+			return fmt.Errorf("failed to find sub process workflow %s version %d: %w", config.Workflow, version, err)
+		}
+	}
+
+	variables, err := s.domainService.MapVariables(ctx, config.Input, instance)
+	if err != nil {
+		return fmt.Errorf("failed to map sub process input for step %s: %w", step.ID, err)
+	}
+	input, err := json.Marshal(variables)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sub process input: %w", err)
+	}
+
+	// 子流程任务不进入任何人的待办，子实例结束前保持等待状态
+	task := task_aggregate.NewTask(instance.InstanceId, instance.WorkflowID)
+	task.TokenID = token.TokenID
+	s.domainService.ApplyStepParamsToTask(task, step, instance)
+	task.Assignee = 0
+	task.Status = status.TaskStatusWaiting
+	child := instance_aggregate.NewSubInstance(instance, task.TaskID, wf.WorkflowID, version, input)
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.moveToken(ctx, token, step.ID); err != nil {
+			return err
+		}
+		if err := s.taskRepo.Save(ctx, task); err != nil {
+			return fmt.Errorf("failed to save task: %w", err)
+		}
+		if err := s.instanceRepo.Save(ctx, child); err != nil {
+			return fmt.Errorf("failed to save sub instance: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[EngineService] Sub instance %s created for task %s, parent instance paused", child.InstanceId.String(), task.TaskID.String())
+
+	return s.StartInstance(ctx, child.InstanceId)
+}
+
+// checkSubProcessCycle 被引用的工作流不能是实例自身或任一祖先实例的工作流
+func (s *WorkflowEngineService) checkSubProcessCycle(ctx context.Context, instance *instance_aggregate.WorkflowInstance, workflowID valueobject.WorkflowID) error {
+	for current := instance; ; {
+		if current.WorkflowID == workflowID {
+			return fmt.Errorf("sub process cycle: workflow %s is already running in instance %s", workflowID.String(), current.InstanceId.String())
+		}
+		if !current.IsSubInstance() {
+			return nil
+		}
+		parent, err := s.instanceRepo.FindByID(ctx, current.ParentInstanceID)
+		if err != nil {
+			return fmt.Errorf("failed to find parent instance: %w", err)
+		}
+		current = parent
+	}
+}
+
+// resumeParent 子实例完成后，将输出映射写入父实例的子流程任务并继续父实例
+func (s *WorkflowEngineService) resumeParent(ctx context.Context, child *instance_aggregate.WorkflowInstance) error {
+	task, parent, step, err := s.parentSubProcessTask(ctx, child)
+	if err != nil || task == nil {
+		return err
+	}
+
+	variables, err := s.domainService.MapVariables(ctx, step.SubProcess.Output, child)
+	if err != nil {
+		return s.failSubProcessTask(ctx, parent, task, fmt.Sprintf("子流程[%s]输出映射失败: %v", step.Name, err))
+	}
+	output, err := json.Marshal(variables)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sub process output: %w", err)
+	}
+
+	task.Status = status.TaskStatusCompleted
+	task.Result = status.TaskResultApproved
+	task.Output = output
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.taskRepo.Update(ctx, task); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		history := task_aggregate.NewTaskHistory(task.TaskID, task.InstanceID, task.TaskName, "0", "sub_process_complete")
+		history.Result = status.TaskResultApproved
+		history.Comment = fmt.Sprintf("子流程实例 %s 已完成", child.InstanceId.String())
+		history.Output = output
+		return s.historyRepo.Save(ctx, history)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[EngineService] Sub instance %s completed, parent instance %s continues", child.InstanceId.String(), parent.InstanceId.String())
+
+	return s.continueFlow(ctx, task)
+}
+
+// failParent 子实例失败时，父实例的子流程任务和父实例一并置为失败
+func (s *WorkflowEngineService) failParent(ctx context.Context, child *instance_aggregate.WorkflowInstance) error {
+	task, parent, step, err := s.parentSubProcessTask(ctx, child)
+	if err != nil || task == nil {
+		return err
+	}
+	return s.failSubProcessTask(ctx, parent, task, fmt.Sprintf("子流程[%s]执行失败: %s", step.Name, child.ErrorMessage))
+}
+
+// failSubProcessTask 子流程任务和父实例置为失败
+func (s *WorkflowEngineService) failSubProcessTask(ctx context.Context, parent *instance_aggregate.WorkflowInstance, task *task_aggregate.Task, reason string) error {
+	task.Status = status.TaskStatusFailed
+	task.Comment = reason
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.taskRepo.Update(ctx, task); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		return s.failInstance(ctx, parent, reason)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("[EngineService] Parent instance %s failed: %s", parent.InstanceId.String(), reason)
+	return nil
+}
+
+// parentSubProcessTask 查找子实例对应的父实例子流程任务
+// 父实例已不在运行或任务已不再等待时返回空任务，子实例的结束不再影响父实例
+func (s *WorkflowEngineService) parentSubProcessTask(ctx context.Context, child *instance_aggregate.WorkflowInstance) (*task_aggregate.Task, *instance_aggregate.WorkflowInstance, *StepDefinition, error) {
+	parent, err := s.instanceRepo.FindByID(ctx, child.ParentInstanceID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to find parent instance: %w", err)
+	}
+	if parent.Status != status.InstanceStatusRunning {
+		log.Printf("[EngineService] Parent instance %s is %s, sub instance %s does not continue it", parent.InstanceId.String(), parent.Status, child.InstanceId.String())
+		return nil, nil, nil, nil
+	}
+	task, err := s.taskRepo.FindByID(ctx, child.ParentTaskID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to find sub process task: %w", err)
+	}
+	if task.Status != status.TaskStatusWaiting {
+		log.Printf("[EngineService] Sub process task %s is %s, sub instance %s does not continue it", task.TaskID.String(), task.Status, child.InstanceId.String())
+		return nil, nil, nil, nil
+	}
+	definition, err := s.loadDefinition(ctx, parent)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	step := s.domainService.FindStepOrParallelTaskByID(task.TaskKey, definition)
+	if step == nil || step.SubProcess == nil {
+		return nil, nil, nil, fmt.Errorf("sub process step not found: %s", task.TaskKey)
+	}
+	return task, parent, step, nil
+}
+
+// cancelChildren 取消实例下仍在运行或挂起的子实例
+func (s *WorkflowEngineService) cancelChildren(ctx context.Context, instance *instance_aggregate.WorkflowInstance, operator int, reason string) error {
+	return s.cancelChildInstances(ctx, instance, func(*instance_aggregate.WorkflowInstance) bool { return true }, operator, reason)
+}
+
+// cancelTaskChildren 父实例的子流程任务被跳转或驳回取消时，取消这些任务启动的子实例
+func (s *WorkflowEngineService) cancelTaskChildren(ctx context.Context, instance *instance_aggregate.WorkflowInstance, tasks []*task_aggregate.Task, operator int, reason string) error {
+	cancelled := make(map[valueobject.TaskID]bool)
+	for _, t := range tasks {
+		if t.TaskType == domain_service.StepTypeSubProcess {
+			cancelled[t.TaskID] = true
+		}
+	}
+	if len(cancelled) == 0 {
+		return nil
+	}
+	return s.cancelChildInstances(ctx, instance, func(child *instance_aggregate.WorkflowInstance) bool {
+		return cancelled[child.ParentTaskID]
+	}, operator, reason)
+}
+
+// cancelChildInstances 取消 match 选中的仍在运行或挂起的子实例
+func (s *WorkflowEngineService) cancelChildInstances(ctx context.Context, instance *instance_aggregate.WorkflowInstance, match func(*instance_aggregate.WorkflowInstance) bool, operator int, reason string) error {
+	children, err := s.instanceRepo.FindChildren(ctx, instance.InstanceId)
+	if err != nil {
+		return fmt.Errorf("failed to find sub instances: %w", err)
+	}
+	for _, child := range children {
+		if child.Status != status.InstanceStatusRunning && child.Status != status.InstanceStatusSuspended {
+			continue
+		}
+		if !match(child) {
+			continue
+		}
+		if err := s.CancelInstance(ctx, child.InstanceId, operator, reason); err != nil {
+			return fmt.Errorf("failed to cancel sub instance %s: %w", child.InstanceId.String(), err)
+		}
+	}
+	return nil
+}
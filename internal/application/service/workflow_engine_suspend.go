@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// SuspendInstance 挂起运行中的实例
+// 挂起期间任务不能处理，定时器暂停，待办任务的处理人收到挂起通知
+func (s *WorkflowEngineService) SuspendInstance(ctx context.Context, instanceID valueobject.InstanceID, reason string) error {
+	instance, err := s.instanceRepo.FindByID(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	if err := instance.Suspend(); err != nil {
+		return fmt.Errorf("%w: instance is %s", err, instance.Status)
+	}
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if s.timerRepo != nil {
+			if err := s.timerRepo.PauseByInstanceID(ctx, instance.InstanceId); err != nil {
+				return fmt.Errorf("failed to pause timers: %w", err)
+			}
+		}
+		if err := s.instanceRepo.Update(ctx, instance); err != nil {
+			return fmt.Errorf("failed to update instance: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pending, err := s.pendingTasks(ctx, instance)
+	if err != nil {
+		return err
+	}
+	if s.notificationSvc != nil {
+		for _, t := range pending {
+			s.notificationSvc.NotifyTaskSuspended(ctx, t, reason)
+		}
+	}
+
+	log.Printf("[EngineService] Instance %s suspended, %d pending tasks affected", instance.InstanceId.String(), len(pending))
+	return nil
+}
+
+// ResumeInstance 恢复挂起的实例，暂停的定时器按挂起时长顺延后继续计时
+func (s *WorkflowEngineService) ResumeInstance(ctx context.Context, instanceID valueobject.InstanceID, reason string) error {
+	instance, err := s.instanceRepo.FindByID(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	suspended, err := instance.Resume()
+	if err != nil {
+		return fmt.Errorf("%w: instance is %s", err, instance.Status)
+	}
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if s.timerRepo != nil {
+			if err := s.timerRepo.ResumeByInstanceID(ctx, instance.InstanceId, suspended); err != nil {
+				return fmt.Errorf("failed to resume timers: %w", err)
+			}
+		}
+		if err := s.instanceRepo.Update(ctx, instance); err != nil {
+			return fmt.Errorf("failed to update instance: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pending, err := s.pendingTasks(ctx, instance)
+	if err != nil {
+		return err
+	}
+	if s.notificationSvc != nil {
+		for _, t := range pending {
+			s.notificationSvc.NotifyTaskResumed(ctx, t, reason)
+		}
+	}
+
+	log.Printf("[EngineService] Instance %s resumed after %s, %d pending tasks affected", instance.InstanceId.String(), suspended, len(pending))
+	return nil
+}
+
+// pendingTasks 实例下待处理的任务
+func (s *WorkflowEngineService) pendingTasks(ctx context.Context, instance *instance_aggregate.WorkflowInstance) ([]*task_aggregate.Task, error) {
+	tasks, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tasks: %w", err)
+	}
+	var pending []*task_aggregate.Task
+	for _, t := range tasks {
+		if t.Status == status.TaskStatusPending {
+			pending = append(pending, t)
+		}
+	}
+	return pending, nil
+}
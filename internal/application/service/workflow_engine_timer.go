@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"jxt-evidence-system/process-management/internal/application/command"
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	timer_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/timer"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// scheduleStepTimeout 为用户任务创建超时定时器
+// 超时策略在创建时写入定时器，触发时不受工作流定义后续修改的影响
+func (s *WorkflowEngineService) scheduleStepTimeout(ctx context.Context, task *task_aggregate.Task, step *StepDefinition) error {
+	if step.Timeout <= 0 || s.timerRepo == nil {
+		return nil
+	}
+
+	policy := domain_service.TimeoutPolicy{Action: domain_service.TimeoutActionNotify}
+	if step.OnTimeout != nil {
+		policy = *step.OnTimeout
+	}
+	payload, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal timeout policy: %w", err)
+	}
+
+	dueAt := time.Now().Add(time.Duration(step.Timeout) * time.Second)
+	timer := timer_aggregate.NewTimer(task.InstanceID, task.WorkflowID, task.TaskID, step.ID, status.TimerTypeTimeout, dueAt, payload)
+	if err := s.timerRepo.Save(ctx, timer); err != nil {
+		return fmt.Errorf("failed to save timer: %w", err)
+	}
+
+	log.Printf("[EngineService] Timeout timer scheduled for task %s at %s (action: %s)", task.TaskID.String(), dueAt.Format("2006-01-02 15:04:05"), policy.Action)
+	return nil
+}
+
+// processRetryPayload 重试定时器参数
+type processRetryPayload struct {
+	Attempt int `json:"attempt"` // 下一次执行的序号
+}
+
+// scheduleProcessRetry 为执行失败的自动化任务创建重试定时器
+func (s *WorkflowEngineService) scheduleProcessRetry(ctx context.Context, task *task_aggregate.Task, step *StepDefinition, attempt int) error {
+	if s.timerRepo == nil {
+		return fmt.Errorf("timer repository not configured, cannot retry step: %s", step.ID)
+	}
+
+	payload, err := json.Marshal(processRetryPayload{Attempt: attempt + 1})
+	if err != nil {
+		return fmt.Errorf("failed to marshal retry payload: %w", err)
+	}
+
+	dueAt := time.Now().Add(step.RetryBackoff.Backoff(attempt))
+	timer := timer_aggregate.NewTimer(task.InstanceID, task.WorkflowID, task.TaskID, step.ID, status.TimerTypeRetry, dueAt, payload)
+	if err := s.timerRepo.Save(ctx, timer); err != nil {
+		return fmt.Errorf("failed to save timer: %w", err)
+	}
+
+	log.Printf("[EngineService] Retry %d/%d scheduled for task %s at %s", attempt, step.Retries, task.TaskID.String(), dueAt.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// HandleTimer 处理到期的定时器
+func (s *WorkflowEngineService) HandleTimer(ctx context.Context, timer *timer_aggregate.Timer) error {
+	log.Printf("[EngineService] Handling timer: %s (type: %s)", timer.TimerID.String(), timer.TimerType)
+
+	switch timer.TimerType {
+	case status.TimerTypeTimeout:
+		return s.handleStepTimeout(ctx, timer)
+	case status.TimerTypeRetry:
+		return s.handleProcessRetry(ctx, timer)
+	default:
+		return fmt.Errorf("unknown timer type: %s", timer.TimerType)
+	}
+}
+
+// handleStepTimeout 处理步骤超时
+func (s *WorkflowEngineService) handleStepTimeout(ctx context.Context, timer *timer_aggregate.Timer) error {
+	task, err := s.taskRepo.FindByID(ctx, timer.TaskID)
+	if err != nil {
+		if errors.Is(err, errors_.ErrTaskNotFound) {
+			log.Printf("[EngineService] Task %s no longer exists, ignoring timeout", timer.TaskID.String())
+			return nil
+		}
+		return fmt.Errorf("failed to find task: %w", err)
+	}
+
+	// 任务已处理（定时器取消前刚好触发），无需超时处理
+	if task.Status != status.TaskStatusPending {
+		log.Printf("[EngineService] Task %s already %s, ignoring timeout", task.TaskID.String(), task.Status)
+		return nil
+	}
+
+	instance, err := s.instanceRepo.FindByID(ctx, task.InstanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance: %w", err)
+	}
+	if instance.Status != status.InstanceStatusRunning {
+		log.Printf("[EngineService] Instance %s is %s, ignoring timeout", instance.InstanceId.String(), instance.Status)
+		return nil
+	}
+
+	var policy domain_service.TimeoutPolicy
+	if len(timer.Payload) > 0 {
+		if err := json.Unmarshal(timer.Payload, &policy); err != nil {
+			return fmt.Errorf("failed to parse timeout policy: %w", err)
+		}
+	}
+	if policy.Comment == "" {
+		policy.Comment = "任务处理超时，系统自动处理"
+	}
+
+	log.Printf("[EngineService] Task %s timed out, action: %s", task.TaskID.String(), policy.Action)
+
+	switch policy.Action {
+	case domain_service.TimeoutActionApprove:
+		if err := s.completeTimedOutTask(ctx, task, status.TaskResultApproved, &policy); err != nil {
+			return err
+		}
+		return s.ContinueAfterTask(ctx, task)
+	case domain_service.TimeoutActionReject:
+		if err := s.completeTimedOutTask(ctx, task, status.TaskResultRejected, &policy); err != nil {
+			return err
+		}
+		return s.RejectAndGoBack(ctx, task)
+	case domain_service.TimeoutActionEscalate:
+		return s.escalateTimedOutTask(ctx, instance, task, &policy)
+	case domain_service.TimeoutActionJump:
+		return s.jumpAfterTimeout(ctx, instance, task, &policy)
+	case domain_service.TimeoutActionNotify, "":
+		if err := s.saveTimeoutHistory(ctx, task, "timeout", "", &policy); err != nil {
+			return err
+		}
+		if s.notificationSvc != nil {
+			s.notificationSvc.NotifyTaskAssigned(ctx, task, task.Assignee)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown timeout action: %s", policy.Action)
+	}
+}
+
+// handleProcessRetry 重新执行失败的自动化任务
+func (s *WorkflowEngineService) handleProcessRetry(ctx context.Context, timer *timer_aggregate.Timer) error {
+	task, err := s.taskRepo.FindByID(ctx, timer.TaskID)
+	if err != nil {
+		if errors.Is(err, errors_.ErrTaskNotFound) {
+			log.Printf("[EngineService] Task %s no longer exists, ignoring retry", timer.TaskID.String())
+			return nil
+		}
+		return fmt.Errorf("failed to find task: %w", err)
+	}
+	if task.Status != status.TaskStatusPending {
+		log.Printf("[EngineService] Task %s already %s, ignoring retry", task.TaskID.String(), task.Status)
+		return nil
+	}
+
+	instance, err := s.instanceRepo.FindByID(ctx, task.InstanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance: %w", err)
+	}
+	if instance.Status != status.InstanceStatusRunning {
+		log.Printf("[EngineService] Instance %s is %s, ignoring retry", instance.InstanceId.String(), instance.Status)
+		return nil
+	}
+
+	var payload processRetryPayload
+	if err := json.Unmarshal(timer.Payload, &payload); err != nil {
+		return fmt.Errorf("failed to parse retry payload: %w", err)
+	}
+
+	definition, err := s.loadDefinition(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	step := s.domainService.FindStepOrParallelTaskByID(task.TaskKey, definition)
+	if step == nil {
+		return fmt.Errorf("step definition not found for task key: %s", task.TaskKey)
+	}
+
+	log.Printf("[EngineService] Retrying process task %s, attempt %d", task.TaskID.String(), payload.Attempt)
+
+	return s.attemptProcessTask(ctx, instance, task, step, definition, payload.Attempt)
+}
+
+// completeTimedOutTask 以指定结果自动完成超时任务
+func (s *WorkflowEngineService) completeTimedOutTask(ctx context.Context, task *task_aggregate.Task, result status.TaskResult, policy *domain_service.TimeoutPolicy) error {
+	if err := task.Complete(&command.CompleteTaskCommand{Result: result, Comment: policy.Comment}); err != nil {
+		return err
+	}
+	if err := s.taskRepo.Update(ctx, task); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	return s.saveTimeoutHistory(ctx, task, "timeout", result, policy)
+}
+
+// escalateTimedOutTask 将超时任务升级给新的处理人
+func (s *WorkflowEngineService) escalateTimedOutTask(ctx context.Context, instance *instance_aggregate.WorkflowInstance, task *task_aggregate.Task, policy *domain_service.TimeoutPolicy) error {
+	assignee, ok := s.domainService.ResolveAssignee(policy.Assignee, instance)
+	if !ok {
+		return fmt.Errorf("invalid escalate assignee: %s", policy.Assignee)
+	}
+
+	// 记录升级前的处理人
+	if err := s.saveTimeoutHistory(ctx, task, "escalate", "", policy); err != nil {
+		return err
+	}
+
+	task.Assignee = assignee
+	if err := s.taskRepo.Update(ctx, task); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	log.Printf("[EngineService] Task %s escalated to %d", task.TaskID.String(), assignee)
+
+	if s.notificationSvc != nil {
+		s.notificationSvc.NotifyTaskAssigned(ctx, task, assignee)
+	}
+	return nil
+}
+
+// jumpAfterTimeout 结束超时任务并跳转到指定步骤
+// 与管理员跳转一致，同时取消实例其他未处理的任务、定时器和子流程实例并结束分支令牌
+func (s *WorkflowEngineService) jumpAfterTimeout(ctx context.Context, instance *instance_aggregate.WorkflowInstance, task *task_aggregate.Task, policy *domain_service.TimeoutPolicy) error {
+	definition, err := s.loadDefinition(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	targetStep := s.domainService.FindStepByID(policy.StepID, definition)
+	if targetStep == nil {
+		return fmt.Errorf("timeout target step not found: %s", policy.StepID)
+	}
+
+	tasks, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks: %w", err)
+	}
+	tokens, err := s.tokenRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return fmt.Errorf("failed to find tokens: %w", err)
+	}
+	var open []*task_aggregate.Task
+	for _, t := range tasks {
+		if t.IsOpen() && t.TaskID != task.TaskID {
+			open = append(open, t)
+		}
+	}
+
+	var root *token_aggregate.ExecutionToken
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.completeTimedOutTask(ctx, task, status.TaskResultCompleted, policy); err != nil {
+			return err
+		}
+		token, err := s.resetToStep(ctx, instance, open, tokens, targetStep.ID, "步骤超时跳转，任务自动取消")
+		root = token
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.cancelTaskChildren(ctx, instance, open, 0, "步骤超时跳转，子流程自动取消"); err != nil {
+		return err
+	}
+
+	log.Printf("[EngineService] Task %s timed out, jumping to step: %s, %d other tasks cancelled", task.TaskID.String(), targetStep.Name, len(open))
+
+	return s.executeStep(ctx, instance, root, targetStep, definition)
+}
+
+// saveTimeoutHistory 记录超时处理历史
+func (s *WorkflowEngineService) saveTimeoutHistory(ctx context.Context, task *task_aggregate.Task, action string, result status.TaskResult, policy *domain_service.TimeoutPolicy) error {
+	if s.historyRepo == nil {
+		return nil
+	}
+	history := task_aggregate.NewTaskHistory(task.TaskID, task.InstanceID, task.TaskName, fmt.Sprintf("%d", task.Assignee), action)
+	history.Result = result
+	history.Comment = policy.Comment
+	if err := s.historyRepo.Save(ctx, history); err != nil {
+		return fmt.Errorf("failed to save task history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// taskToken 获取执行任务的令牌
+// 引入令牌之前创建的任务没有令牌，为其补建停在任务步骤上的根令牌
+func (s *WorkflowEngineService) taskToken(ctx context.Context, instance *instance_aggregate.WorkflowInstance, task *task_aggregate.Task) (*token_aggregate.ExecutionToken, error) {
+	if !task.TokenID.IsEmpty() {
+		token, err := s.tokenRepo.FindByID(ctx, task.TokenID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find token: %w", err)
+		}
+		return token, nil
+	}
+
+	token := token_aggregate.NewRootToken(instance.InstanceId, task.TaskKey)
+	task.TokenID = token.TokenID
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.tokenRepo.Save(ctx, token); err != nil {
+			return fmt.Errorf("failed to save token: %w", err)
+		}
+		if err := s.taskRepo.Update(ctx, task); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[EngineService] Created root token %s for legacy task %s", token.TokenID.String(), task.TaskID.String())
+	return token, nil
+}
+
+// moveToken 令牌流转到指定步骤
+func (s *WorkflowEngineService) moveToken(ctx context.Context, token *token_aggregate.ExecutionToken, stepID string) error {
+	if token.StepID == stepID && token.Status == status.TokenStatusActive {
+		return nil
+	}
+	token.MoveTo(stepID)
+	if err := s.tokenRepo.Update(ctx, token); err != nil {
+		return fmt.Errorf("failed to update token: %w", err)
+	}
+	return nil
+}
+
+// forkToken 在分支步骤处为每个分支创建子令牌
+// 子令牌全部保存后才开始执行分支，避免先执行完的分支误判其他分支已结束
+func (s *WorkflowEngineService) forkToken(ctx context.Context, token *token_aggregate.ExecutionToken, forkStepID string, branchStepIDs []string) ([]*token_aggregate.ExecutionToken, error) {
+	children := token.Fork(forkStepID, branchStepIDs)
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.tokenRepo.Update(ctx, token); err != nil {
+			return fmt.Errorf("failed to update token: %w", err)
+		}
+		for _, child := range children {
+			if err := s.tokenRepo.Save(ctx, child); err != nil {
+				return fmt.Errorf("failed to save token: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[EngineService] Token %s forked at %s into %d branches", token.TokenID.String(), forkStepID, len(children))
+	return children, nil
+}
+
+// advance 步骤执行完毕后令牌继续流转
+// 并行步骤中的任务执行完即结束该分支，其他步骤按流转规则执行下一步
+func (s *WorkflowEngineService) advance(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, step *StepDefinition, definition *WorkflowDefinitionStruct) error {
+	if token.ForkStepID != "" && s.domainService.FindStepByID(step.ID, definition) == nil {
+		return s.finishToken(ctx, instance, token, step, definition)
+	}
+	return s.executeNextStep(ctx, instance, token, step, definition)
+}
+
+// finishToken 令牌没有后续步骤
+// 根令牌结束时完成实例，分支令牌结束时检查父令牌能否汇聚
+func (s *WorkflowEngineService) finishToken(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, lastStep *StepDefinition, definition *WorkflowDefinitionStruct) error {
+	if token.IsRoot() {
+		return s.completeInstance(ctx, instance, token, lastStep, definition)
+	}
+
+	token.Complete()
+	if err := s.tokenRepo.Update(ctx, token); err != nil {
+		return fmt.Errorf("failed to update token: %w", err)
+	}
+	log.Printf("[EngineService] Branch token %s finished at step %s", token.TokenID.String(), token.StepID)
+
+	parent, err := s.tokenRepo.FindByID(ctx, token.ParentID)
+	if err != nil {
+		return fmt.Errorf("failed to find parent token: %w", err)
+	}
+	return s.tryJoin(ctx, instance, parent, definition)
+}
+
+// executeGateway 执行网关步骤
+// 分支令牌到达汇聚网关时等待其他分支，否则按网关类型选择分支继续执行
+func (s *WorkflowEngineService) executeGateway(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, step *StepDefinition, definition *WorkflowDefinitionStruct) error {
+	if !token.IsRoot() && s.domainService.IsJoinGateway(step, definition) {
+		token.WaitAt(step.ID)
+		if err := s.tokenRepo.Update(ctx, token); err != nil {
+			return fmt.Errorf("failed to update token: %w", err)
+		}
+		log.Printf("[EngineService] Token %s waiting at join %s", token.TokenID.String(), step.ID)
+
+		parent, err := s.tokenRepo.FindByID(ctx, token.ParentID)
+		if err != nil {
+			return fmt.Errorf("failed to find parent token: %w", err)
+		}
+		return s.tryJoin(ctx, instance, parent, definition)
+	}
+	return s.leaveGateway(ctx, instance, token, step, definition)
+}
+
+// leaveGateway 按网关类型选择分支
+// 只有一条流出边时令牌直接流转；有多条流出边时即使只选中一个分支也创建子令牌，由后续汇聚网关合并
+func (s *WorkflowEngineService) leaveGateway(ctx context.Context, instance *instance_aggregate.WorkflowInstance, token *token_aggregate.ExecutionToken, step *StepDefinition, definition *WorkflowDefinitionStruct) error {
+	branches, err := s.domainService.SelectBranches(ctx, step, definition, instance)
+	if err != nil {
+		if failErr := s.failInstance(ctx, instance, fmt.Sprintf("网关[%s]没有可执行的分支", step.Name)); failErr != nil {
+			return failErr
+		}
+		return err
+	}
+
+	if step.Type == domain_service.StepTypeExclusiveGateway || len(step.NextSteps) == 1 {
+		return s.executeStep(ctx, instance, token, branches[0], definition)
+	}
+
+	branchIDs := make([]string, 0, len(branches))
+	for _, branch := range branches {
+		branchIDs = append(branchIDs, branch.ID)
+	}
+	children, err := s.forkToken(ctx, token, step.ID, branchIDs)
+	if err != nil {
+		return err
+	}
+	for i, child := range children {
+		if err := s.executeStep(ctx, instance, child, branches[i], definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tryJoin 检查父令牌的子令牌能否汇聚
+// 所有未结束的子令牌都停在同一个汇聚网关时合并为父令牌，父令牌从该网关继续；
+// 子令牌全部结束时，并行步骤的父令牌执行下一步，网关的父令牌随之结束。
+// 父令牌在事务中加锁后重新读取，最后两个分支同时到达时只有一个能完成汇聚
+func (s *WorkflowEngineService) tryJoin(ctx context.Context, instance *instance_aggregate.WorkflowInstance, parent *token_aggregate.ExecutionToken, definition *WorkflowDefinitionStruct) error {
+	if parent.Status != status.TokenStatusForked {
+		return nil
+	}
+
+	var forkStep, joinStep *StepDefinition
+	var joined []*token_aggregate.ExecutionToken
+	resumed := false
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		locked, err := s.tokenRepo.FindByIDForUpdate(ctx, parent.TokenID)
+		if err != nil {
+			return fmt.Errorf("failed to lock parent token: %w", err)
+		}
+		if locked.Status != status.TokenStatusForked {
+			return nil // 其他分支已完成汇聚
+		}
+
+		children, err := s.tokenRepo.FindChildren(ctx, locked.TokenID)
+		if err != nil {
+			return fmt.Errorf("failed to find child tokens: %w", err)
+		}
+
+		var waiting []*token_aggregate.ExecutionToken
+		joinStepID := ""
+		for _, child := range children {
+			if !child.IsLive() {
+				continue
+			}
+			if child.Status != status.TokenStatusWaiting {
+				return nil // 还有分支在执行
+			}
+			if joinStepID != "" && child.StepID != joinStepID {
+				log.Printf("[EngineService] Branches of token %s are waiting at different joins: %s, %s", locked.TokenID.String(), joinStepID, child.StepID)
+				return nil
+			}
+			joinStepID = child.StepID
+			waiting = append(waiting, child)
+		}
+
+		forkStep = s.domainService.FindStepByID(locked.StepID, definition)
+		if forkStep == nil {
+			return fmt.Errorf("fork step not found: %s", locked.StepID)
+		}
+
+		if len(waiting) == 0 {
+			// 父令牌回到分支步骤，随后执行下一步或结束
+			if err := s.moveToken(ctx, locked, forkStep.ID); err != nil {
+				return err
+			}
+		} else {
+			joinStep = s.domainService.FindStepByID(joinStepID, definition)
+			if joinStep == nil {
+				return fmt.Errorf("join step not found: %s", joinStepID)
+			}
+			// 汇聚的子令牌结束，父令牌在同一事务中恢复
+			for _, child := range waiting {
+				child.Complete()
+				if err := s.tokenRepo.Update(ctx, child); err != nil {
+					return fmt.Errorf("failed to update token: %w", err)
+				}
+			}
+			if err := s.moveToken(ctx, locked, joinStep.ID); err != nil {
+				return err
+			}
+		}
+		*parent = *locked
+		joined = waiting
+		resumed = true
+		return nil
+	})
+	if err != nil || !resumed {
+		return err
+	}
+
+	if joinStep == nil {
+		log.Printf("[EngineService] All branches of token %s finished", parent.TokenID.String())
+		if forkStep.Type == domain_service.StepTypeParallel {
+			return s.executeNextStep(ctx, instance, parent, forkStep, definition)
+		}
+		return s.finishToken(ctx, instance, parent, forkStep, definition)
+	}
+
+	log.Printf("[EngineService] %d branches joined at %s, token %s continues", len(joined), joinStep.ID, parent.TokenID.String())
+
+	return s.leaveGateway(ctx, instance, parent, joinStep, definition)
+}
+
+// failInstance 实例置为失败，取消定时器并结束所有令牌，运行中的子实例一并取消，子实例失败时父实例一并失败
+func (s *WorkflowEngineService) failInstance(ctx context.Context, instance *instance_aggregate.WorkflowInstance, reason string) error {
+	if err := instance.Fail(reason); err != nil {
+		return err
+	}
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.instanceRepo.Update(ctx, instance); err != nil {
+			return fmt.Errorf("failed to update instance: %w", err)
+		}
+		if s.timerRepo != nil {
+			if err := s.timerRepo.CancelByInstanceID(ctx, instance.InstanceId); err != nil {
+				return fmt.Errorf("failed to cancel timers: %w", err)
+			}
+		}
+		if err := s.tokenRepo.CloseByInstanceID(ctx, instance.InstanceId, status.TokenStatusCancelled); err != nil {
+			return fmt.Errorf("failed to close tokens: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// 子流程等待中的子实例一并取消
+	if err := s.cancelChildren(ctx, instance, 0, "父流程已失败，子流程自动取消"); err != nil {
+		return err
+	}
+	if instance.IsSubInstance() {
+		return s.failParent(ctx, instance)
+	}
+	return nil
+}
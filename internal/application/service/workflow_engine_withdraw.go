@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// WithdrawTask 撤回已处理的任务
+// 只有任务处理后产生或激活的后续任务都未被处理时才能撤回：后续任务取消（已激活的转回等待），
+// 因会签结束被取消的同组任务恢复，任务重新待处理，令牌回到任务所在步骤
+func (s *WorkflowEngineService) WithdrawTask(ctx context.Context, task *task_aggregate.Task) error {
+	if task.CompletedAt == nil || (task.Status != status.TaskStatusCompleted && task.Status != status.TaskStatusRejected) {
+		return fmt.Errorf("%w: task %s is %s", errors_.ErrCannotWithdraw, task.TaskID.String(), task.Status)
+	}
+	// 加签人的驳回已改写原任务的结果，不能撤回
+	if !task.SourceTaskID.IsEmpty() && task.Status == status.TaskStatusRejected {
+		return fmt.Errorf("%w: signer rejection cannot be withdrawn", errors_.ErrCannotWithdraw)
+	}
+	completedAt := *task.CompletedAt
+
+	instance, err := s.instanceRepo.FindByID(ctx, task.InstanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance: %w", err)
+	}
+	if instance.Status != status.InstanceStatusRunning {
+		return fmt.Errorf("%w: instance is %s", errors_.ErrCannotWithdraw, instance.Status)
+	}
+	definition, err := s.loadDefinition(ctx, instance)
+	if err != nil {
+		return err
+	}
+	step := s.domainService.FindStepOrParallelTaskByID(task.TaskKey, definition)
+	if step == nil {
+		return fmt.Errorf("step definition not found for task key: %s", task.TaskKey)
+	}
+	sequential := false
+	if config, err := domain_service.ParseMultiInstance(step); err == nil && config != nil {
+		sequential = config.Mode == domain_service.MultiInstanceSequential
+	}
+
+	token, err := s.taskToken(ctx, instance, task)
+	if err != nil {
+		return err
+	}
+	tokens, err := s.tokenRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return fmt.Errorf("failed to find tokens: %w", err)
+	}
+	// 任务处理后由该令牌分出的子令牌
+	forked := make(map[valueobject.TokenID]*token_aggregate.ExecutionToken)
+	for _, t := range tokens {
+		if !t.CreatedAt.Before(completedAt) && descendsFrom(t, token.TokenID, tokens) {
+			forked[t.TokenID] = t
+		}
+	}
+
+	tasks, err := s.taskRepo.FindByInstanceID(ctx, instance.InstanceId)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks: %w", err)
+	}
+	var cancel, deactivate, reopen []*task_aggregate.Task
+	for _, t := range tasks {
+		if t.TaskID.Equals(task.TaskID) {
+			continue
+		}
+		switch {
+		case !t.CreatedAt.Before(completedAt):
+			// 任务处理后创建的后续任务
+			if !t.TokenID.Equals(token.TokenID) && forked[t.TokenID] == nil {
+				return fmt.Errorf("%w: task %s belongs to another branch", errors_.ErrCannotWithdraw, t.TaskID.String())
+			}
+			cancel = append(cancel, t)
+		case t.SourceTaskID.Equals(task.TaskID) && t.SignPosition == task_aggregate.SignPositionAfter && t.Status == status.TaskStatusPending:
+			// 任务通过后激活的后加签任务
+			deactivate = append(deactivate, t)
+		case t.Status == status.TaskStatusCancelled && t.CompletedAt != nil && !t.CompletedAt.Before(completedAt):
+			// 任务处理后被取消的任务只能是因会签结束取消的同组任务
+			if !t.SourceTaskID.IsEmpty() || task.GroupID.IsEmpty() || !t.GroupID.Equals(task.GroupID) {
+				return fmt.Errorf("%w: task %s was cancelled afterwards", errors_.ErrCannotWithdraw, t.TaskID.String())
+			}
+			reopen = append(reopen, t)
+		case sequential && t.GroupID.Equals(task.GroupID) && !task.GroupID.IsEmpty() && t.SourceTaskID.IsEmpty() &&
+			t.Sequence > task.Sequence && t.Status == status.TaskStatusPending:
+			// 顺序会签中任务处理后激活的下一个处理人
+			deactivate = append(deactivate, t)
+		}
+	}
+	for _, t := range append(append([]*task_aggregate.Task{}, cancel...), deactivate...) {
+		if err := s.checkUntouched(ctx, t); err != nil {
+			return err
+		}
+	}
+
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.cancelOpenTasks(ctx, cancel, "上一步已撤回，任务自动取消"); err != nil {
+			return err
+		}
+		for _, t := range deactivate {
+			if err := t.Deactivate(); err != nil {
+				return err
+			}
+			if err := s.taskRepo.Update(ctx, t); err != nil {
+				return fmt.Errorf("failed to update task: %w", err)
+			}
+			if s.timerRepo != nil {
+				if err := s.timerRepo.CancelByTaskID(ctx, t.TaskID); err != nil {
+					return fmt.Errorf("failed to cancel timers: %w", err)
+				}
+			}
+		}
+		for _, t := range reopen {
+			if err := t.Reopen(sequential && t.Sequence > task.Sequence); err != nil {
+				return err
+			}
+			if err := s.taskRepo.Update(ctx, t); err != nil {
+				return fmt.Errorf("failed to update task: %w", err)
+			}
+			if t.Status == status.TaskStatusPending {
+				if err := s.scheduleStepTimeout(ctx, t, step); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := task.Reopen(false); err != nil {
+			return err
+		}
+		if err := s.taskRepo.Update(ctx, task); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		if err := s.scheduleStepTimeout(ctx, task, step); err != nil {
+			return err
+		}
+
+		for _, t := range forked {
+			if !t.IsLive() {
+				continue
+			}
+			t.Cancel()
+			if err := s.tokenRepo.Update(ctx, t); err != nil {
+				return fmt.Errorf("failed to update token: %w", err)
+			}
+		}
+		token.MoveTo(task.TaskKey)
+		if err := s.tokenRepo.Update(ctx, token); err != nil {
+			return fmt.Errorf("failed to update token: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// 被取消的后续子流程任务启动的子实例一并取消
+	if err := s.cancelTaskChildren(ctx, instance, cancel, task.Assignee, "上一步已撤回，子流程自动取消"); err != nil {
+		return err
+	}
+
+	log.Printf("[EngineService] Task %s withdrawn, %d downstream tasks cancelled, %d deactivated", task.TaskID.String(), len(cancel), len(deactivate))
+
+	if s.notificationSvc != nil {
+		for _, t := range append(cancel, deactivate...) {
+			s.notificationSvc.NotifyTaskWithdrawn(ctx, t)
+		}
+	}
+	return nil
+}
+
+// checkUntouched 检查后续任务未被处理：仍待处理、未被认领且没有用户的操作记录，引擎自动产生的记录（如自动委托）不算
+func (s *WorkflowEngineService) checkUntouched(ctx context.Context, task *task_aggregate.Task) error {
+	if !task.IsOpen() || task.ClaimedAt != nil {
+		return fmt.Errorf("%w: downstream task %s is already %s", errors_.ErrCannotWithdraw, task.TaskID.String(), task.Status)
+	}
+	histories, err := s.historyRepo.FindByTaskID(ctx, task.TaskID)
+	if err != nil {
+		return fmt.Errorf("failed to find task history: %w", err)
+	}
+	for _, history := range histories {
+		if !history.IsSystemAction() {
+			return fmt.Errorf("%w: downstream task %s has been acted on", errors_.ErrCannotWithdraw, task.TaskID.String())
+		}
+	}
+	return nil
+}
+
+// descendsFrom 判断令牌是否由指定令牌分出
+func descendsFrom(token *token_aggregate.ExecutionToken, ancestorID valueobject.TokenID, tokens []*token_aggregate.ExecutionToken) bool {
+	byID := make(map[valueobject.TokenID]*token_aggregate.ExecutionToken, len(tokens))
+	for _, t := range tokens {
+		byID[t.TokenID] = t
+	}
+	for current := token; current != nil && !current.ParentID.IsEmpty(); current = byID[current.ParentID] {
+		if current.ParentID.Equals(ancestorID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"jxt-evidence-system/process-management/internal/application/command"
+	workflow_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/workflow"
+	workflow_repository "jxt-evidence-system/process-management/internal/domain/aggregate/workflow/repository"
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+)
+
+type txContextMarker struct{}
+
+// recordingTxManager 在 ctx 中标记事务，并记录事务是否以错误结束
+type recordingTxManager struct {
+	calls int
+	err   error
+}
+
+func (m *recordingTxManager) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.calls++
+	m.err = fn(context.WithValue(ctx, txContextMarker{}, true))
+	return m.err
+}
+
+func inTx(ctx context.Context) bool {
+	marked, _ := ctx.Value(txContextMarker{}).(bool)
+	return marked
+}
+
+// fakeWorkflowRepo 只实现激活流程用到的方法
+type fakeWorkflowRepo struct {
+	workflow_repository.WorkflowRepository
+	wf        *workflow_aggregate.Workflow
+	updateErr error
+	updatedTx []bool
+}
+
+func (r *fakeWorkflowRepo) FindByID(ctx context.Context, id valueobject.WorkflowID) (*workflow_aggregate.Workflow, error) {
+	return r.wf, nil
+}
+
+func (r *fakeWorkflowRepo) Update(ctx context.Context, wf *workflow_aggregate.Workflow) error {
+	r.updatedTx = append(r.updatedTx, inTx(ctx))
+	return r.updateErr
+}
+
+type fakeWorkflowVersionRepo struct {
+	workflow_repository.WorkflowVersionRepository
+	latest  *workflow_aggregate.WorkflowVersion
+	savedTx []bool
+	saved   []*workflow_aggregate.WorkflowVersion
+}
+
+func (r *fakeWorkflowVersionRepo) FindLatest(ctx context.Context, workflowID valueobject.WorkflowID) (*workflow_aggregate.WorkflowVersion, error) {
+	if r.latest == nil {
+		return nil, errors_.ErrWorkflowVersionNotFound
+	}
+	return r.latest, nil
+}
+
+func (r *fakeWorkflowVersionRepo) Save(ctx context.Context, version *workflow_aggregate.WorkflowVersion) error {
+	r.savedTx = append(r.savedTx, inTx(ctx))
+	r.saved = append(r.saved, version)
+	return nil
+}
+
+const activateTestDefinition = `{"steps":[` +
+	`{"id":"review","name":"审核","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},` +
+	`{"id":"end","type":"complete"}]}`
+
+func newActivateTestService(updateErr error) (*workflowService, *fakeWorkflowRepo, *fakeWorkflowVersionRepo, *recordingTxManager) {
+	repo := &fakeWorkflowRepo{
+		wf:        workflow_aggregate.NewWorkflow("请假", "", activateTestDefinition, 1),
+		updateErr: updateErr,
+	}
+	versionRepo := &fakeWorkflowVersionRepo{}
+	txManager := &recordingTxManager{}
+	return &workflowService{
+		repo:          repo,
+		versionRepo:   versionRepo,
+		domainService: domain_service.NewWorkflowDomainService(nil),
+		txManager:     txManager,
+	}, repo, versionRepo, txManager
+}
+
+func TestActivateWorkflowPublishesVersionInTransaction(t *testing.T) {
+	svc, repo, versionRepo, txManager := newActivateTestService(nil)
+
+	if err := svc.ActivateWorkflow(context.Background(), &command.ActivateWorkflowCommand{ID: repo.wf.WorkflowID}); err != nil {
+		t.Fatalf("ActivateWorkflow() error = %v", err)
+	}
+	if txManager.calls != 1 {
+		t.Fatalf("Transaction called %d times, want 1", txManager.calls)
+	}
+	if len(versionRepo.saved) != 1 || versionRepo.saved[0].Version != 1 || repo.wf.CurrentVersion != 1 {
+		t.Fatalf("published versions = %v, current version = %d", versionRepo.saved, repo.wf.CurrentVersion)
+	}
+	if len(versionRepo.savedTx) != 1 || !versionRepo.savedTx[0] {
+		t.Errorf("version saved outside the transaction")
+	}
+	if len(repo.updatedTx) != 1 || !repo.updatedTx[0] {
+		t.Errorf("workflow updated outside the transaction")
+	}
+}
+
+func TestActivateWorkflowReturnsUpdateErrorFromTransaction(t *testing.T) {
+	updateErr := errors.New("update failed")
+	svc, repo, versionRepo, txManager := newActivateTestService(updateErr)
+
+	err := svc.ActivateWorkflow(context.Background(), &command.ActivateWorkflowCommand{ID: repo.wf.WorkflowID})
+	if !errors.Is(err, updateErr) {
+		t.Fatalf("ActivateWorkflow() error = %v, want %v", err, updateErr)
+	}
+	// 版本记录与工作流更新在同一个失败的事务中，由事务管理器回滚
+	if !errors.Is(txManager.err, updateErr) || len(versionRepo.savedTx) != 1 || !versionRepo.savedTx[0] {
+		t.Errorf("version save was not part of the failed transaction: tx err = %v, saved in tx = %v", txManager.err, versionRepo.savedTx)
+	}
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	delegation "jxt-evidence-system/process-management/internal/domain/aggregate/delegation"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+)
+
+// DelegationRepository 委托规则仓储接口
+type DelegationRepository interface {
+	Save(ctx context.Context, rule *delegation.DelegationRule) error
+	FindByID(ctx context.Context, id valueobject.DelegationID) (*delegation.DelegationRule, error)
+	// FindByUserID 查找用户设置的全部委托规则，按生效时间倒序
+	FindByUserID(ctx context.Context, userID int) ([]*delegation.DelegationRule, error)
+	// FindActive 查找用户在指定时间生效的委托规则，按创建时间倒序
+	FindActive(ctx context.Context, userID int, at time.Time) ([]*delegation.DelegationRule, error)
+	Update(ctx context.Context, rule *delegation.DelegationRule) error
+	Delete(ctx context.Context, id valueobject.DelegationID) error
+}
@@ -13,34 +13,41 @@ import (
 
 // WorkflowInstance 工作流实例
 type WorkflowInstance struct {
-	InstanceId   valueobject.InstanceID      `json:"instanceId" gorm:"primaryKey;column:id;type:uuid;comment:主键编码"`
-	WorkflowID   valueobject.WorkflowID      `json:"workflowId" gorm:"column:workflow_id;type:uuid;comment:工作流编码"`
-	InstanceNo   string                      `json:"instanceNo"`
-	WorkflowNo   string                      `json:"workflowNo" gorm:"-"`
-	WorkflowName string                      `json:"workflowName" gorm:"-"`
-	Status       status.InstanceStatus       `json:"status"`
-	Input        json.RawMessage             `gorm:"type:jsonb" json:"input"`
-	Output       json.RawMessage             `gorm:"type:jsonb" json:"output"`
-	ErrorMessage string                      `json:"errorMessage"`
-	StartedAt    time.Time                   `json:"startedAt"`
-	CompletedAt  *time.Time                  `json:"completedAt"`
-	Workflow     workflow_aggregate.Workflow `json:"-" gorm:"foreignKey:workflow_id;references:id"`
+	InstanceId       valueobject.InstanceID      `json:"instanceId" gorm:"primaryKey;column:id;type:uuid;comment:主键编码"`
+	WorkflowID       valueobject.WorkflowID      `json:"workflowId" gorm:"column:workflow_id;type:uuid;comment:工作流编码"`
+	WorkflowVersion  int                         `json:"workflowVersion" gorm:"comment:启动时的工作流版本号"` // 实例始终按该版本的定义执行
+	InstanceNo       string                      `json:"instanceNo"`
+	WorkflowNo       string                      `json:"workflowNo" gorm:"-"`
+	WorkflowName     string                      `json:"workflowName" gorm:"-"`
+	Status           status.InstanceStatus       `json:"status"`
+	Input            json.RawMessage             `gorm:"type:jsonb" json:"input"`
+	Output           json.RawMessage             `gorm:"type:jsonb" json:"output"`
+	ErrorMessage     string                      `json:"errorMessage"`
+	StartedAt        time.Time                   `json:"startedAt"`
+	CompletedAt      *time.Time                  `json:"completedAt"`
+	SuspendedAt      *time.Time                  `json:"suspendedAt"`
+	ParentInstanceID valueobject.InstanceID      `json:"parentInstanceId" gorm:"column:parent_instance_id;type:uuid;index;comment:父实例编码"`
+	RootInstanceID   valueobject.InstanceID      `json:"rootInstanceId" gorm:"column:root_instance_id;type:uuid;index;comment:根实例编码"`
+	ParentTaskID     valueobject.TaskID          `json:"parentTaskId" gorm:"column:parent_task_id;type:uuid;comment:父实例子流程任务编码"` // 父实例中等待子流程结束的任务
+	InitiatorID      int                         `json:"initiatorId" gorm:"column:initiator_id;index;comment:发起人编码"`             // 启动实例的用户，子实例继承父实例的发起人
+	Workflow         workflow_aggregate.Workflow `json:"-" gorm:"foreignKey:workflow_id;references:id"`
 
 	// 审计字段
 	models.ControlBy
 	models.ModelTime
 }
 
-// NewWorkflowInstance 创建新工作流实例
-func NewWorkflowInstance(workflowID valueobject.WorkflowID, input json.RawMessage) *WorkflowInstance {
+// NewWorkflowInstance 创建新工作流实例，version 为实例绑定的工作流版本号
+func NewWorkflowInstance(workflowID valueobject.WorkflowID, version int, input json.RawMessage) *WorkflowInstance {
 	now := time.Now()
 	return &WorkflowInstance{
-		InstanceId: valueobject.NewInstanceID(),
-		InstanceNo: "instance-" + now.Format("20060102150405"),
-		WorkflowID: workflowID,
-		Status:     status.InstanceStatusRunning,
-		Input:      input,
-		StartedAt:  now,
+		InstanceId:      valueobject.NewInstanceID(),
+		InstanceNo:      "instance-" + now.Format("20060102150405"),
+		WorkflowID:      workflowID,
+		WorkflowVersion: version,
+		Status:          status.InstanceStatusRunning,
+		Input:           input,
+		StartedAt:       now,
 		ModelTime: models.ModelTime{
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
@@ -48,6 +55,24 @@ func NewWorkflowInstance(workflowID valueobject.WorkflowID, input json.RawMessag
 	}
 }
 
+// NewSubInstance 创建由父实例子流程步骤启动的子实例
+func NewSubInstance(parent *WorkflowInstance, parentTaskID valueobject.TaskID, workflowID valueobject.WorkflowID, version int, input json.RawMessage) *WorkflowInstance {
+	child := NewWorkflowInstance(workflowID, version, input)
+	child.ParentInstanceID = parent.InstanceId
+	child.RootInstanceID = parent.RootInstanceID
+	if child.RootInstanceID.IsEmpty() {
+		child.RootInstanceID = parent.InstanceId
+	}
+	child.ParentTaskID = parentTaskID
+	child.InitiatorID = parent.InitiatorID
+	return child
+}
+
+// IsSubInstance 是否为子流程实例
+func (wi *WorkflowInstance) IsSubInstance() bool {
+	return !wi.ParentInstanceID.IsEmpty()
+}
+
 // Complete 完成实例
 func (wi *WorkflowInstance) Complete(output string) error {
 	if wi.Status != status.InstanceStatusRunning {
@@ -74,13 +99,54 @@ func (wi *WorkflowInstance) Fail(errorMsg string) error {
 	return nil
 }
 
-// Cancel 取消实例
-func (wi *WorkflowInstance) Cancel() error {
+// Recover 失败的实例经人工干预后恢复运行
+func (wi *WorkflowInstance) Recover() error {
+	if wi.Status != status.InstanceStatusFailed {
+		return errors.ErrInvalidInstanceStatusTransition
+	}
+	wi.Status = status.InstanceStatusRunning
+	wi.ErrorMessage = ""
+	wi.CompletedAt = nil
+	wi.UpdatedAt = time.Now()
+	return nil
+}
+
+// Suspend 挂起运行中的实例
+func (wi *WorkflowInstance) Suspend() error {
 	if wi.Status != status.InstanceStatusRunning {
+		return errors.ErrInvalidInstanceStatusTransition
+	}
+	now := time.Now()
+	wi.Status = status.InstanceStatusSuspended
+	wi.SuspendedAt = &now
+	wi.UpdatedAt = now
+	return nil
+}
+
+// Resume 恢复挂起的实例，返回挂起的时长
+func (wi *WorkflowInstance) Resume() (time.Duration, error) {
+	if wi.Status != status.InstanceStatusSuspended {
+		return 0, errors.ErrInvalidInstanceStatusTransition
+	}
+	now := time.Now()
+	var suspended time.Duration
+	if wi.SuspendedAt != nil {
+		suspended = now.Sub(*wi.SuspendedAt)
+	}
+	wi.Status = status.InstanceStatusRunning
+	wi.SuspendedAt = nil
+	wi.UpdatedAt = now
+	return suspended, nil
+}
+
+// Cancel 取消运行中或挂起的实例
+func (wi *WorkflowInstance) Cancel() error {
+	if wi.Status != status.InstanceStatusRunning && wi.Status != status.InstanceStatusSuspended {
 		return errors.ErrCannotCancelCompletedInstance
 	}
 	now := time.Now()
 	wi.Status = status.InstanceStatusCancelled
+	wi.SuspendedAt = nil
 	wi.CompletedAt = &now
 	wi.UpdatedAt = now
 	return nil
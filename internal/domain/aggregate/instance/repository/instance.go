@@ -16,4 +16,8 @@ type WorkflowInstanceRepository interface {
 	Update(ctx context.Context, instance *instance.WorkflowInstance) error
 	Delete(ctx context.Context, id valueobject.InstanceID) error
 	CountByWorkflowID(ctx context.Context, workflowID valueobject.WorkflowID) (int64, error)
+	// FindRunningByWorkflowID 查找工作流下全部运行中的实例
+	FindRunningByWorkflowID(ctx context.Context, workflowID valueobject.WorkflowID) ([]*instance.WorkflowInstance, error)
+	// FindChildren 查找由父实例子流程步骤启动的子实例
+	FindChildren(ctx context.Context, parentID valueobject.InstanceID) ([]*instance.WorkflowInstance, error)
 }
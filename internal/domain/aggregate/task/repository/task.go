@@ -17,6 +17,12 @@ type TaskRepository interface {
 	CountTasksByInstanceID(ctx context.Context, id valueobject.InstanceID) (int, error)
 	FindTodoByAssignee(ctx context.Context, assignee int, query *command.TodoTaskPagedQuery) ([]*task.Task, int, error)
 	FindDoneByAssignee(ctx context.Context, assignee int, query *command.DoneTaskPagedQuery) ([]*task.Task, int, error)
+	// FindClaimable 查找用户作为候选用户或候选组成员可认领的任务
+	FindClaimable(ctx context.Context, userID int, userGroups []int, query *command.ClaimableTaskPagedQuery) ([]*task.Task, int, error)
+	// Claim 通过条件更新认领任务，任务已被他人认领时返回 false（多人同时认领时只有一人成功）
+	Claim(ctx context.Context, task *task.Task) (bool, error)
+	// Unclaim 通过条件更新放弃认领，任务已不由该用户认领时返回 false
+	Unclaim(ctx context.Context, task *task.Task, userID int) (bool, error)
 	GetPage(ctx context.Context, query *command.TaskPagedQuery) ([]*task.Task, int, error)
 	Update(ctx context.Context, task *task.Task) error
 	Delete(ctx context.Context, id valueobject.TaskID) error
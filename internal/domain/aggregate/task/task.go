@@ -18,6 +18,7 @@ type Task struct {
 	TaskID      valueobject.TaskID                  `json:"taskId" gorm:"primaryKey;column:id;type:uuid;comment:主键编码"`
 	InstanceID  valueobject.InstanceID              `json:"instanceId" gorm:"column:instance_id;type:uuid;index;comment:实例编码"`
 	WorkflowID  valueobject.WorkflowID              `json:"workflowId" gorm:"column:workflow_id;type:uuid;comment:工作流编码"`
+	TokenID     valueobject.TokenID                 `json:"tokenId" gorm:"column:token_id;type:uuid;index;comment:执行令牌编码"`
 	TaskNo      string                              `json:"taskNo"`
 	InstaceNo   string                              `json:"instanceNo" gorm:"-"`
 	WorkflowNo  string                              `json:"workflowNo" gorm:"-"`
@@ -28,8 +29,26 @@ type Task struct {
 	TaskType    string                              `json:"taskType"`
 	Workflow    workflow_aggregate.Workflow         `json:"-"`
 	Instance    instance_aggregate.WorkflowInstance `json:"-"`
-	// 任务分配
-	Assignee int `json:"assignee"`
+	// 任务分配：未指定处理人时由候选人认领
+	Assignee   int             `json:"assignee"`
+	Candidates []TaskCandidate `json:"candidates" gorm:"foreignKey:TaskID;references:TaskID"`
+	// 委托与转办：处理人委托期间任务自动交给受托人，转办时记录经手的处理人，均保留原处理人
+	OriginalAssignee int   `json:"originalAssignee" gorm:"comment:原处理人"`
+	DelegationChain  []int `json:"delegationChain" gorm:"serializer:json;type:jsonb;comment:转办链"` // 原处理人及之后依次转办到的处理人
+
+	// 会签：同一次会签创建的任务属于同一任务组，顺序会签按序号依次处理
+	GroupID  valueobject.TaskGroupID `json:"groupId" gorm:"column:group_id;type:uuid;index;comment:会签任务组编码"`
+	Sequence int                     `json:"sequence" gorm:"comment:会签序号"`
+
+	// 加签：加签产生的任务记录来源任务和加签位置
+	SourceTaskID valueobject.TaskID `json:"sourceTaskId" gorm:"column:source_task_id;type:uuid;index;comment:加签来源任务编码"`
+	SignPosition string             `json:"signPosition" gorm:"comment:加签位置"`
+
+	// 驳回：驳回时记录指定的目标；驳回产生的任务记录发起驳回的步骤，处理时可直接提交回该步骤
+	RejectTarget      string `json:"rejectTarget" gorm:"comment:驳回目标步骤"`
+	RejectToInitiator bool   `json:"rejectToInitiator" gorm:"comment:是否驳回到发起人"`
+	ReturnTaskKey     string `json:"returnTaskKey" gorm:"comment:发起驳回的步骤"`
+	DirectResubmit    bool   `json:"directResubmit" gorm:"comment:是否直接提交回发起驳回的步骤"`
 
 	// 任务状态
 	Status   status.TaskStatus   `json:"status"`
@@ -54,6 +73,13 @@ type Task struct {
 	models.ModelTime
 }
 
+// 加签位置
+const (
+	SignPositionBefore   = "before"   // 前加签：加签人先处理，处理后交回原处理人
+	SignPositionAfter    = "after"    // 后加签：原处理人通过后再由加签人处理
+	SignPositionParallel = "parallel" // 并加签：加签人与原处理人同时处理
+)
+
 // TableName 指定表名
 func (Task) TableName() string {
 	return "workflow_tasks"
@@ -82,6 +108,18 @@ func (t *Task) Complete(cmd *command.CompleteTaskCommand) error {
 	if t.Status != status.TaskStatusPending {
 		return errors.ErrTaskNotPending
 	}
+	if cmd.Result == status.TaskResultRejected {
+		if cmd.TargetStepID != "" && cmd.ToInitiator {
+			return errors.ErrInvalidRejectTarget
+		}
+		t.RejectTarget = cmd.TargetStepID
+		t.RejectToInitiator = cmd.ToInitiator
+	} else if cmd.DirectResubmit {
+		if t.ReturnTaskKey == "" {
+			return errors.ErrCannotResubmitDirectly
+		}
+		t.DirectResubmit = true
+	}
 
 	now := time.Now()
 	t.Output = cmd.Output
@@ -98,18 +136,127 @@ func (t *Task) Complete(cmd *command.CompleteTaskCommand) error {
 	return nil
 }
 
+// IsOpen 任务是否尚未处理（待处理或等待轮到）
+func (t *Task) IsOpen() bool {
+	return t.Status == status.TaskStatusPending || t.Status == status.TaskStatusWaiting
+}
+
+// Activate 顺序会签轮到该任务，转为待处理
+func (t *Task) Activate() error {
+	if t.Status != status.TaskStatusWaiting {
+		return errors.ErrTaskNotPending
+	}
+	t.Status = status.TaskStatusPending
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// Deactivate 撤回时将已激活的顺序会签或后加签任务转回等待
+func (t *Task) Deactivate() error {
+	if t.Status != status.TaskStatusPending {
+		return errors.ErrTaskNotPending
+	}
+	t.Status = status.TaskStatusWaiting
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// Reopen 撤回已处理的任务，重新转为待处理
+func (t *Task) Reopen(waiting bool) error {
+	if t.Status != status.TaskStatusCompleted && t.Status != status.TaskStatusRejected && t.Status != status.TaskStatusCancelled {
+		return errors.ErrCannotWithdraw
+	}
+	t.Status = status.TaskStatusPending
+	if waiting {
+		t.Status = status.TaskStatusWaiting
+	}
+	t.Result = ""
+	t.CompletedAt = nil
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// Cancel 取消尚未处理的任务
+func (t *Task) Cancel(comment string) error {
+	if !t.IsOpen() {
+		return errors.ErrTaskNotPending
+	}
+	now := time.Now()
+	t.Status = status.TaskStatusCancelled
+	t.Comment = comment
+	t.CompletedAt = &now
+	t.UpdatedAt = now
+	return nil
+}
+
+// AddSigner 为待处理任务加签，返回加签人的任务
+// 前加签时原任务转为等待，加签人处理后再交回原处理人；后加签时加签人的任务等待原处理人通过
+func (t *Task) AddSigner(signer int, position string) (*Task, error) {
+	if t.Status != status.TaskStatusPending {
+		return nil, errors.ErrTaskNotPending
+	}
+	if signer == 0 || signer == t.Assignee || !t.SourceTaskID.IsEmpty() {
+		return nil, errors.ErrInvalidSigner
+	}
+
+	added := NewTask(t.InstanceID, t.WorkflowID)
+	added.TokenID = t.TokenID
+	added.TaskName = t.TaskName
+	added.TaskKey = t.TaskKey
+	added.Description = t.Description
+	added.TaskType = t.TaskType
+	added.Priority = t.Priority
+	added.TaskData = t.TaskData
+	added.FormData = t.FormData
+	added.DueDate = t.DueDate
+	added.Assignee = signer
+	added.SourceTaskID = t.TaskID
+	added.SignPosition = position
+
+	switch position {
+	case SignPositionBefore:
+		t.Status = status.TaskStatusWaiting
+		t.UpdatedAt = time.Now()
+	case SignPositionAfter:
+		added.Status = status.TaskStatusWaiting
+	case SignPositionParallel:
+	default:
+		return nil, errors.ErrInvalidSignPosition
+	}
+	return added, nil
+}
+
+// RejectBySigner 加签人驳回时，原任务的处理结果随之改为驳回，驳回目标以加签人指定的为准
+func (t *Task) RejectBySigner(signer *Task) {
+	now := time.Now()
+	t.RejectTarget = signer.RejectTarget
+	t.RejectToInitiator = signer.RejectToInitiator
+	t.Status = status.TaskStatusRejected
+	t.Result = status.TaskResultRejected
+	if t.CompletedAt == nil {
+		t.CompletedAt = &now
+	}
+	t.UpdatedAt = now
+}
+
 // CanBeClaimed 判断任务是否可以被认领
 func (t *Task) CanBeClaimed(userID int, userGroups []int) bool {
 	if t.Status != status.TaskStatusPending {
 		return false
 	}
 
+	// 已被认领的任务不能再次认领
+	if t.ClaimedAt != nil {
+		return false
+	}
+
 	// 如果指定了处理人，只有该处理人可以认领
 	if t.Assignee != 0 {
 		return t.Assignee == userID
 	}
 
-	return false
+	// 未指定处理人时，候选用户和候选组成员可以认领
+	return t.IsCandidate(userID, userGroups)
 }
 
 // TaskHistory 任务历史记录
@@ -143,3 +290,12 @@ func NewTaskHistory(taskID valueobject.TaskID, instanceID valueobject.InstanceID
 		CreatedAt:  time.Now(),
 	}
 }
+
+// IsSystemAction 历史是否由引擎自动产生（自动委托、自动化步骤重试、子流程完成），而非用户对任务的操作
+func (h *TaskHistory) IsSystemAction() bool {
+	switch h.Action {
+	case "auto_delegate", "attempt", "sub_process_complete":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package task_aggregate
+
+import (
+	"time"
+
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	errors "jxt-evidence-system/process-management/shared/common/errors"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// 候选类型
+const (
+	CandidateTypeUser  = "user"  // 候选用户
+	CandidateTypeGroup = "group" // 候选组（组织机构），组内成员都可认领
+)
+
+// TaskCandidate 任务候选人，未分配处理人的任务由候选用户或候选组成员认领
+type TaskCandidate struct {
+	TaskID        valueobject.TaskID `json:"-" gorm:"primaryKey;column:task_id;type:uuid;comment:任务编码"`
+	CandidateType string             `json:"type" gorm:"primaryKey;size:16;comment:候选类型"`
+	CandidateID   int                `json:"id" gorm:"primaryKey;autoIncrement:false;index;comment:候选用户或组编码"`
+}
+
+// TableName 指定表名
+func (TaskCandidate) TableName() string {
+	return "workflow_task_candidates"
+}
+
+// SetCandidates 设置任务的候选用户和候选组，重复的编码只保留一个
+func (t *Task) SetCandidates(users, groups []int) {
+	t.Candidates = nil
+	seen := make(map[TaskCandidate]bool)
+	add := func(candidateType string, ids []int) {
+		for _, id := range ids {
+			c := TaskCandidate{TaskID: t.TaskID, CandidateType: candidateType, CandidateID: id}
+			if id == 0 || seen[c] {
+				continue
+			}
+			seen[c] = true
+			t.Candidates = append(t.Candidates, c)
+		}
+	}
+	add(CandidateTypeUser, users)
+	add(CandidateTypeGroup, groups)
+}
+
+// IsCandidate 判断用户是否为任务的候选人（候选用户或属于候选组）
+func (t *Task) IsCandidate(userID int, userGroups []int) bool {
+	for _, c := range t.Candidates {
+		switch c.CandidateType {
+		case CandidateTypeUser:
+			if c.CandidateID == userID {
+				return true
+			}
+		case CandidateTypeGroup:
+			for _, g := range userGroups {
+				if c.CandidateID == g {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// CandidateUsers 任务的候选用户
+func (t *Task) CandidateUsers() []int {
+	var users []int
+	for _, c := range t.Candidates {
+		if c.CandidateType == CandidateTypeUser {
+			users = append(users, c.CandidateID)
+		}
+	}
+	return users
+}
+
+// CandidateGroups 任务的候选组
+func (t *Task) CandidateGroups() []int {
+	var groups []int
+	for _, c := range t.Candidates {
+		if c.CandidateType == CandidateTypeGroup {
+			groups = append(groups, c.CandidateID)
+		}
+	}
+	return groups
+}
+
+// Claim 用户认领任务，认领后任务进入该用户的待办
+func (t *Task) Claim(userID int, userGroups []int) error {
+	if t.Status != status.TaskStatusPending {
+		return errors.ErrTaskNotPending
+	}
+	if t.ClaimedAt != nil || (t.Assignee != 0 && t.Assignee != userID) {
+		return errors.ErrTaskAlreadyClaimed
+	}
+	if !t.CanBeClaimed(userID, userGroups) {
+		return errors.ErrTaskNotClaimable
+	}
+	now := time.Now()
+	t.Assignee = userID
+	t.ClaimedAt = &now
+	t.UpdatedAt = now
+	return nil
+}
+
+// Unclaim 认领人放弃认领，有候选人的任务退回候选人的待领列表
+func (t *Task) Unclaim(userID int) error {
+	if t.Status != status.TaskStatusPending {
+		return errors.ErrTaskNotPending
+	}
+	if t.ClaimedAt == nil {
+		return errors.ErrTaskNotClaimed
+	}
+	if t.Assignee != userID {
+		return errors.ErrUnauthorized
+	}
+	if len(t.Candidates) > 0 {
+		t.Assignee = 0
+	}
+	t.ClaimedAt = nil
+	t.UpdatedAt = time.Now()
+	return nil
+}
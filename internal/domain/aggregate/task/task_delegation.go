@@ -0,0 +1,54 @@
+package task_aggregate
+
+import (
+	"time"
+
+	errors "jxt-evidence-system/process-management/shared/common/errors"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// Delegate 当前处理人将任务转办给其他用户
+// 转办链从原处理人开始记录经手的处理人，转办给链中的用户会形成环而被拒绝；转回原处理人时转办链重置。
+// maxHops 大于0时限制原处理人之后的转办次数
+func (t *Task) Delegate(from, to int, maxHops int) error {
+	if t.Status != status.TaskStatusPending {
+		return errors.ErrTaskNotPending
+	}
+	if t.Assignee != from {
+		return errors.ErrUnauthorized
+	}
+	if to == 0 || to == from {
+		return errors.ErrInvalidDelegateTarget
+	}
+
+	chain := t.DelegationChain
+	if len(chain) == 0 {
+		// 自动委托产生的任务以委托人为原处理人
+		if t.OriginalAssignee != 0 && t.OriginalAssignee != from {
+			chain = []int{t.OriginalAssignee, from}
+		} else {
+			chain = []int{from}
+		}
+	}
+	owner := chain[0]
+
+	if to == owner {
+		t.DelegationChain = nil
+		t.OriginalAssignee = 0
+	} else {
+		for _, userID := range chain {
+			if userID == to {
+				return errors.ErrDelegationCycle
+			}
+		}
+		if maxHops > 0 && len(chain) > maxHops {
+			return errors.ErrDelegationLimitExceeded
+		}
+		t.DelegationChain = append(chain, to)
+		t.OriginalAssignee = owner
+	}
+
+	t.Assignee = to
+	t.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	timer "jxt-evidence-system/process-management/internal/domain/aggregate/timer"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+)
+
+// TimerRepository 定时器仓储接口
+type TimerRepository interface {
+	Save(ctx context.Context, timer *timer.Timer) error
+	FindByID(ctx context.Context, id valueobject.TimerID) (*timer.Timer, error)
+	// FindDue 查找到期且未触发的定时器，按触发时间升序
+	FindDue(ctx context.Context, now time.Time, limit int) ([]*timer.Timer, error)
+	// Claim 原子地将待触发定时器标记为已触发，返回是否抢占成功（多实例部署时避免重复触发）
+	Claim(ctx context.Context, id valueobject.TimerID) (bool, error)
+	Update(ctx context.Context, timer *timer.Timer) error
+	CancelByTaskID(ctx context.Context, taskID valueobject.TaskID) error
+	CancelByInstanceID(ctx context.Context, instanceID valueobject.InstanceID) error
+	// PauseByInstanceID 暂停实例下所有待触发的定时器
+	PauseByInstanceID(ctx context.Context, instanceID valueobject.InstanceID) error
+	// ResumeByInstanceID 恢复实例下暂停的定时器，触发时间顺延 shift
+	ResumeByInstanceID(ctx context.Context, instanceID valueobject.InstanceID, shift time.Duration) error
+}
@@ -0,0 +1,89 @@
+package timer_aggregate
+
+import (
+	"encoding/json"
+	"time"
+
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	errors "jxt-evidence-system/process-management/shared/common/errors"
+	"jxt-evidence-system/process-management/shared/common/models"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// Timer 持久化定时器
+// 用于步骤超时等需要在未来某个时间点触发的动作，服务重启后由调度器继续扫描触发
+type Timer struct {
+	TimerID      valueobject.TimerID    `json:"timerId" gorm:"primaryKey;column:id;type:uuid;comment:主键编码"`
+	InstanceID   valueobject.InstanceID `json:"instanceId" gorm:"column:instance_id;type:uuid;index;comment:实例编码"`
+	WorkflowID   valueobject.WorkflowID `json:"workflowId" gorm:"column:workflow_id;type:uuid;comment:工作流编码"`
+	TaskID       valueobject.TaskID     `json:"taskId" gorm:"column:task_id;type:uuid;index;comment:任务编码"`
+	StepID       string                 `json:"stepId" gorm:"comment:步骤编码"`
+	TimerType    status.TimerType       `json:"timerType" gorm:"comment:定时器类型"`
+	Payload      json.RawMessage        `gorm:"type:jsonb" json:"payload"` // 触发时需要的参数（如超时策略）
+	Status       status.TimerStatus     `json:"status" gorm:"index;comment:定时器状态"`
+	DueAt        time.Time              `json:"dueAt" gorm:"index;comment:触发时间"`
+	FiredAt      *time.Time             `json:"firedAt"`
+	ErrorMessage string                 `json:"errorMessage"`
+
+	// 审计字段
+	models.ModelTime
+}
+
+// TableName 指定表名
+func (Timer) TableName() string {
+	return "workflow_timers"
+}
+
+// NewTimer 创建新定时器
+func NewTimer(instanceID valueobject.InstanceID, workflowID valueobject.WorkflowID, taskID valueobject.TaskID, stepID string, timerType status.TimerType, dueAt time.Time, payload json.RawMessage) *Timer {
+	now := time.Now()
+	return &Timer{
+		TimerID:    valueobject.NewTimerID(),
+		InstanceID: instanceID,
+		WorkflowID: workflowID,
+		TaskID:     taskID,
+		StepID:     stepID,
+		TimerType:  timerType,
+		Payload:    payload,
+		Status:     status.TimerStatusPending,
+		DueAt:      dueAt,
+		ModelTime: models.ModelTime{
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+}
+
+// IsDue 判断定时器是否到期
+func (t *Timer) IsDue(now time.Time) bool {
+	return t.Status == status.TimerStatusPending && !t.DueAt.After(now)
+}
+
+// Fire 标记定时器已触发
+func (t *Timer) Fire() error {
+	if t.Status != status.TimerStatusPending {
+		return errors.ErrTimerNotPending
+	}
+	now := time.Now()
+	t.Status = status.TimerStatusFired
+	t.FiredAt = &now
+	t.UpdatedAt = now
+	return nil
+}
+
+// Cancel 取消定时器
+func (t *Timer) Cancel() error {
+	if t.Status != status.TimerStatusPending {
+		return errors.ErrTimerNotPending
+	}
+	t.Status = status.TimerStatusCancelled
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// Fail 定时器触发后处理失败
+func (t *Timer) Fail(errorMsg string) {
+	t.Status = status.TimerStatusFailed
+	t.ErrorMessage = errorMsg
+	t.UpdatedAt = time.Now()
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	token "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// TokenRepository 执行令牌仓储接口
+type TokenRepository interface {
+	Save(ctx context.Context, token *token.ExecutionToken) error
+	FindByID(ctx context.Context, id valueobject.TokenID) (*token.ExecutionToken, error)
+	// FindByIDForUpdate 查找令牌并加行锁，须在事务中调用，锁在事务结束时释放
+	FindByIDForUpdate(ctx context.Context, id valueobject.TokenID) (*token.ExecutionToken, error)
+	// FindByInstanceID 查找实例的所有令牌，按创建时间升序
+	FindByInstanceID(ctx context.Context, instanceID valueobject.InstanceID) ([]*token.ExecutionToken, error)
+	// FindChildren 查找父令牌分支出的子令牌
+	FindChildren(ctx context.Context, parentID valueobject.TokenID) ([]*token.ExecutionToken, error)
+	Update(ctx context.Context, token *token.ExecutionToken) error
+	// CloseByInstanceID 将实例下所有未结束的令牌置为指定状态
+	CloseByInstanceID(ctx context.Context, instanceID valueobject.InstanceID, tokenStatus status.TokenStatus) error
+}
@@ -0,0 +1,99 @@
+package token_aggregate
+
+import (
+	"time"
+
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	"jxt-evidence-system/process-management/shared/common/models"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// ExecutionToken 执行令牌
+// 标记实例当前执行到的位置。启动时创建根令牌，分支（网关、并行步骤）为每条分支创建子令牌，
+// 汇聚网关按子令牌的到达情况判断分支是否全部完成
+type ExecutionToken struct {
+	TokenID    valueobject.TokenID    `json:"tokenId" gorm:"primaryKey;column:id;type:uuid;comment:主键编码"`
+	InstanceID valueobject.InstanceID `json:"instanceId" gorm:"column:instance_id;type:uuid;index;comment:实例编码"`
+	ParentID   valueobject.TokenID    `json:"parentId" gorm:"column:parent_id;type:uuid;index;comment:父令牌编码"`
+	ForkStepID string                 `json:"forkStepId" gorm:"comment:创建该令牌的分支步骤"`
+	StepID     string                 `json:"stepId" gorm:"comment:当前步骤"`
+	Status     status.TokenStatus     `json:"status" gorm:"index;comment:令牌状态"`
+
+	// 审计字段
+	models.ModelTime
+}
+
+// TableName 指定表名
+func (ExecutionToken) TableName() string {
+	return "execution_tokens"
+}
+
+// NewRootToken 创建实例的根令牌
+func NewRootToken(instanceID valueobject.InstanceID, stepID string) *ExecutionToken {
+	return newToken(instanceID, valueobject.TokenID{}, "", stepID)
+}
+
+func newToken(instanceID valueobject.InstanceID, parentID valueobject.TokenID, forkStepID, stepID string) *ExecutionToken {
+	now := time.Now()
+	return &ExecutionToken{
+		TokenID:    valueobject.NewTokenID(),
+		InstanceID: instanceID,
+		ParentID:   parentID,
+		ForkStepID: forkStepID,
+		StepID:     stepID,
+		Status:     status.TokenStatusActive,
+		ModelTime: models.ModelTime{
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+}
+
+// IsRoot 是否为根令牌
+func (t *ExecutionToken) IsRoot() bool {
+	return t.ParentID.IsEmpty()
+}
+
+// IsLive 令牌是否仍在流程中（未结束、未取消）
+func (t *ExecutionToken) IsLive() bool {
+	return t.Status == status.TokenStatusActive || t.Status == status.TokenStatusForked || t.Status == status.TokenStatusWaiting
+}
+
+// Fork 在分支步骤处为每个分支目标创建子令牌，当前令牌进入等待汇聚状态
+func (t *ExecutionToken) Fork(forkStepID string, branchStepIDs []string) []*ExecutionToken {
+	t.StepID = forkStepID
+	t.Status = status.TokenStatusForked
+	t.UpdatedAt = time.Now()
+
+	children := make([]*ExecutionToken, 0, len(branchStepIDs))
+	for _, stepID := range branchStepIDs {
+		children = append(children, newToken(t.InstanceID, t.TokenID, forkStepID, stepID))
+	}
+	return children
+}
+
+// MoveTo 令牌流转到指定步骤
+func (t *ExecutionToken) MoveTo(stepID string) {
+	t.StepID = stepID
+	t.Status = status.TokenStatusActive
+	t.UpdatedAt = time.Now()
+}
+
+// WaitAt 令牌到达汇聚网关，等待其他分支
+func (t *ExecutionToken) WaitAt(joinStepID string) {
+	t.StepID = joinStepID
+	t.Status = status.TokenStatusWaiting
+	t.UpdatedAt = time.Now()
+}
+
+// Complete 令牌结束
+func (t *ExecutionToken) Complete() {
+	t.Status = status.TokenStatusCompleted
+	t.UpdatedAt = time.Now()
+}
+
+// Cancel 取消令牌
+func (t *ExecutionToken) Cancel() {
+	t.Status = status.TokenStatusCancelled
+	t.UpdatedAt = time.Now()
+}
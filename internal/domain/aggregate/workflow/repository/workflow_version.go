@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	workflow "jxt-evidence-system/process-management/internal/domain/aggregate/workflow"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+)
+
+// WorkflowVersionRepository 工作流版本仓储接口
+type WorkflowVersionRepository interface {
+	Save(ctx context.Context, version *workflow.WorkflowVersion) error
+	FindByVersion(ctx context.Context, workflowID valueobject.WorkflowID, version int) (*workflow.WorkflowVersion, error)
+	// FindLatest 查找最近发布的版本，尚未发布时返回 ErrWorkflowVersionNotFound
+	FindLatest(ctx context.Context, workflowID valueobject.WorkflowID) (*workflow.WorkflowVersion, error)
+	// FindByWorkflowID 按版本号降序列出工作流的全部版本
+	FindByWorkflowID(ctx context.Context, workflowID valueobject.WorkflowID) ([]*workflow.WorkflowVersion, error)
+}
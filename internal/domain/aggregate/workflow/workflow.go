@@ -10,13 +10,15 @@ import (
 )
 
 // Workflow 工作流聚合根
+// Definition 为可编辑的草稿定义，激活时发布为不可变的 WorkflowVersion，实例按版本执行
 type Workflow struct {
-	WorkflowID  valueobject.WorkflowID `json:"workflowId" gorm:"primaryKey;column:id;type:uuid;comment:主键编码"`
-	WorkflowNo  string                 `json:"workflowNo"`
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Status      status.WorkflowStatus  `json:"status"`
-	Definition  string                 `gorm:"type:jsonb" json:"definition"`
+	WorkflowID     valueobject.WorkflowID `json:"workflowId" gorm:"primaryKey;column:id;type:uuid;comment:主键编码"`
+	WorkflowNo     string                 `json:"workflowNo"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	Status         status.WorkflowStatus  `json:"status"`
+	Definition     string                 `gorm:"type:jsonb" json:"definition"`
+	CurrentVersion int                    `json:"currentVersion" gorm:"comment:当前发布版本号"` // 0 表示尚未发布
 	// 审计字段
 	models.ControlBy
 	models.ModelTime
@@ -52,6 +54,22 @@ func (w *Workflow) Activate() error {
 	return nil
 }
 
+// PublishVersion 发布当前定义，latest 为最近一次发布的版本（可为空）
+// 定义与最近版本相同时不产生新版本，返回 nil
+func (w *Workflow) PublishVersion(latest *WorkflowVersion, publishBy int) *WorkflowVersion {
+	if latest != nil && latest.Definition == w.Definition {
+		w.CurrentVersion = latest.Version
+		return nil
+	}
+
+	next := 1
+	if latest != nil {
+		next = latest.Version + 1
+	}
+	w.CurrentVersion = next
+	return NewWorkflowVersion(w, next, publishBy)
+}
+
 // Freeze 冻结工作流
 func (w *Workflow) Freeze() error {
 	if w.Status != status.StatusActive {
@@ -0,0 +1,36 @@
+package workflow_aggregate
+
+import (
+	"time"
+
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+)
+
+// WorkflowVersion 工作流定义的已发布版本，发布后不可修改
+type WorkflowVersion struct {
+	WorkflowID  valueobject.WorkflowID `json:"workflowId" gorm:"primaryKey;column:workflow_id;type:uuid;comment:工作流编码"`
+	Version     int                    `json:"version" gorm:"primaryKey;autoIncrement:false;comment:版本号"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Definition  string                 `gorm:"type:jsonb" json:"definition"`
+	CreateBy    int                    `json:"createBy" gorm:"index;comment:发布者"`
+	CreatedAt   time.Time              `json:"createdAt" gorm:"comment:发布时间"`
+}
+
+// NewWorkflowVersion 根据工作流当前定义创建版本
+func NewWorkflowVersion(w *Workflow, version int, publishBy int) *WorkflowVersion {
+	return &WorkflowVersion{
+		WorkflowID:  w.WorkflowID,
+		Version:     version,
+		Name:        w.Name,
+		Description: w.Description,
+		Definition:  w.Definition,
+		CreateBy:    publishBy,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// TableName 指定表名
+func (WorkflowVersion) TableName() string {
+	return "workflow_versions"
+}
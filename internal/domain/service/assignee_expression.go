@@ -0,0 +1,162 @@
+package domain_service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+)
+
+// 处理人表达式类型
+const (
+	AssigneeKindRole     = "role"     // role:角色@org:组织，组织内担任该角色的用户
+	AssigneeKindLeaderOf = "leaderOf" // leaderOf:用户，用户所在组织的负责人
+	AssigneeKindOrgHead  = "orgHead"  // orgHead:组织 或 orgHead:parent(组织)，组织或其上级组织的负责人
+	AssigneeKindPoliceNo = "policeNo" // policeNo:警号，警号对应的用户
+)
+
+// AssigneeExpression 解析后的处理人表达式，由 AssigneeResolver 通过用户/组织服务解析为用户
+type AssigneeExpression struct {
+	Kind     string `json:"kind"`
+	Role     string `json:"role,omitempty"`     // 角色名称
+	Org      string `json:"org,omitempty"`      // 组织ID、编码或名称
+	Parent   bool   `json:"parent,omitempty"`   // orgHead 取上级组织的负责人
+	User     string `json:"user,omitempty"`     // 用户ID
+	PoliceNo string `json:"policeNo,omitempty"` // 警号
+}
+
+// String 还原为表达式文本
+func (e *AssigneeExpression) String() string {
+	switch e.Kind {
+	case AssigneeKindRole:
+		return fmt.Sprintf("role:%s@org:%s", e.Role, e.Org)
+	case AssigneeKindLeaderOf:
+		return "leaderOf:" + e.User
+	case AssigneeKindOrgHead:
+		if e.Parent {
+			return fmt.Sprintf("orgHead:parent(%s)", e.Org)
+		}
+		return "orgHead:" + e.Org
+	case AssigneeKindPoliceNo:
+		return "policeNo:" + e.PoliceNo
+	}
+	return e.Kind
+}
+
+// IsAssigneeExpression 判断处理人配置是否为需要通过用户/组织服务解析的表达式
+// 数字和 ${variable} 仍按用户ID处理
+func IsAssigneeExpression(value string) bool {
+	kind, _, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return false
+	}
+	switch kind {
+	case AssigneeKindRole, AssigneeKindLeaderOf, AssigneeKindOrgHead, AssigneeKindPoliceNo:
+		return true
+	}
+	return false
+}
+
+// ParseAssigneeExpression 解析处理人表达式，变量应已替换
+func ParseAssigneeExpression(value string) (*AssigneeExpression, error) {
+	kind, arg, ok := strings.Cut(strings.TrimSpace(value), ":")
+	arg = strings.TrimSpace(arg)
+	if !ok || arg == "" {
+		return nil, fmt.Errorf("invalid assignee expression %q", value)
+	}
+
+	expr := &AssigneeExpression{Kind: kind}
+	switch kind {
+	case AssigneeKindRole:
+		role, org, ok := strings.Cut(arg, "@org:")
+		expr.Role, expr.Org = strings.TrimSpace(role), strings.TrimSpace(org)
+		if !ok || expr.Role == "" || expr.Org == "" {
+			return nil, fmt.Errorf("invalid assignee expression %q: expected role:<role>@org:<org>", value)
+		}
+	case AssigneeKindLeaderOf:
+		expr.User = arg
+	case AssigneeKindOrgHead:
+		if strings.HasPrefix(arg, "parent(") {
+			if !strings.HasSuffix(arg, ")") {
+				return nil, fmt.Errorf("invalid assignee expression %q: unclosed parent(", value)
+			}
+			expr.Parent = true
+			arg = strings.TrimSpace(arg[len("parent(") : len(arg)-1])
+			if arg == "" {
+				return nil, fmt.Errorf("invalid assignee expression %q: expected orgHead:parent(<org>)", value)
+			}
+		}
+		expr.Org = arg
+	case AssigneeKindPoliceNo:
+		expr.PoliceNo = arg
+	default:
+		return nil, fmt.Errorf("unknown assignee expression kind %q", kind)
+	}
+	return expr, nil
+}
+
+// InterpolateVariables 将文本中的 ${path} 替换为实例变量的值，path 与条件表达式中的变量引用一致
+func (s *WorkflowDomainService) InterpolateVariables(ctx context.Context, value string, instance *instance_aggregate.WorkflowInstance) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	evaluator := NewConditionEvaluatorWithSnapshot(s.NewVariableSnapshot(ctx, instance))
+	var b strings.Builder
+	rest := value
+	for {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unclosed variable reference in %q", value)
+		}
+		path := strings.TrimSpace(rest[start+2 : start+end])
+		segments, err := parseVariablePath(path)
+		if err != nil {
+			return "", fmt.Errorf("invalid variable reference ${%s}: %v", path, err)
+		}
+		resolved, err := evaluator.resolveVariable(path, segments)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(formatVariable(resolved))
+		rest = rest[start+end+1:]
+	}
+}
+
+// formatVariable 变量值转为文本，整数不带小数部分
+func formatVariable(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// validateAssigneeExpression 校验处理人表达式的结构，变量以占位值代替
+func validateAssigneeExpression(value string) error {
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(value[start:], "}")
+		if end < 0 {
+			return fmt.Errorf("unclosed variable reference in %q", value)
+		}
+		value = value[:start] + "0" + value[start+end+1:]
+	}
+	_, err := ParseAssigneeExpression(value)
+	return err
+}
@@ -0,0 +1,76 @@
+package domain_service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+func TestParseAssigneeExpression(t *testing.T) {
+	tests := []struct {
+		value string
+		want  AssigneeExpression
+	}{
+		{"role:法制员@org:12", AssigneeExpression{Kind: AssigneeKindRole, Role: "法制员", Org: "12"}},
+		{"leaderOf:7", AssigneeExpression{Kind: AssigneeKindLeaderOf, User: "7"}},
+		{"orgHead:12", AssigneeExpression{Kind: AssigneeKindOrgHead, Org: "12"}},
+		{"orgHead:parent( 12 )", AssigneeExpression{Kind: AssigneeKindOrgHead, Org: "12", Parent: true}},
+		{" policeNo:A001 ", AssigneeExpression{Kind: AssigneeKindPoliceNo, PoliceNo: "A001"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseAssigneeExpression(tt.value)
+		if err != nil {
+			t.Errorf("ParseAssigneeExpression(%q) error = %v", tt.value, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("ParseAssigneeExpression(%q) = %+v, want %+v", tt.value, *got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"role:法制员", "role:@org:12", "orgHead:parent(12", "orgHead:parent()", "policeNo:", "manager:1"} {
+		if _, err := ParseAssigneeExpression(value); err == nil {
+			t.Errorf("ParseAssigneeExpression(%q) expected error", value)
+		}
+	}
+}
+
+func TestInterpolateAssigneeVariables(t *testing.T) {
+	instance := &instance_aggregate.WorkflowInstance{Input: json.RawMessage(`{"orgId": 12, "case": {"owner": "A001"}}`), InitiatorID: 7}
+	review := task_aggregate.NewTask(instance.InstanceId, instance.WorkflowID)
+	review.TaskKey = "review"
+	review.Status = status.TaskStatusCompleted
+	review.Output = json.RawMessage(`{"orgId": 30}`)
+	s := NewWorkflowDomainService(&countingTaskRepo{tasks: []*task_aggregate.Task{review}})
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"role:法制员@org:${input.orgId}", "role:法制员@org:12"},
+		{"policeNo:${input.case.owner}", "policeNo:A001"},
+		{"leaderOf:${initiator}", "leaderOf:7"},
+		{"orgHead:parent(${review.orgId})", "orgHead:parent(30)"},
+		{"orgHead:12", "orgHead:12"},
+	}
+	for _, tt := range tests {
+		got, err := s.InterpolateVariables(context.Background(), tt.value, instance)
+		if err != nil {
+			t.Errorf("InterpolateVariables(%q) error = %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("InterpolateVariables(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"orgHead:${input.missing}", "orgHead:${input.orgId"} {
+		if _, err := s.InterpolateVariables(context.Background(), value, instance); err == nil {
+			t.Errorf("InterpolateVariables(%q) expected error", value)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package domain_service
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// 导出布局参数（像素）
+const (
+	bpmnLayoutColumnWidth = 180
+	bpmnLayoutRowHeight   = 130
+	bpmnLayoutMargin      = 100
+)
+
+// bpmnTextEscaper 扩展元素文本转义，保留引号以便在建模工具中阅读
+var bpmnTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// bpmnExporter 工作流定义到 BPMN 的转换器，与 bpmnImporter 互逆
+type bpmnExporter struct {
+	definition *WorkflowDefinitionStruct
+	elements   []*bpmnElement
+	byID       map[string]*bpmnElement
+	reserved   map[string]bool   // 定义中的全部步骤ID，生成的元素ID不能与之冲突
+	joins      map[string]string // parallel 步骤ID -> 汇聚网关ID
+	flowSeq    int
+	endEventID string
+}
+
+// ExportBpmn 将工作流定义转换为 BPMN 2.0 XML（含简单的自动布局，可直接在建模工具中打开）
+func (s *WorkflowDomainService) ExportBpmn(processID string, definition *WorkflowDefinitionStruct) ([]byte, error) {
+	if len(definition.Steps) == 0 {
+		return nil, fmt.Errorf("workflow has no steps")
+	}
+
+	ex := &bpmnExporter{
+		definition: definition,
+		byID:       make(map[string]*bpmnElement),
+		reserved:   make(map[string]bool),
+		joins:      make(map[string]string),
+	}
+	for id := range flattenSteps(definition.Steps) {
+		ex.reserved[id] = true
+	}
+	if err := ex.build(); err != nil {
+		return nil, err
+	}
+
+	processID = "Process_" + processID
+	doc := bpmnDefinitions{
+		XMLName:         xml.Name{Space: BpmnModelNamespace, Local: "definitions"},
+		XmlnsBpmndi:     BpmnDINamespace,
+		XmlnsDC:         BpmnDCNamespace,
+		XmlnsDI:         BpmnDDINamespace,
+		XmlnsXSI:        BpmnXSINamespace,
+		XmlnsPM:         BpmnExtensionNamespace,
+		ID:              "Definitions_1",
+		TargetNamespace: BpmnExtensionNamespace,
+		Processes: []bpmnProcess{{
+			ID:            processID,
+			Name:          definition.Name,
+			IsExecutable:  "true",
+			Documentation: definition.Description,
+		}},
+		Diagrams: []bpmnDiagram{{ID: "BPMNDiagram_1", Plane: ex.layout(processID)}},
+	}
+	for _, el := range ex.elements {
+		doc.Processes[0].Elements = append(doc.Processes[0].Elements, *el)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode BPMN XML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (ex *bpmnExporter) build() error {
+	steps := ex.definition.Steps
+
+	start := ex.addNode(bpmnStartEvent, ex.uniqueID("StartEvent_1"), "")
+	for i := range steps {
+		if err := ex.addStep(&steps[i]); err != nil {
+			return err
+		}
+	}
+	ex.addFlow(start.ID, steps[0].ID, "")
+
+	for i := range steps {
+		step := &steps[i]
+		if step.Type == StepTypeComplete {
+			continue
+		}
+		if IsGateway(step) {
+			ex.addGatewayFlows(step, ex.successors(i))
+			continue
+		}
+		source := step.ID
+		if join, ok := ex.joins[step.ID]; ok {
+			source = join
+		}
+		ex.addSuccessors(source, step.ID, ex.successors(i))
+	}
+	return nil
+}
+
+// addStep 添加步骤对应的元素，parallel 步骤展开为分支网关、并行任务和汇聚网关
+func (ex *bpmnExporter) addStep(step *StepDefinition) error {
+	if step.ID == "" {
+		return fmt.Errorf("step without id cannot be exported")
+	}
+	if _, ok := ex.byID[step.ID]; ok {
+		return fmt.Errorf("duplicate step id %s", step.ID)
+	}
+
+	switch step.Type {
+	case StepTypeComplete:
+		el := ex.addNode(bpmnEndEvent, step.ID, step.Name)
+		el.Documentation = step.Description
+	case StepTypeParallel:
+		split := ex.addNode(bpmnParallelGateway, step.ID, step.Name)
+		split.Documentation = step.Description
+		join := ex.addNode(bpmnParallelGateway, ex.uniqueID(step.ID+"_join"), "")
+		ex.joins[step.ID] = join.ID
+		for i := range step.ParallelTasks {
+			task := &step.ParallelTasks[i]
+			if err := ex.addTask(task, true); err != nil {
+				return err
+			}
+			ex.addFlow(split.ID, task.ID, "")
+			ex.addFlow(task.ID, join.ID, "")
+		}
+	case StepTypeExclusiveGateway, StepTypeInclusiveGateway, StepTypeParallelGateway:
+		// 网关步骤类型与 BPMN 网关元素同名
+		el := ex.addNode(step.Type, step.ID, step.Name)
+		el.Documentation = step.Description
+	default:
+		return ex.addTask(step, false)
+	}
+	return nil
+}
+
+// addTask 添加 userTask / serviceTask / callActivity，无法用 BPMN 属性表达的配置写入扩展元素 pm:config
+func (ex *bpmnExporter) addTask(step *StepDefinition, parallel bool) error {
+	if _, ok := ex.byID[step.ID]; ok || step.ID == "" {
+		return fmt.Errorf("duplicate or empty step id %q", step.ID)
+	}
+
+	kind := bpmnUserTask
+	switch step.Type {
+	case StepTypeProcess:
+		kind = bpmnServiceTask
+	case StepTypeSubProcess:
+		kind = bpmnCallActivity
+	}
+	el := ex.addNode(kind, step.ID, step.Name)
+	el.Documentation = step.Description
+
+	params := make(map[string]interface{}, len(step.Params))
+	for k, v := range step.Params {
+		params[k] = v
+	}
+	if kind == bpmnServiceTask {
+		if handler, ok := params["handler"].(string); ok && handler != "" {
+			el.Implementation = handler
+			delete(params, "handler")
+		}
+	} else if kind == bpmnUserTask {
+		if assignee, ok := params["assignee"].(string); ok && assignee != "" {
+			el.HumanPerformer = &bpmnPerformer{Expression: assignee}
+			delete(params, "assignee")
+		}
+	}
+
+	config := bpmnStepConfig{
+		Timeout:      step.Timeout,
+		OnTimeout:    step.OnTimeout,
+		Retries:      step.Retries,
+		RetryBackoff: step.RetryBackoff,
+		Reject:       step.Reject,
+	}
+	// 工作流名称写入 calledElement，指定版本或变量映射时完整配置保存在扩展元素中
+	if step.SubProcess != nil {
+		el.CalledElement = step.SubProcess.Workflow
+		if step.SubProcess.Version != 0 || len(step.SubProcess.Input) > 0 || len(step.SubProcess.Output) > 0 {
+			config.SubProcess = step.SubProcess
+		}
+	}
+	// 并行任务的条件无法放在并行网关的出口顺序流上
+	if parallel {
+		config.Condition = step.Condition
+	}
+	if len(params) > 0 {
+		config.Params = params
+	}
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(config); err != nil {
+		return fmt.Errorf("failed to encode config of step %s: %w", step.ID, err)
+	}
+	if text := strings.TrimSpace(data.String()); text != "{}" {
+		el.ExtensionElements = &bpmnExtensionElements{Inner: "<pm:config>" + bpmnTextEscaper.Replace(text) + "</pm:config>"}
+	}
+	return nil
+}
+
+// successors 与 FindNextStep 一致的后续步骤：有 nextSteps 时按其顺序，否则顺序执行并跳过带条件的步骤
+func (ex *bpmnExporter) successors(i int) []*StepDefinition {
+	steps := ex.definition.Steps
+	step := &steps[i]
+
+	var candidates []*StepDefinition
+	if len(step.NextSteps) > 0 {
+		for _, id := range step.NextSteps {
+			for j := range steps {
+				if steps[j].ID == id {
+					candidates = append(candidates, &steps[j])
+					break
+				}
+			}
+		}
+		return candidates
+	}
+	for j := i + 1; j < len(steps); j++ {
+		candidates = append(candidates, &steps[j])
+		if steps[j].Condition == "" {
+			break
+		}
+	}
+	return candidates
+}
+
+// addSuccessors 添加到后续步骤的顺序流
+// 只有一个无条件后续步骤时直接连接，否则经排他网关分支；所有条件都不满足时流程结束
+func (ex *bpmnExporter) addSuccessors(source, stepID string, candidates []*StepDefinition) {
+	if len(candidates) == 0 {
+		ex.addFlow(source, ex.endEvent(), "")
+		return
+	}
+	if len(candidates) == 1 && candidates[0].Condition == "" {
+		ex.addFlow(source, candidates[0].ID, "")
+		return
+	}
+
+	gateway := ex.addNode(bpmnExclusiveGateway, ex.uniqueID(stepID+"_gateway"), "")
+	ex.addFlow(source, gateway.ID, "")
+	for _, next := range candidates {
+		flow := ex.addFlow(gateway.ID, next.ID, next.Condition)
+		if next.Condition == "" {
+			// 无条件的后续步骤作为默认流，其后的步骤永远不会被选中
+			gateway.Default = flow.ID
+			return
+		}
+	}
+	gateway.Default = ex.addFlow(gateway.ID, ex.endEvent(), "").ID
+}
+
+// addGatewayFlows 网关步骤直接连接各分支，分支条件写在顺序流上（并行网关忽略条件）
+func (ex *bpmnExporter) addGatewayFlows(step *StepDefinition, branches []*StepDefinition) {
+	for _, next := range branches {
+		condition := next.Condition
+		if step.Type == StepTypeParallelGateway {
+			condition = ""
+		}
+		ex.addFlow(step.ID, next.ID, condition)
+	}
+}
+
+func (ex *bpmnExporter) addNode(kind, id, name string) *bpmnElement {
+	el := &bpmnElement{XMLName: xml.Name{Local: kind}, ID: id, Name: name}
+	ex.elements = append(ex.elements, el)
+	ex.byID[id] = el
+	return el
+}
+
+func (ex *bpmnExporter) addFlow(source, target, condition string) *bpmnElement {
+	ex.flowSeq++
+	flow := &bpmnElement{
+		XMLName:   xml.Name{Local: bpmnSequenceFlow},
+		ID:        ex.uniqueID(fmt.Sprintf("Flow_%d", ex.flowSeq)),
+		SourceRef: source,
+		TargetRef: target,
+	}
+	if condition != "" {
+		flow.ConditionExpression = &bpmnExpression{XsiType: "tFormalExpression", Body: "${" + condition + "}"}
+	}
+	ex.elements = append(ex.elements, flow)
+	ex.byID[flow.ID] = flow
+	ex.byID[source].Outgoing = append(ex.byID[source].Outgoing, flow.ID)
+	ex.byID[target].Incoming = append(ex.byID[target].Incoming, flow.ID)
+	return flow
+}
+
+// endEvent 条件均不满足或没有后续步骤时流向的结束事件
+func (ex *bpmnExporter) endEvent() string {
+	if ex.endEventID == "" {
+		ex.endEventID = ex.addNode(bpmnEndEvent, ex.uniqueID("EndEvent_1"), "").ID
+	}
+	return ex.endEventID
+}
+
+func (ex *bpmnExporter) uniqueID(base string) string {
+	id := base
+	for i := 1; ex.reserved[id] || ex.byID[id] != nil; i++ {
+		id = fmt.Sprintf("%s_%d", base, i)
+	}
+	return id
+}
+
+// layout 按从开始事件出发的层次排列节点，顺序流画成直线
+func (ex *bpmnExporter) layout(processID string) bpmnPlane {
+	plane := bpmnPlane{ID: "BPMNPlane_1", BpmnElement: processID}
+
+	column := make(map[string]int)
+	var nodes []*bpmnElement
+	for _, el := range ex.elements {
+		if el.XMLName.Local != bpmnSequenceFlow {
+			nodes = append(nodes, el)
+		}
+	}
+	queue := []string{nodes[0].ID}
+	column[nodes[0].ID] = 0
+	maxColumn := 0
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, flowID := range ex.byID[id].Outgoing {
+			target := ex.byID[flowID].TargetRef
+			if _, ok := column[target]; !ok {
+				column[target] = column[id] + 1
+				if column[target] > maxColumn {
+					maxColumn = column[target]
+				}
+				queue = append(queue, target)
+			}
+		}
+	}
+
+	rows := make(map[int]int)
+	bounds := make(map[string]bpmnBounds)
+	for _, el := range nodes {
+		col, ok := column[el.ID]
+		if !ok {
+			maxColumn++
+			col = maxColumn
+		}
+		width, height := bpmnShapeSize(el.XMLName.Local)
+		centerX := bpmnLayoutMargin + col*bpmnLayoutColumnWidth + 50
+		centerY := bpmnLayoutMargin + rows[col]*bpmnLayoutRowHeight + 40
+		rows[col]++
+		b := bpmnBounds{X: centerX - width/2, Y: centerY - height/2, Width: width, Height: height}
+		bounds[el.ID] = b
+		plane.Shapes = append(plane.Shapes, bpmnShape{ID: el.ID + "_di", BpmnElement: el.ID, Bounds: b})
+	}
+
+	for _, el := range ex.elements {
+		if el.XMLName.Local != bpmnSequenceFlow {
+			continue
+		}
+		from, to := bounds[el.SourceRef], bounds[el.TargetRef]
+		plane.Edges = append(plane.Edges, bpmnEdge{
+			ID:          el.ID + "_di",
+			BpmnElement: el.ID,
+			Waypoints: []bpmnWaypoint{
+				{X: from.X + from.Width, Y: from.Y + from.Height/2},
+				{X: to.X, Y: to.Y + to.Height/2},
+			},
+		})
+	}
+	return plane
+}
+
+func bpmnShapeSize(kind string) (int, int) {
+	switch {
+	case strings.HasSuffix(kind, "Event"):
+		return 36, 36
+	case strings.HasSuffix(kind, "Gateway"):
+		return 50, 50
+	default:
+		return 100, 80
+	}
+}
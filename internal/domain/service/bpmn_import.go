@@ -0,0 +1,600 @@
+package domain_service
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	command "jxt-evidence-system/process-management/internal/application/command"
+	"strings"
+	"unicode"
+)
+
+// bpmnStepConfig 扩展元素 pm:config 中保存的步骤配置（BPMN 标准属性无法表达的部分）
+type bpmnStepConfig struct {
+	Condition    string                 `json:"condition,omitempty"` // 并行任务的执行条件
+	Timeout      int                    `json:"timeout,omitempty"`
+	OnTimeout    *TimeoutPolicy         `json:"onTimeout,omitempty"`
+	Retries      int                    `json:"retries,omitempty"`
+	RetryBackoff *RetryPolicy           `json:"retryBackoff,omitempty"`
+	Reject       *RejectPolicy          `json:"reject,omitempty"`
+	SubProcess   *SubProcessConfig      `json:"subProcess,omitempty"` // callActivity 的版本和变量映射
+	Params       map[string]interface{} `json:"params,omitempty"`
+}
+
+// bpmnNode 导入时的流程节点
+type bpmnNode struct {
+	element  *bpmnElement
+	incoming []*bpmnFlow
+	outgoing []*bpmnFlow
+}
+
+func (n *bpmnNode) kind() string {
+	return n.element.XMLName.Local
+}
+
+// isSplit 并行网关有多个出口时为分支网关，否则视为汇聚网关
+func (n *bpmnNode) isSplit() bool {
+	return n.kind() == bpmnParallelGateway && len(n.outgoing) > 1
+}
+
+// bpmnFlow 顺序流
+type bpmnFlow struct {
+	element   *bpmnElement
+	condition string
+	isDefault bool
+}
+
+// bpmnTarget 解析网关后得到的目标步骤及其条件
+type bpmnTarget struct {
+	stepID    string
+	condition string
+}
+
+// bpmnImporter BPMN 到工作流定义的转换器
+// 任务和结束事件转换为步骤，排他网关展开为 nextSteps + 目标步骤条件，
+// 并行网关（分支到汇聚之间每条分支一个任务）转换为 parallel 步骤
+type bpmnImporter struct {
+	nodes       map[string]*bpmnNode
+	nodeOrder   []string
+	steps       map[string]*StepDefinition
+	stepOrder   []string
+	branchOf    map[string]string // 并行分支任务ID -> 所属并行步骤ID
+	joinOf      map[string]string // 并行步骤ID -> 汇聚网关ID
+	conditioned map[string]bool   // 已确定条件的步骤
+	endStepID   string            // 隐式结束时自动补充的结束步骤
+	unsupported []command.BpmnUnsupportedElement
+}
+
+// ImportBpmn 将 BPMN 2.0 XML 转换为工作流定义，返回无法转换或被忽略的元素
+func (s *WorkflowDomainService) ImportBpmn(data []byte) (*WorkflowDefinitionStruct, []command.BpmnUnsupportedElement, error) {
+	var doc bpmnDefinitions
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse BPMN XML: %w", err)
+	}
+	if len(doc.Processes) == 0 {
+		return nil, nil, fmt.Errorf("BPMN document contains no process")
+	}
+
+	im := &bpmnImporter{
+		nodes:       make(map[string]*bpmnNode),
+		steps:       make(map[string]*StepDefinition),
+		branchOf:    make(map[string]string),
+		joinOf:      make(map[string]string),
+		conditioned: make(map[string]bool),
+		unsupported: []command.BpmnUnsupportedElement{},
+	}
+	for _, other := range doc.Others {
+		// 图形信息不影响流程语义，直接忽略
+		if other.XMLName.Space == BpmnDINamespace {
+			continue
+		}
+		im.report(other.ID, other.XMLName.Local, other.Name, "only process elements are imported")
+	}
+	for _, process := range doc.Processes[1:] {
+		im.report(process.ID, "process", process.Name, "only the first process is imported")
+	}
+
+	process := doc.Processes[0]
+	definition, err := im.convert(&process)
+	if err != nil {
+		return nil, nil, err
+	}
+	return definition, im.unsupported, nil
+}
+
+func (im *bpmnImporter) report(id, elementType, name, reason string) {
+	im.unsupported = append(im.unsupported, command.BpmnUnsupportedElement{
+		ElementID:   id,
+		ElementType: elementType,
+		Name:        name,
+		Reason:      reason,
+	})
+}
+
+func (im *bpmnImporter) convert(process *bpmnProcess) (*WorkflowDefinitionStruct, error) {
+	flows := im.collectElements(process)
+	im.linkFlows(flows)
+
+	var start *bpmnNode
+	for _, id := range im.nodeOrder {
+		node := im.nodes[id]
+		if node.kind() != bpmnStartEvent {
+			continue
+		}
+		if start != nil {
+			im.report(id, bpmnStartEvent, node.element.Name, "only one start event is supported, ignored")
+			continue
+		}
+		start = node
+	}
+	if start == nil {
+		return nil, fmt.Errorf("process %s has no start event", process.ID)
+	}
+
+	im.buildParallelSteps()
+	im.buildSteps()
+
+	// 步骤的 nextSteps 及目标步骤条件
+	for _, id := range im.stepOrder {
+		step := im.steps[id]
+		if step.Type == StepTypeComplete || im.branchOf[id] != "" {
+			continue
+		}
+		source := im.nodes[id]
+		if join, ok := im.joinOf[id]; ok {
+			source = im.nodes[join]
+		}
+		targets := im.resolveTargets(source.outgoing, "", map[string]bool{})
+		im.checkImplicitFork(source, targets)
+		added := make(map[string]bool)
+		for _, target := range targets {
+			im.applyCondition(target)
+			if !added[target.stepID] {
+				added[target.stepID] = true
+				step.NextSteps = append(step.NextSteps, target.stepID)
+			}
+		}
+		// BPMN 允许无出口的任务隐式结束，工作流定义中需要显式的结束步骤，否则会按顺序执行到下一步
+		if len(step.NextSteps) == 0 {
+			step.NextSteps = []string{im.ensureEndStep()}
+		}
+	}
+
+	first := im.resolveTargets(start.outgoing, "", map[string]bool{})
+	if len(first) == 0 {
+		return nil, fmt.Errorf("start event %s has no outgoing sequence flow", start.element.ID)
+	}
+	if len(first) > 1 || first[0].condition != "" {
+		im.report(start.element.ID, bpmnStartEvent, start.element.Name, fmt.Sprintf("conditional or multiple start flows are not supported, %s is used as the first step", first[0].stepID))
+	}
+
+	return &WorkflowDefinitionStruct{
+		Name:        firstNonEmpty(process.Name, process.ID),
+		Description: strings.TrimSpace(process.Documentation),
+		Steps:       im.orderedSteps(first[0].stepID),
+	}, nil
+}
+
+// collectElements 收集节点和顺序流，其他元素记入不支持列表
+func (im *bpmnImporter) collectElements(process *bpmnProcess) []*bpmnFlow {
+	var flows []*bpmnFlow
+	for i := range process.Elements {
+		el := &process.Elements[i]
+		kind := el.XMLName.Local
+		switch kind {
+		case bpmnSequenceFlow:
+			flow := &bpmnFlow{element: el}
+			if el.ConditionExpression != nil {
+				flow.condition = im.normalizeCondition(el, el.ConditionExpression.Body)
+			}
+			flows = append(flows, flow)
+		case bpmnStartEvent, bpmnEndEvent, bpmnUserTask, bpmnServiceTask, bpmnCallActivity, bpmnExclusiveGateway, bpmnParallelGateway:
+			if el.ID == "" {
+				im.report("", kind, el.Name, "element has no id, ignored")
+				continue
+			}
+			if _, ok := im.nodes[el.ID]; ok {
+				im.report(el.ID, kind, el.Name, "duplicate element id, ignored")
+				continue
+			}
+			im.nodes[el.ID] = &bpmnNode{element: el}
+			im.nodeOrder = append(im.nodeOrder, el.ID)
+			for _, child := range el.Children {
+				im.report(firstNonEmpty(child.ID, el.ID), child.XMLName.Local, el.Name, fmt.Sprintf("not supported on %s %s, ignored", kind, el.ID))
+			}
+		default:
+			im.report(el.ID, kind, el.Name, "unsupported element type, ignored")
+		}
+	}
+	return flows
+}
+
+// normalizeCondition 转换 ${...} / #{...} 包裹的条件，并检查表达式能否被条件求值器解析
+// 包裹内已使用 ${变量} 引用的表达式（本系统导出的条件）原样保留，
+// 否则按 JUEL 表达式处理：裸标识符是变量，改写为 ${变量} 引用
+func (im *bpmnImporter) normalizeCondition(el *bpmnElement, body string) string {
+	condition := strings.TrimSpace(body)
+	if (strings.HasPrefix(condition, "${") || strings.HasPrefix(condition, "#{")) && strings.HasSuffix(condition, "}") {
+		condition = strings.TrimSpace(condition[2 : len(condition)-1])
+		if !strings.Contains(condition, "${") {
+			condition = juelToCondition(condition)
+		}
+	}
+	if err := ValidateCondition(condition); err != nil {
+		im.report(el.ID, "conditionExpression", el.Name, fmt.Sprintf("condition %q is not supported: %v", condition, err))
+	}
+	return condition
+}
+
+// juelOperators JUEL 的文字运算符
+var juelOperators = map[string]string{
+	"and": "&&",
+	"or":  "||",
+	"not": "!",
+	"eq":  "==",
+	"ne":  "!=",
+	"gt":  ">",
+	"ge":  ">=",
+	"lt":  "<",
+	"le":  "<=",
+}
+
+// juelToCondition 将 JUEL 表达式改写为条件表达式
+// 标识符路径（如 days、input.days、files[0].name）改写为 ${path}，文字运算符改写为符号，
+// 字符串、数字、true/false/null/in 和函数名保持不变
+func juelToCondition(expr string) string {
+	src := []rune(expr)
+	var b strings.Builder
+	for i := 0; i < len(src); {
+		ch := src[i]
+		switch {
+		case ch == '"' || ch == '\'':
+			start := i
+			for i++; i < len(src) && src[i] != ch; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			if i < len(src) {
+				i++
+			}
+			b.WriteString(string(src[start:min(i, len(src))]))
+		case unicode.IsDigit(ch):
+			start := i
+			for i < len(src) && (unicode.IsDigit(src[i]) || unicode.IsLetter(src[i]) || src[i] == '.' ||
+				((src[i] == '-' || src[i] == ':') && i+1 < len(src) && unicode.IsDigit(src[i+1]))) {
+				i++
+			}
+			b.WriteString(string(src[start:i]))
+		case unicode.IsLetter(ch) || ch == '_':
+			start := i
+			for i < len(src) {
+				if r := src[i]; unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' {
+					i++
+					continue
+				}
+				if src[i] == '[' {
+					if end := indexRune(src[i:], ']'); end > 0 {
+						i += end + 1
+						continue
+					}
+				}
+				break
+			}
+			word := string(src[start:i])
+			next := i
+			for next < len(src) && unicode.IsSpace(src[next]) {
+				next++
+			}
+			switch {
+			case next < len(src) && src[next] == '(':
+				b.WriteString(word)
+			case word == "true" || word == "false" || word == "null" || word == "in":
+				b.WriteString(word)
+			case juelOperators[word] != "":
+				b.WriteString(juelOperators[word])
+			default:
+				b.WriteString("${" + word + "}")
+			}
+		default:
+			b.WriteRune(ch)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// indexRune 返回 r 在 src 中的下标，不存在时返回 -1
+func indexRune(src []rune, r rune) int {
+	for i, ch := range src {
+		if ch == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// linkFlows 建立节点间的连接，连接不存在或不支持的元素的顺序流被丢弃
+func (im *bpmnImporter) linkFlows(flows []*bpmnFlow) {
+	for _, flow := range flows {
+		el := flow.element
+		source, ok := im.nodes[el.SourceRef]
+		if !ok {
+			im.report(el.ID, bpmnSequenceFlow, el.Name, fmt.Sprintf("source %s is not a supported element, ignored", el.SourceRef))
+			continue
+		}
+		target, ok := im.nodes[el.TargetRef]
+		if !ok {
+			im.report(el.ID, bpmnSequenceFlow, el.Name, fmt.Sprintf("target %s is not a supported element, ignored", el.TargetRef))
+			continue
+		}
+		flow.isDefault = source.element.Default != "" && source.element.Default == el.ID
+		source.outgoing = append(source.outgoing, flow)
+		target.incoming = append(target.incoming, flow)
+	}
+
+	// 默认流放在最后：工作流按 nextSteps 顺序取第一个满足条件的步骤
+	for _, node := range im.nodes {
+		for i, flow := range node.outgoing {
+			if flow.isDefault {
+				node.outgoing = append(append(node.outgoing[:i:i], node.outgoing[i+1:]...), flow)
+				break
+			}
+		}
+	}
+}
+
+// buildParallelSteps 将并行分支网关转换为 parallel 步骤
+// 仅支持"分支网关 -> 每条分支一个任务 -> 同一个汇聚网关"的结构
+func (im *bpmnImporter) buildParallelSteps() {
+	for _, id := range im.nodeOrder {
+		split := im.nodes[id]
+		if !split.isSplit() {
+			continue
+		}
+
+		step := &StepDefinition{ID: id, Type: StepTypeParallel, Name: split.element.Name, Description: strings.TrimSpace(split.element.Documentation)}
+		join := ""
+		for _, flow := range split.outgoing {
+			branch := im.nodes[flow.element.TargetRef]
+			next := ""
+			if len(branch.outgoing) == 1 {
+				next = branch.outgoing[0].element.TargetRef
+			}
+			isTask := branch.kind() == bpmnUserTask || branch.kind() == bpmnServiceTask
+			if !isTask || len(branch.incoming) != 1 || next == "" || im.nodes[next].kind() != bpmnParallelGateway || (join != "" && next != join) {
+				im.report(id, bpmnParallelGateway, split.element.Name, fmt.Sprintf("parallel branch via %s must be a single task leading to a common join gateway, branch is imported as a regular step", flow.element.ID))
+				continue
+			}
+			join = next
+
+			task := im.newTaskStep(branch.element)
+			if flow.condition != "" {
+				task.Condition = flow.condition
+			}
+			step.ParallelTasks = append(step.ParallelTasks, *task)
+			im.branchOf[branch.element.ID] = id
+		}
+		if join != "" {
+			im.joinOf[id] = join
+		}
+		im.steps[id] = step
+	}
+}
+
+// buildSteps 将任务和结束事件转换为步骤（按文档顺序）
+func (im *bpmnImporter) buildSteps() {
+	for _, id := range im.nodeOrder {
+		node := im.nodes[id]
+		switch node.kind() {
+		case bpmnUserTask, bpmnServiceTask, bpmnCallActivity:
+			if im.branchOf[id] != "" {
+				continue
+			}
+			im.steps[id] = im.newTaskStep(node.element)
+		case bpmnEndEvent:
+			im.steps[id] = &StepDefinition{ID: id, Type: StepTypeComplete, Name: node.element.Name, Description: strings.TrimSpace(node.element.Documentation)}
+		case bpmnParallelGateway:
+			if !node.isSplit() {
+				continue
+			}
+		default:
+			continue
+		}
+		im.stepOrder = append(im.stepOrder, id)
+	}
+}
+
+// newTaskStep 将 userTask / serviceTask / callActivity 转换为步骤
+func (im *bpmnImporter) newTaskStep(el *bpmnElement) *StepDefinition {
+	step := &StepDefinition{ID: el.ID, Name: el.Name, Description: strings.TrimSpace(el.Documentation)}
+	config := im.stepConfig(el)
+	step.Condition = config.Condition
+	step.Timeout = config.Timeout
+	step.OnTimeout = config.OnTimeout
+	step.Retries = config.Retries
+	step.RetryBackoff = config.RetryBackoff
+	step.Reject = config.Reject
+	step.SubProcess = config.SubProcess
+	step.Params = config.Params
+	setParam := func(key, value string) {
+		if step.Params == nil {
+			step.Params = make(map[string]interface{})
+		}
+		step.Params[key] = value
+	}
+
+	if el.XMLName.Local == bpmnCallActivity {
+		step.Type = StepTypeSubProcess
+		if el.CalledElement != "" {
+			if step.SubProcess == nil {
+				step.SubProcess = &SubProcessConfig{}
+			}
+			step.SubProcess.Workflow = el.CalledElement
+		}
+		return step
+	}
+	if el.XMLName.Local == bpmnServiceTask {
+		step.Type = StepTypeProcess
+		// ##WebService、##unspecified 等为 BPMN 预定义取值，不对应处理器
+		if el.Implementation != "" && !strings.HasPrefix(el.Implementation, "##") {
+			setParam("handler", el.Implementation)
+		}
+		return step
+	}
+
+	step.Type = StepTypeUserTask
+	assignee := el.Assignee
+	for _, performer := range []*bpmnPerformer{el.HumanPerformer, el.PotentialOwner} {
+		if assignee == "" && performer != nil {
+			assignee = strings.TrimSpace(performer.Expression)
+		}
+	}
+	if assignee != "" {
+		setParam("assignee", assignee)
+	}
+	return step
+}
+
+// stepConfig 读取扩展元素 pm:config
+func (im *bpmnImporter) stepConfig(el *bpmnElement) bpmnStepConfig {
+	var config bpmnStepConfig
+	if el.ExtensionElements == nil {
+		return config
+	}
+	for _, item := range el.ExtensionElements.Items {
+		if item.XMLName.Space != BpmnExtensionNamespace || item.XMLName.Local != "config" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(item.Value), &config); err != nil {
+			im.report(el.ID, "extensionElements", el.Name, fmt.Sprintf("invalid step config: %v", err))
+			return bpmnStepConfig{}
+		}
+	}
+	return config
+}
+
+// resolveTargets 沿顺序流穿过排他网关找到目标步骤，网关前后的条件以 && 合并
+func (im *bpmnImporter) resolveTargets(flows []*bpmnFlow, prefix string, visited map[string]bool) []bpmnTarget {
+	var targets []bpmnTarget
+	for _, flow := range flows {
+		condition := joinConditions(prefix, flow.condition)
+		node := im.nodes[flow.element.TargetRef]
+		id := node.element.ID
+
+		switch {
+		case node.kind() == bpmnStartEvent:
+			im.report(flow.element.ID, bpmnSequenceFlow, flow.element.Name, "sequence flow into a start event is not supported, ignored")
+		case node.kind() == bpmnExclusiveGateway || (node.kind() == bpmnParallelGateway && !node.isSplit()):
+			// 排他网关和单出口的并行网关（汇聚）直接穿过
+			if visited[id] {
+				im.report(id, node.kind(), node.element.Name, "gateway cycle without tasks is not supported")
+				continue
+			}
+			visited[id] = true
+			targets = append(targets, im.resolveTargets(node.outgoing, condition, visited)...)
+			delete(visited, id)
+		default:
+			if owner := im.branchOf[id]; owner != "" {
+				id = owner
+			}
+			targets = append(targets, bpmnTarget{stepID: id, condition: condition})
+		}
+	}
+	return targets
+}
+
+// checkImplicitFork 任务有多个无条件出口时 BPMN 语义为并行，这里只能按排他处理
+func (im *bpmnImporter) checkImplicitFork(source *bpmnNode, targets []bpmnTarget) {
+	if source.kind() == bpmnExclusiveGateway || source.kind() == bpmnParallelGateway {
+		return
+	}
+	unconditional := 0
+	for _, flow := range source.outgoing {
+		if flow.condition == "" {
+			unconditional++
+		}
+	}
+	if unconditional > 1 {
+		im.report(source.element.ID, source.kind(), source.element.Name, "multiple unconditional outgoing flows (implicit parallel split) are not supported, the first one is taken")
+	}
+}
+
+// applyCondition 将顺序流条件写入目标步骤，同一步骤的多个入口条件不一致时以第一个为准
+func (im *bpmnImporter) applyCondition(target bpmnTarget) {
+	step := im.steps[target.stepID]
+	if step == nil {
+		return
+	}
+	if !im.conditioned[target.stepID] {
+		im.conditioned[target.stepID] = true
+		if target.condition != "" {
+			step.Condition = target.condition
+		}
+		return
+	}
+	if step.Condition != target.condition {
+		im.report(step.ID, step.Type, step.Name, fmt.Sprintf("step is reached by sequence flows with different conditions, condition %q is used", step.Condition))
+	}
+}
+
+// ensureEndStep 返回自动补充的结束步骤ID
+func (im *bpmnImporter) ensureEndStep() string {
+	if im.endStepID != "" {
+		return im.endStepID
+	}
+	id := "end"
+	for i := 1; im.nodes[id] != nil || im.steps[id] != nil; i++ {
+		id = fmt.Sprintf("end_%d", i)
+	}
+	im.steps[id] = &StepDefinition{ID: id, Type: StepTypeComplete, Name: "结束"}
+	im.stepOrder = append(im.stepOrder, id)
+	im.endStepID = id
+	return id
+}
+
+// orderedSteps 第一步放在首位，其余按从第一步出发的广度优先顺序排列，不可达步骤保留在末尾
+func (im *bpmnImporter) orderedSteps(firstID string) []StepDefinition {
+	var ordered []StepDefinition
+	added := make(map[string]bool)
+	queue := []string{firstID}
+	added[firstID] = true
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		step := im.steps[id]
+		ordered = append(ordered, *step)
+		for _, next := range step.NextSteps {
+			if !added[next] && im.steps[next] != nil {
+				added[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	for _, id := range im.stepOrder {
+		if !added[id] && im.branchOf[id] == "" {
+			ordered = append(ordered, *im.steps[id])
+		}
+	}
+	return ordered
+}
+
+func joinConditions(left, right string) string {
+	switch {
+	case left == "":
+		return right
+	case right == "":
+		return left
+	default:
+		return fmt.Sprintf("(%s) && (%s)", left, right)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package domain_service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+)
+
+const leaveBpmn = `<?xml version="1.0" encoding="UTF-8"?>
+<bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" id="Definitions_1">
+  <bpmn:process id="leave" name="请假" isExecutable="true">
+    <bpmn:startEvent id="start"/>
+    <bpmn:userTask id="apply" name="申请"/>
+    <bpmn:exclusiveGateway id="gateway" default="toHr"/>
+    <bpmn:userTask id="manager" name="经理审批"/>
+    <bpmn:userTask id="hr" name="人事审批"/>
+    <bpmn:endEvent id="end"/>
+    <bpmn:sequenceFlow id="f1" sourceRef="start" targetRef="apply"/>
+    <bpmn:sequenceFlow id="f2" sourceRef="apply" targetRef="gateway"/>
+    <bpmn:sequenceFlow id="toManager" sourceRef="gateway" targetRef="manager">
+      <bpmn:conditionExpression xsi:type="bpmn:tFormalExpression">${days &gt; 3 &amp;&amp; input.type == 'annual'}</bpmn:conditionExpression>
+    </bpmn:sequenceFlow>
+    <bpmn:sequenceFlow id="toHr" sourceRef="gateway" targetRef="hr"/>
+    <bpmn:sequenceFlow id="f3" sourceRef="manager" targetRef="end"/>
+    <bpmn:sequenceFlow id="f4" sourceRef="hr" targetRef="end"/>
+  </bpmn:process>
+</bpmn:definitions>`
+
+func TestImportBpmnConditionEvaluatesAgainstInput(t *testing.T) {
+	s := NewWorkflowDomainService(nil)
+	definition, unsupported, err := s.ImportBpmn([]byte(leaveBpmn))
+	if err != nil {
+		t.Fatalf("ImportBpmn() error = %v", err)
+	}
+	if len(unsupported) != 0 {
+		t.Fatalf("ImportBpmn() unsupported = %+v, want none", unsupported)
+	}
+	if got, want := s.FindStepByID("manager", definition).Condition, `${days} > 3 && ${input.type} == 'annual'`; got != want {
+		t.Fatalf("manager condition = %q, want %q", got, want)
+	}
+
+	apply := s.FindStepByID("apply", definition)
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"days": 5, "type": "annual"}`, "manager"},
+		{`{"days": 2, "type": "annual"}`, "hr"},
+		{`{"days": 5, "type": "sick"}`, "hr"},
+	}
+	for _, tt := range tests {
+		instance := &instance_aggregate.WorkflowInstance{Input: json.RawMessage(tt.input)}
+		next := s.FindNextStep(context.Background(), apply, definition, instance)
+		if next == nil || next.ID != tt.want {
+			t.Errorf("FindNextStep(%s) = %v, want %s", tt.input, next, tt.want)
+		}
+	}
+}
+
+func TestImportBpmnKeepsExportedConditions(t *testing.T) {
+	s := NewWorkflowDomainService(nil)
+	definition, _, err := s.ImportBpmn([]byte(leaveBpmn))
+	if err != nil {
+		t.Fatalf("ImportBpmn() error = %v", err)
+	}
+	exported, err := s.ExportBpmn("leave", definition)
+	if err != nil {
+		t.Fatalf("ExportBpmn() error = %v", err)
+	}
+	reimported, _, err := s.ImportBpmn(exported)
+	if err != nil {
+		t.Fatalf("ImportBpmn(exported) error = %v", err)
+	}
+	want := s.FindStepByID("manager", definition).Condition
+	if got := s.FindStepByID("manager", reimported).Condition; got != want {
+		t.Errorf("round trip condition = %q, want %q", got, want)
+	}
+}
+
+func TestJuelToCondition(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"days > 3", "${days} > 3"},
+		{"input.days >= 3", "${input.days} >= 3"},
+		{"files[0].name == 'a.pdf'", "${files[0].name} == 'a.pdf'"},
+		{"approved == true and level ne \"A\"", "${approved} == true && ${level} != \"A\""},
+		{"not approved", "! ${approved}"},
+		{"len(files) gt 2", "len(${files}) > 2"},
+		{"level in ['A', 'B']", "${level} in ['A', 'B']"},
+		{"startDate < 2024-01-01", "${startDate} < 2024-01-01"},
+	}
+	for _, tt := range tests {
+		if got := juelToCondition(tt.expr); got != tt.want {
+			t.Errorf("juelToCondition(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
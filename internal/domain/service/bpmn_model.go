@@ -0,0 +1,137 @@
+package domain_service
+
+import "encoding/xml"
+
+// BPMN 2.0 命名空间
+const (
+	BpmnModelNamespace = "http://www.omg.org/spec/BPMN/20100524/MODEL"
+	BpmnDINamespace    = "http://www.omg.org/spec/BPMN/20100524/DI"
+	BpmnDCNamespace    = "http://www.omg.org/spec/DD/20100524/DC"
+	BpmnDDINamespace   = "http://www.omg.org/spec/DD/20100524/DI"
+	BpmnXSINamespace   = "http://www.w3.org/2001/XMLSchema-instance"
+
+	// BpmnExtensionNamespace 本系统扩展元素命名空间，用于保存 BPMN 无法表达的步骤配置
+	BpmnExtensionNamespace = "http://jxt-evidence-system/schema/process-management/bpmn"
+)
+
+// BPMN 元素类型（本地名）
+const (
+	bpmnStartEvent       = "startEvent"
+	bpmnEndEvent         = "endEvent"
+	bpmnUserTask         = "userTask"
+	bpmnServiceTask      = "serviceTask"
+	bpmnCallActivity     = "callActivity"
+	bpmnExclusiveGateway = "exclusiveGateway"
+	bpmnParallelGateway  = "parallelGateway"
+	bpmnSequenceFlow     = "sequenceFlow"
+)
+
+// bpmnDefinitions BPMN 文档根元素
+// 导入时子元素不限定命名空间（兼容 bpmn:、bpmn2: 等不同前缀），导出时使用默认命名空间
+type bpmnDefinitions struct {
+	XMLName         xml.Name       `xml:"http://www.omg.org/spec/BPMN/20100524/MODEL definitions"`
+	XmlnsBpmndi     string         `xml:"xmlns:bpmndi,attr,omitempty"`
+	XmlnsDC         string         `xml:"xmlns:dc,attr,omitempty"`
+	XmlnsDI         string         `xml:"xmlns:di,attr,omitempty"`
+	XmlnsXSI        string         `xml:"xmlns:xsi,attr,omitempty"`
+	XmlnsPM         string         `xml:"xmlns:pm,attr,omitempty"`
+	ID              string         `xml:"id,attr,omitempty"`
+	TargetNamespace string         `xml:"targetNamespace,attr,omitempty"`
+	Processes       []bpmnProcess  `xml:"process"`
+	Diagrams        []bpmnDiagram  `xml:"bpmndi:BPMNDiagram"`
+	Others          []bpmnAnyChild `xml:",any"`
+}
+
+// bpmnProcess 流程
+type bpmnProcess struct {
+	ID            string        `xml:"id,attr"`
+	Name          string        `xml:"name,attr,omitempty"`
+	IsExecutable  string        `xml:"isExecutable,attr,omitempty"`
+	Documentation string        `xml:"documentation,omitempty"`
+	Elements      []bpmnElement `xml:",any"`
+}
+
+// bpmnElement 流程内的元素（事件、任务、网关、顺序流等），按 XMLName 区分类型
+// 字段顺序与 BPMN XSD 一致，保证导出结果可被建模工具校验通过
+type bpmnElement struct {
+	XMLName             xml.Name
+	ID                  string                 `xml:"id,attr"`
+	Name                string                 `xml:"name,attr,omitempty"`
+	SourceRef           string                 `xml:"sourceRef,attr,omitempty"`
+	TargetRef           string                 `xml:"targetRef,attr,omitempty"`
+	Default             string                 `xml:"default,attr,omitempty"`
+	Implementation      string                 `xml:"implementation,attr,omitempty"` // serviceTask 处理器名称
+	CalledElement       string                 `xml:"calledElement,attr,omitempty"`  // callActivity 引用的工作流名称
+	Assignee            string                 `xml:"assignee,attr,omitempty"`       // camunda:assignee、flowable:assignee 等扩展属性
+	Documentation       string                 `xml:"documentation,omitempty"`
+	ExtensionElements   *bpmnExtensionElements `xml:"extensionElements"`
+	Incoming            []string               `xml:"incoming"`
+	Outgoing            []string               `xml:"outgoing"`
+	HumanPerformer      *bpmnPerformer         `xml:"humanPerformer"`
+	PotentialOwner      *bpmnPerformer         `xml:"potentialOwner"`
+	ConditionExpression *bpmnExpression        `xml:"conditionExpression"`
+	Children            []bpmnAnyChild         `xml:",any"` // 其他子元素，如事件定义、多实例配置
+}
+
+// bpmnExpression 条件表达式
+type bpmnExpression struct {
+	XsiType string `xml:"xsi:type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// bpmnPerformer humanPerformer / potentialOwner
+type bpmnPerformer struct {
+	Expression string `xml:"resourceAssignmentExpression>formalExpression"`
+}
+
+// bpmnExtensionElements 扩展元素，导入时解析 Items，导出时直接写入 Inner
+type bpmnExtensionElements struct {
+	Items []bpmnAnyChild `xml:",any"`
+	Inner string         `xml:",innerxml"`
+}
+
+// bpmnAnyChild 未识别的子元素
+type bpmnAnyChild struct {
+	XMLName xml.Name
+	ID      string `xml:"id,attr"`
+	Name    string `xml:"name,attr"`
+	Value   string `xml:",chardata"`
+}
+
+// ===== 图形信息（仅导出） =====
+
+type bpmnDiagram struct {
+	ID    string    `xml:"id,attr"`
+	Plane bpmnPlane `xml:"bpmndi:BPMNPlane"`
+}
+
+type bpmnPlane struct {
+	ID          string      `xml:"id,attr"`
+	BpmnElement string      `xml:"bpmnElement,attr"`
+	Shapes      []bpmnShape `xml:"bpmndi:BPMNShape"`
+	Edges       []bpmnEdge  `xml:"bpmndi:BPMNEdge"`
+}
+
+type bpmnShape struct {
+	ID          string     `xml:"id,attr"`
+	BpmnElement string     `xml:"bpmnElement,attr"`
+	Bounds      bpmnBounds `xml:"dc:Bounds"`
+}
+
+type bpmnBounds struct {
+	X      int `xml:"x,attr"`
+	Y      int `xml:"y,attr"`
+	Width  int `xml:"width,attr"`
+	Height int `xml:"height,attr"`
+}
+
+type bpmnEdge struct {
+	ID          string         `xml:"id,attr"`
+	BpmnElement string         `xml:"bpmnElement,attr"`
+	Waypoints   []bpmnWaypoint `xml:"di:waypoint"`
+}
+
+type bpmnWaypoint struct {
+	X int `xml:"x,attr"`
+	Y int `xml:"y,attr"`
+}
@@ -0,0 +1,146 @@
+package domain_service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_repository "jxt-evidence-system/process-management/internal/domain/aggregate/task/repository"
+)
+
+// ConditionCache 已编译条件表达式缓存，按工作流ID分组
+// 工作流激活时编译全部条件，求值时直接复用语法树
+type ConditionCache struct {
+	mu       sync.RWMutex
+	compiled map[string]map[string]conditionNode
+}
+
+// NewConditionCache 创建条件表达式缓存
+func NewConditionCache() *ConditionCache {
+	return &ConditionCache{
+		compiled: make(map[string]map[string]conditionNode),
+	}
+}
+
+// Compile 编译工作流定义中的全部条件表达式，全部成功后替换该工作流的缓存
+func (c *ConditionCache) Compile(workflowID string, definition *WorkflowDefinitionStruct) error {
+	compiled := make(map[string]conditionNode)
+	if err := compileStepConditions(definition.Steps, compiled); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.compiled[workflowID] = compiled
+	c.mu.Unlock()
+	return nil
+}
+
+func compileStepConditions(steps []StepDefinition, compiled map[string]conditionNode) error {
+	for _, step := range steps {
+		if step.Condition != "" {
+			if _, ok := compiled[step.Condition]; !ok {
+				node, err := compileCondition(step.Condition)
+				if err != nil {
+					return fmt.Errorf("invalid condition in step %s: %w", step.ID, err)
+				}
+				compiled[step.Condition] = node
+			}
+		}
+		if err := compileStepConditions(step.ParallelTasks, compiled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get 获取已编译的条件表达式，未命中时（如服务重启后）编译并加入缓存
+func (c *ConditionCache) Get(workflowID string, condition string) (conditionNode, error) {
+	c.mu.RLock()
+	node, ok := c.compiled[workflowID][condition]
+	c.mu.RUnlock()
+	if ok {
+		return node, nil
+	}
+
+	node, err := compileCondition(condition)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.compiled[workflowID] == nil {
+		c.compiled[workflowID] = make(map[string]conditionNode)
+	}
+	c.compiled[workflowID][condition] = node
+	c.mu.Unlock()
+	return node, nil
+}
+
+// Invalidate 清除工作流的条件缓存（定义修改或删除时调用）
+func (c *ConditionCache) Invalidate(workflowID string) {
+	c.mu.Lock()
+	delete(c.compiled, workflowID)
+	c.mu.Unlock()
+}
+
+// VariableSnapshot 条件求值使用的变量快照
+// 一次分支决策内共享：实例输入只解析一次，步骤输出在首次引用时查询一次
+type VariableSnapshot struct {
+	ctx      context.Context
+	instance *instance_aggregate.WorkflowInstance
+	taskRepo task_repository.TaskRepository
+
+	input    map[string]interface{}
+	inputErr error
+	inputOK  bool
+
+	stepOutputs    map[string]map[string]interface{}
+	stepOutputsErr error
+	stepOutputsOK  bool
+}
+
+// NewVariableSnapshot 创建变量快照
+// ctx 用于查询步骤输出，在事务中创建时可以读到事务内尚未提交的输出
+func NewVariableSnapshot(ctx context.Context, instance *instance_aggregate.WorkflowInstance, taskRepo task_repository.TaskRepository) *VariableSnapshot {
+	return &VariableSnapshot{
+		ctx:      ctx,
+		instance: instance,
+		taskRepo: taskRepo,
+	}
+}
+
+// Input 实例输入
+func (v *VariableSnapshot) Input() (map[string]interface{}, error) {
+	if !v.inputOK {
+		v.input, v.inputErr = parseInstanceInput(v.instance.Input)
+		if v.inputErr != nil {
+			v.inputErr = fmt.Errorf("failed to parse instance input: %v", v.inputErr)
+		}
+		v.inputOK = true
+	}
+	return v.input, v.inputErr
+}
+
+// Initiator 实例发起人
+func (v *VariableSnapshot) Initiator() int {
+	return v.instance.InitiatorID
+}
+
+// StepOutputs 各步骤的输出，按步骤ID索引
+func (v *VariableSnapshot) StepOutputs() (map[string]map[string]interface{}, error) {
+	if !v.stepOutputsOK {
+		if v.taskRepo == nil {
+			v.stepOutputsErr = fmt.Errorf("task repository not configured")
+		} else {
+			tasks, err := v.taskRepo.FindByInstanceID(v.ctx, v.instance.InstanceId)
+			if err != nil {
+				v.stepOutputsErr = fmt.Errorf("failed to find tasks: %v", err)
+			} else {
+				v.stepOutputs = buildStepOutputs(tasks)
+			}
+		}
+		v.stepOutputsOK = true
+	}
+	return v.stepOutputs, v.stepOutputsErr
+}
@@ -0,0 +1,154 @@
+package domain_service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	task_repository "jxt-evidence-system/process-management/internal/domain/aggregate/task/repository"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// countingTaskRepo 只实现 FindByInstanceID，并记录调用次数
+type countingTaskRepo struct {
+	task_repository.TaskRepository
+	tasks []*task_aggregate.Task
+	calls int
+	ctx   context.Context
+}
+
+func (r *countingTaskRepo) FindByInstanceID(ctx context.Context, instanceID valueobject.InstanceID) ([]*task_aggregate.Task, error) {
+	r.calls++
+	r.ctx = ctx
+	return r.tasks, nil
+}
+
+func TestConditionCacheCompilesAllSteps(t *testing.T) {
+	cache := NewConditionCache()
+	definition := &WorkflowDefinitionStruct{Steps: []StepDefinition{
+		{ID: "a", Condition: "${days} > 3"},
+		{ID: "b", Condition: "${days} > 3"},
+		{ID: "p", ParallelTasks: []StepDefinition{{ID: "p1", Condition: "${level} in ['A']"}}},
+	}}
+	if err := cache.Compile("wf", definition); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if got := len(cache.compiled["wf"]); got != 2 {
+		t.Fatalf("compiled conditions = %d, want 2", got)
+	}
+
+	compiled := cache.compiled["wf"]["${level} in ['A']"]
+	node, err := cache.Get("wf", "${level} in ['A']")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if node != compiled {
+		t.Errorf("Get() returned a new node, want the compiled one")
+	}
+}
+
+func TestConditionCacheCompileErrorKeepsPreviousEntries(t *testing.T) {
+	cache := NewConditionCache()
+	valid := &WorkflowDefinitionStruct{Steps: []StepDefinition{{ID: "a", Condition: "${days} > 3"}}}
+	if err := cache.Compile("wf", valid); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	invalid := &WorkflowDefinitionStruct{Steps: []StepDefinition{
+		{ID: "a", Condition: "${days} > 5"},
+		{ID: "b", Condition: "${days} >"},
+	}}
+	err := cache.Compile("wf", invalid)
+	if err == nil || !strings.Contains(err.Error(), "step b") {
+		t.Fatalf("Compile() error = %v, want error for step b", err)
+	}
+	if _, ok := cache.compiled["wf"]["${days} > 3"]; !ok {
+		t.Errorf("previous entries were replaced by a failed compile")
+	}
+	if _, ok := cache.compiled["wf"]["${days} > 5"]; ok {
+		t.Errorf("partially compiled entries were cached")
+	}
+}
+
+func TestConditionCacheGetAndInvalidate(t *testing.T) {
+	cache := NewConditionCache()
+	first, err := cache.Get("wf", "${days} > 3")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	second, _ := cache.Get("wf", "${days} > 3")
+	if first != second {
+		t.Errorf("Get() did not reuse the cached node on a hit")
+	}
+	if _, err := cache.Get("wf", "${days} >"); err == nil {
+		t.Errorf("Get() expected error for invalid condition")
+	}
+
+	cache.Invalidate("wf")
+	if _, ok := cache.compiled["wf"]; ok {
+		t.Fatalf("Invalidate() kept the workflow entries")
+	}
+	third, _ := cache.Get("wf", "${days} > 3")
+	if third == first {
+		t.Errorf("Get() after Invalidate() returned the stale node")
+	}
+}
+
+func TestVariableSnapshotQueriesStepOutputsOnce(t *testing.T) {
+	instance := &instance_aggregate.WorkflowInstance{Input: json.RawMessage(`{"days": 5}`)}
+	review := task_aggregate.NewTask(instance.InstanceId, instance.WorkflowID)
+	review.TaskKey = "review"
+	review.Status = status.TaskStatusCompleted
+	review.Output = json.RawMessage(`{"amount": 200, "approved": true}`)
+	repo := &countingTaskRepo{tasks: []*task_aggregate.Task{review}}
+
+	s := NewWorkflowDomainService(repo)
+	vars := s.NewVariableSnapshot(context.Background(), instance)
+	for _, condition := range []string{
+		"${review.amount} > 100",
+		"${review.approved} == true && ${days} > 3",
+		"${review.amount} * 2 == 400",
+	} {
+		if !s.EvaluateConditionWithSnapshot(condition, instance, vars) {
+			t.Errorf("EvaluateConditionWithSnapshot(%q) = false, want true", condition)
+		}
+	}
+	if repo.calls != 1 {
+		t.Errorf("FindByInstanceID called %d times, want 1", repo.calls)
+	}
+}
+
+type snapshotContextKey struct{}
+
+func TestVariableSnapshotStepOutputs(t *testing.T) {
+	instance := &instance_aggregate.WorkflowInstance{}
+	review := task_aggregate.NewTask(instance.InstanceId, instance.WorkflowID)
+	review.TaskKey = "review"
+	review.Status = status.TaskStatusCompleted
+	review.Output = json.RawMessage(`{"amount": 200}`)
+	// 未完成任务的输出不可见
+	sync := task_aggregate.NewTask(instance.InstanceId, instance.WorkflowID)
+	sync.TaskKey = "sync"
+	sync.Output = json.RawMessage(`{"amount": 300}`)
+	repo := &countingTaskRepo{tasks: []*task_aggregate.Task{review, sync}}
+
+	// 使用调用方的 ctx 查询，事务中的快照才能读到未提交的输出
+	ctx := context.WithValue(context.Background(), snapshotContextKey{}, "tx")
+	outputs, err := NewWorkflowDomainService(repo).NewVariableSnapshot(ctx, instance).StepOutputs()
+	if err != nil {
+		t.Fatalf("StepOutputs() error = %v", err)
+	}
+	if repo.ctx == nil || repo.ctx.Value(snapshotContextKey{}) != "tx" {
+		t.Errorf("FindByInstanceID did not receive the caller context")
+	}
+	if outputs["review"]["amount"] != 200.0 {
+		t.Errorf("StepOutputs()[review] = %v, want amount 200", outputs["review"])
+	}
+	if _, ok := outputs["sync"]; ok {
+		t.Errorf("StepOutputs() includes output of uncompleted task: %v", outputs["sync"])
+	}
+}
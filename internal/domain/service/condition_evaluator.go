@@ -2,28 +2,33 @@ package domain_service
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
-	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
 	task_repository "jxt-evidence-system/process-management/internal/domain/aggregate/task/repository"
 	"log"
-	"regexp"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// VariableInitiator 内置变量：实例发起人的用户ID，${initiator}
+const VariableInitiator = "initiator"
+
 // ConditionEvaluator 条件表达式求值器
 type ConditionEvaluator struct {
-	instance *instance_aggregate.WorkflowInstance
-	taskRepo task_repository.TaskRepository
+	vars *VariableSnapshot
 }
 
 // NewConditionEvaluator 创建条件求值器
-func NewConditionEvaluator(instance *instance_aggregate.WorkflowInstance, taskRepo task_repository.TaskRepository) *ConditionEvaluator {
+func NewConditionEvaluator(ctx context.Context, instance *instance_aggregate.WorkflowInstance, taskRepo task_repository.TaskRepository) *ConditionEvaluator {
+	return NewConditionEvaluatorWithSnapshot(NewVariableSnapshot(ctx, instance, taskRepo))
+}
+
+// NewConditionEvaluatorWithSnapshot 基于已有变量快照创建条件求值器
+func NewConditionEvaluatorWithSnapshot(vars *VariableSnapshot) *ConditionEvaluator {
 	return &ConditionEvaluator{
-		instance: instance,
-		taskRepo: taskRepo,
+		vars: vars,
 	}
 }
 
@@ -33,166 +38,170 @@ func NewConditionEvaluator(instance *instance_aggregate.WorkflowInstance, taskRe
 // - ${step_id.field} == true
 // - ${step_id.field} > 100
 // - ${step_id.field} != null
-// - 逻辑运算：&&, ||, !
+// - 嵌套路径与下标：${input.case.level}、${collect.files[0].name}
+// - 数组与包含：${input.case.level} in ["A", "B"]
+// - 算术运算：+ - * / %，如 ${review.amount} * 1.1 > 5000
+// - 函数：len、contains、startsWith、endsWith、lower、upper、matches、now、date、daysBetween、abs、round
+// - 逻辑运算：&&, ||, !（优先级 比较运算 > ! > && > ||，!${a} == 1 等价于 !(${a} == 1)，可用括号分组）
 func (e *ConditionEvaluator) Evaluate(condition string) (bool, error) {
-	if condition == "" {
+	if strings.TrimSpace(condition) == "" {
 		return true, nil
 	}
 
 	log.Printf("[ConditionEvaluator] Evaluating condition: %s", condition)
 
-	// 处理逻辑运算符
-	if strings.Contains(condition, "&&") {
-		parts := strings.Split(condition, "&&")
-		for _, part := range parts {
-			result, err := e.Evaluate(strings.TrimSpace(part))
-			if err != nil {
-				return false, err
-			}
-			if !result {
-				return false, nil
-			}
-		}
-		return true, nil
+	node, err := compileCondition(condition)
+	if err != nil {
+		return false, err
 	}
+	return e.evaluateNode(node)
+}
 
-	if strings.Contains(condition, "||") {
-		parts := strings.Split(condition, "||")
-		for _, part := range parts {
-			result, err := e.Evaluate(strings.TrimSpace(part))
-			if err != nil {
-				return false, err
-			}
-			if result {
-				return true, nil
-			}
-		}
-		return false, nil
+// Value 对表达式求值并返回结果本身（不转换为布尔值），用于变量映射
+func (e *ConditionEvaluator) Value(expr string) (interface{}, error) {
+	node, err := compileCondition(expr)
+	if err != nil {
+		return nil, err
 	}
+	return e.eval(node)
+}
 
-	// 处理取反
-	if strings.HasPrefix(strings.TrimSpace(condition), "!") {
-		innerCondition := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(condition), "!"))
-		result, err := e.Evaluate(innerCondition)
-		if err != nil {
-			return false, err
-		}
-		return !result, nil
+// evaluateNode 对已编译的条件表达式求值
+func (e *ConditionEvaluator) evaluateNode(node conditionNode) (bool, error) {
+	value, err := e.eval(node)
+	if err != nil {
+		return false, err
 	}
-
-	// 处理比较运算符
-	return e.evaluateComparison(condition)
+	return e.toBool(value), nil
 }
 
-// evaluateComparison 求值比较表达式
-func (e *ConditionEvaluator) evaluateComparison(condition string) (bool, error) {
-	// 支持的运算符：==, !=, >, <, >=, <=
-	operators := []string{"==", "!=", ">=", "<=", ">", "<"}
-
-	for _, op := range operators {
-		if strings.Contains(condition, op) {
-			parts := strings.SplitN(condition, op, 2)
-			if len(parts) != 2 {
-				continue
-			}
-
-			left := strings.TrimSpace(parts[0])
-			right := strings.TrimSpace(parts[1])
-
-			// 解析左值
-			leftValue, err := e.resolveValue(left)
+// eval 对语法树节点求值
+func (e *ConditionEvaluator) eval(node conditionNode) (interface{}, error) {
+	switch n := node.(type) {
+	case *literalNode:
+		return n.value, nil
+	case *variableNode:
+		return e.resolveVariable(n.path, n.segments)
+	case *arrayNode:
+		values := make([]interface{}, 0, len(n.elements))
+		for _, element := range n.elements {
+			value, err := e.eval(element)
 			if err != nil {
-				return false, err
+				return nil, err
 			}
-
-			// 解析右值
-			rightValue, err := e.resolveValue(right)
+			values = append(values, value)
+		}
+		return values, nil
+	case *callNode:
+		fn, ok := conditionFunctions[n.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown function: %s", n.name)
+		}
+		args := make([]interface{}, 0, len(n.args))
+		for _, arg := range n.args {
+			value, err := e.eval(arg)
 			if err != nil {
-				return false, err
+				return nil, err
 			}
-
-			// 执行比较
-			return e.compare(leftValue, rightValue, op)
+			args = append(args, value)
 		}
+		return fn.call(e, args)
+	case *negNode:
+		value, err := e.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		num, err := e.toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		return -num, nil
+	case *arithNode:
+		left, err := e.eval(n.left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := e.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return e.arithmetic(left, right, n.op)
+	case *notNode:
+		value, err := e.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		return !e.toBool(value), nil
+	case *logicalNode:
+		left, err := e.eval(n.left)
+		if err != nil {
+			return nil, err
+		}
+		// 短路求值
+		if n.op == tokenAnd && !e.toBool(left) {
+			return false, nil
+		}
+		if n.op == tokenOr && e.toBool(left) {
+			return true, nil
+		}
+		right, err := e.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return e.toBool(right), nil
+	case *compareNode:
+		left, err := e.eval(n.left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := e.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return e.compare(left, right, n.op)
+	default:
+		return nil, fmt.Errorf("unsupported expression node at position %d", node.position()+1)
 	}
-
-	// 如果没有运算符，尝试作为布尔值解析
-	value, err := e.resolveValue(condition)
-	if err != nil {
-		return false, err
-	}
-
-	// 转换为布尔值
-	return e.toBool(value), nil
 }
 
-// resolveValue 解析值
-// 支持：
-// - ${variable} - 从实例输入中获取
-// - ${step_id.field} - 从步骤输出中获取
-// - "string" - 字符串字面量
-// - 123 - 数字字面量
-// - true/false - 布尔字面量
-// - null - 空值
-func (e *ConditionEvaluator) resolveValue(value string) (interface{}, error) {
-	value = strings.TrimSpace(value)
-
-	// 处理字符串字面量
-	if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-		return strings.Trim(value, "\""), nil
+// resolveVariable 解析变量引用
+// - variable - 从实例输入中获取，initiator 为实例发起人
+// - input.a.b - 从实例输入中按路径获取
+// - step_id.field.sub - 从步骤输出中按路径获取，步骤不存在时回退到实例输入的嵌套字段
+func (e *ConditionEvaluator) resolveVariable(varPath string, segments []pathSegment) (interface{}, error) {
+	if len(segments) == 1 {
+		if segments[0].key == VariableInitiator && e.vars.Initiator() != 0 {
+			return float64(e.vars.Initiator()), nil
+		}
+		return e.resolveInstanceInput(varPath)
 	}
 
-	// 处理布尔字面量
-	if value == "true" {
-		return true, nil
+	input, err := e.vars.Input()
+	if err != nil {
+		return nil, err
 	}
-	if value == "false" {
-		return false, nil
+	if segments[0].key == "input" {
+		return walkPath(input, segments[1:], varPath)
 	}
 
-	// 处理null
-	if value == "null" {
-		return nil, nil
+	outputs, err := e.vars.StepOutputs()
+	if err != nil {
+		return nil, err
 	}
-
-	// 处理数字字面量
-	if num, err := strconv.ParseFloat(value, 64); err == nil {
-		return num, nil
+	if output, ok := outputs[segments[0].key]; ok {
+		return walkPath(output, segments[1:], varPath)
 	}
-
-	// 处理变量引用 ${...}
-	if strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") {
-		varPath := strings.TrimSuffix(strings.TrimPrefix(value, "${"), "}")
-
-		// 检查是否是步骤输出引用 step_id.field
-		if strings.Contains(varPath, ".") {
-			return e.resolveStepOutput(varPath)
-		}
-
-		// 从实例输入中获取
-		return e.resolveInstanceInput(varPath)
+	if _, ok := input[segments[0].key]; ok {
+		return walkPath(input, segments, varPath)
 	}
-
-	// 默认作为字符串返回
-	return value, nil
+	return nil, fmt.Errorf("task output not found for step: %s", segments[0].key)
 }
 
 // resolveInstanceInput 从实例输入中解析变量
 func (e *ConditionEvaluator) resolveInstanceInput(varName string) (interface{}, error) {
-	var input map[string]interface{}
-	inputData := e.instance.Input
-
-	// 检查双重编码
-	inputStr := string(inputData)
-	if strings.HasPrefix(inputStr, "\"") && strings.HasSuffix(inputStr, "\"") {
-		var tempStr string
-		if err := json.Unmarshal(inputData, &tempStr); err == nil {
-			inputData = []byte(tempStr)
-		}
-	}
-
-	if err := json.Unmarshal(inputData, &input); err != nil {
-		return nil, fmt.Errorf("failed to parse instance input: %v", err)
+	input, err := e.vars.Input()
+	if err != nil {
+		return nil, err
 	}
 
 	if val, ok := input[varName]; ok {
@@ -202,51 +211,93 @@ func (e *ConditionEvaluator) resolveInstanceInput(varName string) (interface{},
 	return nil, fmt.Errorf("variable not found in instance input: %s", varName)
 }
 
-// resolveStepOutput 从步骤输出中解析变量
-// 格式：step_id.field
-func (e *ConditionEvaluator) resolveStepOutput(varPath string) (interface{}, error) {
-	parts := strings.SplitN(varPath, ".", 2)
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid step output reference: %s", varPath)
-	}
-
-	stepKey := parts[0]
-	fieldName := parts[1]
-
-	ctx := context.Background()
+// walkPath 沿路径逐段读取嵌套对象和数组
+func walkPath(root map[string]interface{}, segments []pathSegment, varPath string) (interface{}, error) {
+	var current interface{} = root
+	for _, seg := range segments {
+		if seg.isIndex {
+			list, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot index non-array value in %s", varPath)
+			}
+			if seg.index >= len(list) {
+				return nil, fmt.Errorf("index %d out of range in %s (length %d)", seg.index, varPath, len(list))
+			}
+			current = list[seg.index]
+			continue
+		}
 
-	tasks, err := e.taskRepo.FindByInstanceID(ctx, e.instance.InstanceId)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find tasks: %v", err)
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot read field %s of non-object value in %s", seg.key, varPath)
+		}
+		val, ok := object[seg.key]
+		if !ok {
+			return nil, fmt.Errorf("field not found: %s", varPath)
+		}
+		current = val
 	}
+	return current, nil
+}
 
-	var targetTask *task_aggregate.Task
-	for _, task := range tasks {
-		if task.TaskKey == stepKey {
-			targetTask = task
-			break
+// arithmetic 算术运算，+ 两侧有字符串时做拼接
+func (e *ConditionEvaluator) arithmetic(left, right interface{}, operator string) (interface{}, error) {
+	if operator == "+" {
+		_, leftIsString := left.(string)
+		_, rightIsString := right.(string)
+		if leftIsString || rightIsString {
+			return toString(left) + toString(right), nil
 		}
 	}
 
-	if targetTask == nil {
-		return nil, fmt.Errorf("task not found for step: %s", stepKey)
+	leftNum, err := e.toFloat(left)
+	if err != nil {
+		return nil, err
 	}
-
-	// 从任务输出中获取字段值
-	if len(targetTask.Output) == 0 {
-		return nil, fmt.Errorf("task output is empty for step: %s", stepKey)
+	rightNum, err := e.toFloat(right)
+	if err != nil {
+		return nil, err
 	}
 
-	var output map[string]interface{}
-	if err := json.Unmarshal(targetTask.Output, &output); err != nil {
-		return nil, fmt.Errorf("failed to parse task output: %v", err)
+	switch operator {
+	case "+":
+		return leftNum + rightNum, nil
+	case "-":
+		return leftNum - rightNum, nil
+	case "*":
+		return leftNum * rightNum, nil
+	case "/":
+		if rightNum == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return leftNum / rightNum, nil
+	case "%":
+		if rightNum == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(leftNum, rightNum), nil
+	default:
+		return nil, fmt.Errorf("unsupported operator: %s", operator)
 	}
+}
 
-	if val, ok := output[fieldName]; ok {
-		return val, nil
+// contains 判断数组是否包含元素或字符串是否包含子串
+func (e *ConditionEvaluator) contains(container, item interface{}) (bool, error) {
+	switch c := container.(type) {
+	case nil:
+		return false, nil
+	case []interface{}:
+		for _, element := range c {
+			if e.equals(element, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		return strings.Contains(c, toString(item)), nil
+	default:
+		return false, fmt.Errorf("cannot check membership in %v", container)
 	}
-
-	return nil, fmt.Errorf("field not found in task output: %s.%s", stepKey, fieldName)
 }
 
 // compare 比较两个值
@@ -272,6 +323,8 @@ func (e *ConditionEvaluator) compare(left, right interface{}, operator string) (
 			return false, err
 		}
 		return lt || e.equals(left, right), nil
+	case "in":
+		return e.contains(right, left)
 	default:
 		return false, fmt.Errorf("unsupported operator: %s", operator)
 	}
@@ -287,6 +340,13 @@ func (e *ConditionEvaluator) equals(left, right interface{}) bool {
 		return false
 	}
 
+	// 时间按时刻比较
+	if isTime(left) || isTime(right) {
+		leftTime, leftErr := toTime(left)
+		rightTime, rightErr := toTime(right)
+		return leftErr == nil && rightErr == nil && leftTime.Equal(rightTime)
+	}
+
 	// 类型转换
 	leftStr := fmt.Sprintf("%v", left)
 	rightStr := fmt.Sprintf("%v", right)
@@ -296,6 +356,14 @@ func (e *ConditionEvaluator) equals(left, right interface{}) bool {
 
 // greaterThan 判断大于
 func (e *ConditionEvaluator) greaterThan(left, right interface{}) (bool, error) {
+	if isTime(left) || isTime(right) {
+		leftTime, rightTime, err := toTimes(left, right)
+		if err != nil {
+			return false, err
+		}
+		return leftTime.After(rightTime), nil
+	}
+
 	leftNum, err := e.toFloat(left)
 	if err != nil {
 		return false, err
@@ -311,6 +379,14 @@ func (e *ConditionEvaluator) greaterThan(left, right interface{}) (bool, error)
 
 // lessThan 判断小于
 func (e *ConditionEvaluator) lessThan(left, right interface{}) (bool, error) {
+	if isTime(left) || isTime(right) {
+		leftTime, rightTime, err := toTimes(left, right)
+		if err != nil {
+			return false, err
+		}
+		return leftTime.Before(rightTime), nil
+	}
+
 	leftNum, err := e.toFloat(left)
 	if err != nil {
 		return false, err
@@ -324,6 +400,23 @@ func (e *ConditionEvaluator) lessThan(left, right interface{}) (bool, error) {
 	return leftNum < rightNum, nil
 }
 
+func isTime(value interface{}) bool {
+	_, ok := value.(time.Time)
+	return ok
+}
+
+func toTimes(left, right interface{}) (time.Time, time.Time, error) {
+	leftTime, err := toTime(left)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	rightTime, err := toTime(right)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return leftTime, rightTime, nil
+}
+
 // toFloat 转换为浮点数
 func (e *ConditionEvaluator) toFloat(value interface{}) (float64, error) {
 	switch v := value.(type) {
@@ -364,31 +457,11 @@ func (e *ConditionEvaluator) toBool(value interface{}) bool {
 	}
 }
 
-// ValidateCondition 验证条件表达式语法
+// ValidateCondition 验证条件表达式语法及函数、运算符的类型
 func ValidateCondition(condition string) error {
-	if condition == "" {
+	if strings.TrimSpace(condition) == "" {
 		return nil
 	}
-
-	// 基本语法检查
-	// 检查括号匹配
-	openCount := strings.Count(condition, "(")
-	closeCount := strings.Count(condition, ")")
-	if openCount != closeCount {
-		return fmt.Errorf("unmatched parentheses in condition: %s", condition)
-	}
-	/*匹配格式：${variable_name}
-	允许的字符：字母、数字、下划线、点号
-	至少一个字符*/
-	// 检查变量引用格式
-	varPattern := regexp.MustCompile(`\$\{[a-zA-Z0-9_\.]+\}`)
-	matches := varPattern.FindAllString(condition, -1) //在字符串中查找所有匹配正则表达式的子串
-	for _, match := range matches {
-		varPath := strings.TrimSuffix(strings.TrimPrefix(match, "${"), "}")
-		if strings.Contains(varPath, "..") {
-			return fmt.Errorf("invalid variable reference: %s", match)
-		}
-	}
-
-	return nil
+	_, err := compileCondition(condition)
+	return err
 }
@@ -0,0 +1,386 @@
+package domain_service
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ConditionTypeError 条件表达式类型错误（未知函数、参数个数或类型不匹配等）
+type ConditionTypeError struct {
+	Pos int    // 出错位置（从1开始的字符列号）
+	Msg string // 错误描述
+}
+
+func (e *ConditionTypeError) Error() string {
+	return fmt.Sprintf("type error at position %d: %s", e.Pos, e.Msg)
+}
+
+func typeError(pos int, format string, args ...interface{}) *ConditionTypeError {
+	return &ConditionTypeError{Pos: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// ===== 静态类型 =====
+
+// valueType 表达式静态类型，按位组合表示"可能是其中任一类型"
+type valueType int
+
+const (
+	typeNumber valueType = 1 << iota
+	typeString
+	typeBool
+	typeArray
+	typeTime
+	typeNull
+
+	// typeAny 变量引用在编译期无法确定类型
+	typeAny = typeNumber | typeString | typeBool | typeArray | typeTime | typeNull
+)
+
+var valueTypeNames = []struct {
+	t    valueType
+	name string
+}{
+	{typeNumber, "number"},
+	{typeString, "string"},
+	{typeBool, "bool"},
+	{typeArray, "array"},
+	{typeTime, "time"},
+	{typeNull, "null"},
+}
+
+func (t valueType) String() string {
+	if t == typeAny {
+		return "any"
+	}
+	var names []string
+	for _, item := range valueTypeNames {
+		if t&item.t != 0 {
+			names = append(names, item.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// ===== 函数库 =====
+
+// conditionFunction 条件表达式内置函数
+type conditionFunction struct {
+	args    []valueType // 每个参数可接受的类型
+	returns valueType
+	call    func(e *ConditionEvaluator, args []interface{}) (interface{}, error)
+}
+
+var conditionFunctions map[string]conditionFunction
+
+func init() {
+	conditionFunctions = map[string]conditionFunction{
+		// len(x) 字符串长度（按字符）或数组长度
+		"len": {
+			args:    []valueType{typeString | typeArray | typeNull},
+			returns: typeNumber,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				switch v := args[0].(type) {
+				case nil:
+					return float64(0), nil
+				case string:
+					return float64(utf8.RuneCountInString(v)), nil
+				case []interface{}:
+					return float64(len(v)), nil
+				case map[string]interface{}:
+					return float64(len(v)), nil
+				default:
+					return nil, fmt.Errorf("len: unsupported argument %v", v)
+				}
+			},
+		},
+		// contains(x, item) 字符串包含子串或数组包含元素
+		"contains": {
+			args:    []valueType{typeString | typeArray | typeNull, typeAny},
+			returns: typeBool,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				return e.contains(args[0], args[1])
+			},
+		},
+		"startsWith": {
+			args:    []valueType{typeString, typeString},
+			returns: typeBool,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				return strings.HasPrefix(toString(args[0]), toString(args[1])), nil
+			},
+		},
+		"endsWith": {
+			args:    []valueType{typeString, typeString},
+			returns: typeBool,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				return strings.HasSuffix(toString(args[0]), toString(args[1])), nil
+			},
+		},
+		"lower": {
+			args:    []valueType{typeString},
+			returns: typeString,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				return strings.ToLower(toString(args[0])), nil
+			},
+		},
+		"upper": {
+			args:    []valueType{typeString},
+			returns: typeString,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				return strings.ToUpper(toString(args[0])), nil
+			},
+		},
+		// matches(s, pattern) 正则匹配（RE2 语法）
+		"matches": {
+			args:    []valueType{typeString, typeString},
+			returns: typeBool,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				re, err := compileRegexp(toString(args[1]))
+				if err != nil {
+					return nil, err
+				}
+				return re.MatchString(toString(args[0])), nil
+			},
+		},
+		// now() 当前时间
+		"now": {
+			returns: typeTime,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				return time.Now(), nil
+			},
+		},
+		// date(s) 解析日期，支持 RFC3339、"2006-01-02 15:04:05"、"2006-01-02"
+		"date": {
+			args:    []valueType{typeString | typeTime},
+			returns: typeTime,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				return toTime(args[0])
+			},
+		},
+		// daysBetween(from, to) 两个时间相差的天数（to - from，不足一天舍去）
+		"daysBetween": {
+			args:    []valueType{typeString | typeTime, typeString | typeTime},
+			returns: typeNumber,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				from, err := toTime(args[0])
+				if err != nil {
+					return nil, err
+				}
+				to, err := toTime(args[1])
+				if err != nil {
+					return nil, err
+				}
+				return math.Trunc(to.Sub(from).Hours() / 24), nil
+			},
+		},
+		"abs": {
+			args:    []valueType{typeNumber | typeString},
+			returns: typeNumber,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				num, err := e.toFloat(args[0])
+				if err != nil {
+					return nil, err
+				}
+				return math.Abs(num), nil
+			},
+		},
+		"round": {
+			args:    []valueType{typeNumber | typeString},
+			returns: typeNumber,
+			call: func(e *ConditionEvaluator, args []interface{}) (interface{}, error) {
+				num, err := e.toFloat(args[0])
+				if err != nil {
+					return nil, err
+				}
+				return math.Round(num), nil
+			},
+		},
+	}
+}
+
+// regexpCache 已编译的正则表达式
+var regexpCache sync.Map
+
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexpCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+	}
+	regexpCache.Store(pattern, re)
+	return re, nil
+}
+
+// dateLayouts date() 及时间比较支持的格式
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// toTime 转换为时间
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot convert to time: %s", v)
+	default:
+		return time.Time{}, fmt.Errorf("cannot convert to time: %v", value)
+	}
+}
+
+// toString 转换为字符串，null 视为空串
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ===== 类型检查 =====
+
+// checkTypes 推导节点静态类型，发现函数或运算符用法错误时返回 ConditionTypeError
+func checkTypes(node conditionNode) (valueType, error) {
+	switch n := node.(type) {
+	case *literalNode:
+		switch n.value.(type) {
+		case float64:
+			return typeNumber, nil
+		case string:
+			return typeString, nil
+		case bool:
+			return typeBool, nil
+		default:
+			return typeNull, nil
+		}
+	case *variableNode:
+		return typeAny, nil
+	case *arrayNode:
+		for _, element := range n.elements {
+			if _, err := checkTypes(element); err != nil {
+				return 0, err
+			}
+		}
+		return typeArray, nil
+	case *notNode:
+		if _, err := checkTypes(n.operand); err != nil {
+			return 0, err
+		}
+		return typeBool, nil
+	case *logicalNode:
+		if _, err := checkTypes(n.left); err != nil {
+			return 0, err
+		}
+		if _, err := checkTypes(n.right); err != nil {
+			return 0, err
+		}
+		return typeBool, nil
+	case *negNode:
+		operand, err := checkTypes(n.operand)
+		if err != nil {
+			return 0, err
+		}
+		if operand&(typeNumber|typeString) == 0 {
+			return 0, typeError(n.pos, "operator '-' expects a number, got %s", operand)
+		}
+		return typeNumber, nil
+	case *arithNode:
+		left, right, err := checkOperands(n.left, n.right)
+		if err != nil {
+			return 0, err
+		}
+		allowed := typeNumber | typeString
+		if left&allowed == 0 || right&allowed == 0 {
+			return 0, typeError(n.pos, "operator '%s' cannot be applied to %s and %s", n.op, left, right)
+		}
+		if n.op == "+" {
+			return left | right, nil
+		}
+		return typeNumber, nil
+	case *compareNode:
+		left, right, err := checkOperands(n.left, n.right)
+		if err != nil {
+			return 0, err
+		}
+		switch n.op {
+		case ">", "<", ">=", "<=":
+			allowed := typeNumber | typeString | typeTime
+			if left&allowed == 0 || right&allowed == 0 {
+				return 0, typeError(n.pos, "operator '%s' cannot be applied to %s and %s", n.op, left, right)
+			}
+		case "in":
+			if right&(typeArray|typeString|typeNull) == 0 {
+				return 0, typeError(n.pos, "operator 'in' expects an array or string on the right, got %s", right)
+			}
+		}
+		return typeBool, nil
+	case *callNode:
+		return checkCall(n)
+	default:
+		return 0, typeError(node.position(), "unsupported expression")
+	}
+}
+
+func checkOperands(leftNode, rightNode conditionNode) (valueType, valueType, error) {
+	left, err := checkTypes(leftNode)
+	if err != nil {
+		return 0, 0, err
+	}
+	right, err := checkTypes(rightNode)
+	if err != nil {
+		return 0, 0, err
+	}
+	return left, right, nil
+}
+
+func checkCall(n *callNode) (valueType, error) {
+	fn, ok := conditionFunctions[n.name]
+	if !ok {
+		return 0, typeError(n.pos, "unknown function '%s'", n.name)
+	}
+	if len(n.args) != len(fn.args) {
+		return 0, typeError(n.pos, "function '%s' expects %d argument(s), got %d", n.name, len(fn.args), len(n.args))
+	}
+	for i, arg := range n.args {
+		argType, err := checkTypes(arg)
+		if err != nil {
+			return 0, err
+		}
+		if argType&fn.args[i] == 0 {
+			return 0, typeError(arg.position(), "argument %d of '%s' must be %s, got %s", i+1, n.name, fn.args[i], argType)
+		}
+	}
+
+	// 字面量参数提前校验
+	if n.name == "matches" {
+		if lit, ok := n.args[1].(*literalNode); ok {
+			if _, err := compileRegexp(toString(lit.value)); err != nil {
+				return 0, typeError(lit.pos, "%v", err)
+			}
+		}
+	}
+	if n.name == "date" {
+		if lit, ok := n.args[0].(*literalNode); ok {
+			if _, err := toTime(lit.value); err != nil {
+				return 0, typeError(lit.pos, "%v", err)
+			}
+		}
+	}
+	return fn.returns, nil
+}
@@ -0,0 +1,87 @@
+package domain_service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConditionFunctions(t *testing.T) {
+	e := newTestEvaluator(`{"name": "Report-2024.PDF", "title": "证据清单", "tags": ["a", "b"], "amount": -4.6, "submitted": "2024-01-01", "due": "2024-01-11 08:00:00"}`)
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{`len(${title}) == 4`, true},
+		{`len(${tags}) == 2`, true},
+		{`len(null) == 0`, true},
+		{`contains(${tags}, 'b')`, true},
+		{`contains(${name}, '2024')`, true},
+		{`contains(null, 'a')`, false},
+		{`startsWith(${name}, 'Report')`, true},
+		{`endsWith(lower(${name}), '.pdf')`, true},
+		{`upper('abc') == 'ABC'`, true},
+		{`matches(${name}, '^Report-\\d{4}\\.PDF$')`, true},
+		{`matches(${name}, '^report')`, false},
+		{`abs(${amount}) == 4.6`, true},
+		{`round(${amount}) == -5`, true},
+		{`round('2.5') == 3`, true},
+		{`daysBetween(${submitted}, ${due}) == 10`, true},
+		{`date(${due}) > date(${submitted})`, true},
+		{`date('2024-01-01') == date('2024-01-01 00:00:00')`, true},
+		{`now() > date('2024-01-01')`, true},
+		{`${amount} + 1 < -3.5`, true},
+		{`'a' + 1 == 'a1'`, true},
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition)
+		if err != nil {
+			t.Errorf("Evaluate(%q) error = %v", tt.condition, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+}
+
+func TestConditionFunctionRuntimeErrors(t *testing.T) {
+	e := newTestEvaluator(`{"pattern": "[", "day": "tomorrow", "zero": 0}`)
+	for _, condition := range []string{
+		`matches('abc', ${pattern})`,
+		`date(${day}) > now()`,
+		`daysBetween(${day}, '2024-01-01') > 1`,
+		`1 / ${zero} > 0`,
+		`5 % ${zero} > 0`,
+	} {
+		if _, err := e.Evaluate(condition); err == nil {
+			t.Errorf("Evaluate(%q) expected error", condition)
+		}
+	}
+}
+
+func TestConditionValueReturnsTypedResult(t *testing.T) {
+	e := newTestEvaluator(`{"amount": 100, "tags": ["a"]}`)
+	value, err := e.Value(`${amount} * 1.1`)
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	if num, ok := value.(float64); !ok || num < 109.99 || num > 110.01 {
+		t.Errorf("Value() = %v, want 110", value)
+	}
+
+	value, err = e.Value(`date('2024-03-01')`)
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	if tm, ok := value.(time.Time); !ok || tm.Month() != time.March {
+		t.Errorf("Value() = %v, want 2024-03-01", value)
+	}
+
+	value, err = e.Value(`[${amount}, 'x']`)
+	if err != nil {
+		t.Fatalf("Value() error = %v", err)
+	}
+	if list, ok := value.([]interface{}); !ok || len(list) != 2 {
+		t.Errorf("Value() = %v, want a two-element array", value)
+	}
+}
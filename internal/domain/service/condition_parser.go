@@ -0,0 +1,695 @@
+package domain_service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ConditionSyntaxError 条件表达式语法错误
+type ConditionSyntaxError struct {
+	Pos int    // 出错位置（从1开始的字符列号）
+	Msg string // 错误描述
+}
+
+func (e *ConditionSyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+func syntaxError(pos int, format string, args ...interface{}) *ConditionSyntaxError {
+	return &ConditionSyntaxError{Pos: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// ===== 词法分析 =====
+
+type tokenKind int
+
+const (
+	tokenEOF      tokenKind = iota
+	tokenIdent              // 裸字符串，如 approved
+	tokenNumber             // 数字字面量
+	tokenString             // 字符串字面量
+	tokenVariable           // 变量引用 ${...}
+	tokenTrue
+	tokenFalse
+	tokenNull
+	tokenLParen
+	tokenRParen
+	tokenNot
+	tokenAnd
+	tokenOr
+	tokenEq
+	tokenNeq
+	tokenGt
+	tokenLt
+	tokenGte
+	tokenLte
+	tokenIn
+	tokenPlus
+	tokenMinus
+	tokenStar
+	tokenSlash
+	tokenPercent
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+var tokenNames = map[tokenKind]string{
+	tokenEOF:      "end of expression",
+	tokenIdent:    "identifier",
+	tokenNumber:   "number",
+	tokenString:   "string",
+	tokenVariable: "variable",
+	tokenTrue:     "true",
+	tokenFalse:    "false",
+	tokenNull:     "null",
+	tokenLParen:   "'('",
+	tokenRParen:   "')'",
+	tokenNot:      "'!'",
+	tokenAnd:      "'&&'",
+	tokenOr:       "'||'",
+	tokenEq:       "'=='",
+	tokenNeq:      "'!='",
+	tokenGt:       "'>'",
+	tokenLt:       "'<'",
+	tokenGte:      "'>='",
+	tokenLte:      "'<='",
+	tokenIn:       "'in'",
+	tokenPlus:     "'+'",
+	tokenMinus:    "'-'",
+	tokenStar:     "'*'",
+	tokenSlash:    "'/'",
+	tokenPercent:  "'%'",
+	tokenLBracket: "'['",
+	tokenRBracket: "']'",
+	tokenComma:    "','",
+}
+
+// singleCharTokens 单字符运算符和分隔符
+var singleCharTokens = map[rune]tokenKind{
+	'(': tokenLParen,
+	')': tokenRParen,
+	'[': tokenLBracket,
+	']': tokenRBracket,
+	',': tokenComma,
+	'+': tokenPlus,
+	'-': tokenMinus,
+	'*': tokenStar,
+	'/': tokenSlash,
+	'%': tokenPercent,
+}
+
+func (k tokenKind) String() string {
+	return tokenNames[k]
+}
+
+type token struct {
+	kind tokenKind
+	text string // 标识符、字符串（已处理转义）、变量路径或数字原文
+	pos  int    // 起始位置（字符下标）
+}
+
+// tokenize 将条件表达式切分为词法单元
+func tokenize(input string) ([]token, error) {
+	src := []rune(input)
+	var tokens []token
+
+	for i := 0; i < len(src); {
+		ch := src[i]
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case singleCharTokens[ch] != tokenEOF:
+			tokens = append(tokens, token{kind: singleCharTokens[ch], text: string(ch), pos: i})
+			i++
+		case ch == '&' || ch == '|':
+			if i+1 >= len(src) || src[i+1] != ch {
+				return nil, syntaxError(i, "unexpected '%c', did you mean '%c%c'", ch, ch, ch)
+			}
+			kind := tokenAnd
+			if ch == '|' {
+				kind = tokenOr
+			}
+			tokens = append(tokens, token{kind: kind, text: string([]rune{ch, ch}), pos: i})
+			i += 2
+		case ch == '=':
+			if i+1 >= len(src) || src[i+1] != '=' {
+				return nil, syntaxError(i, "unexpected '=', did you mean '=='")
+			}
+			tokens = append(tokens, token{kind: tokenEq, text: "==", pos: i})
+			i += 2
+		case ch == '!':
+			if i+1 < len(src) && src[i+1] == '=' {
+				tokens = append(tokens, token{kind: tokenNeq, text: "!=", pos: i})
+				i += 2
+			} else {
+				tokens = append(tokens, token{kind: tokenNot, text: "!", pos: i})
+				i++
+			}
+		case ch == '>' || ch == '<':
+			kind, text := tokenGt, ">"
+			if ch == '<' {
+				kind, text = tokenLt, "<"
+			}
+			if i+1 < len(src) && src[i+1] == '=' {
+				if ch == '>' {
+					kind, text = tokenGte, ">="
+				} else {
+					kind, text = tokenLte, "<="
+				}
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: i})
+			i += len(text)
+		case ch == '"' || ch == '\'':
+			tok, next, err := scanString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		case ch == '$':
+			tok, next, err := scanVariable(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		case unicode.IsDigit(ch):
+			tok, next, err := scanNumber(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		case unicode.IsLetter(ch) || ch == '_':
+			start := i
+			for i < len(src) && isIdentRune(src[i]) {
+				i++
+			}
+			text := string(src[start:i])
+			kind := tokenIdent
+			switch text {
+			case "true":
+				kind = tokenTrue
+			case "false":
+				kind = tokenFalse
+			case "null":
+				kind = tokenNull
+			case "in":
+				kind = tokenIn
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: start})
+		default:
+			return nil, syntaxError(i, "unexpected character '%c'", ch)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(src)})
+	return tokens, nil
+}
+
+func isIdentRune(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_' || ch == '.' || ch == '-'
+}
+
+// scanString 读取字符串字面量，支持 \" \' \\ \n \t 转义
+func scanString(src []rune, start int) (token, int, error) {
+	quote := src[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		ch := src[i]
+		if ch == quote {
+			return token{kind: tokenString, text: sb.String(), pos: start}, i + 1, nil
+		}
+		if ch != '\\' {
+			sb.WriteRune(ch)
+			continue
+		}
+		if i+1 >= len(src) {
+			break
+		}
+		i++
+		switch src[i] {
+		case '"', '\'', '\\':
+			sb.WriteRune(src[i])
+		case 'n':
+			sb.WriteRune('\n')
+		case 't':
+			sb.WriteRune('\t')
+		default:
+			return token{}, 0, syntaxError(i-1, "invalid escape sequence '\\%c'", src[i])
+		}
+	}
+	return token{}, 0, syntaxError(start, "unterminated string")
+}
+
+// scanVariable 读取变量引用 ${path}，path 支持点号和下标，如 ${input.case.level}、${collect.files[0]}
+func scanVariable(src []rune, start int) (token, int, error) {
+	if start+1 >= len(src) || src[start+1] != '{' {
+		return token{}, 0, syntaxError(start, "expected '{' after '$'")
+	}
+	for i := start + 2; i < len(src); i++ {
+		if src[i] != '}' {
+			continue
+		}
+		path := strings.TrimSpace(string(src[start+2 : i]))
+		if path == "" {
+			return token{}, 0, syntaxError(start, "empty variable reference")
+		}
+		if _, err := parseVariablePath(path); err != nil {
+			return token{}, 0, syntaxError(start, "invalid variable reference ${%s}: %v", path, err)
+		}
+		return token{kind: tokenVariable, text: path, pos: start}, i + 1, nil
+	}
+	return token{}, 0, syntaxError(start, "unterminated variable reference, missing '}'")
+}
+
+// scanNumber 读取数字字面量
+func scanNumber(src []rune, start int) (token, int, error) {
+	i := start
+	for i < len(src) && (unicode.IsDigit(src[i]) || src[i] == '.') {
+		i++
+	}
+	// 数字后紧跟字母（如 1a）或形如 2024-01-01 的日期时按裸字符串处理，与旧版行为一致
+	if i < len(src) && (unicode.IsLetter(src[i]) || src[i] == '_') || isDateLiteral(src[start:]) {
+		for i < len(src) && isIdentRune(src[i]) {
+			i++
+		}
+		return token{kind: tokenIdent, text: string(src[start:i]), pos: start}, i, nil
+	}
+	text := string(src[start:i])
+	if _, err := strconv.ParseFloat(text, 64); err != nil {
+		return token{}, 0, syntaxError(start, "invalid number '%s'", text)
+	}
+	return token{kind: tokenNumber, text: text, pos: start}, i, nil
+}
+
+// isDateLiteral 判断是否以 yyyy-mm-dd 开头
+func isDateLiteral(src []rune) bool {
+	if len(src) < 10 {
+		return false
+	}
+	for i, ch := range src[:10] {
+		if i == 4 || i == 7 {
+			if ch != '-' {
+				return false
+			}
+		} else if !unicode.IsDigit(ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// pathSegment 变量路径中的一段：字段名或数组下标
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseVariablePath 解析变量路径，如 collect.files[0].name
+func parseVariablePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	src := []rune(path)
+	for i := 0; i < len(src); {
+		// 字段名
+		start := i
+		for i < len(src) && src[i] != '.' && src[i] != '[' {
+			i++
+		}
+		key := strings.TrimSpace(string(src[start:i]))
+		if key == "" {
+			if i < len(src) && src[i] == '[' && len(segments) > 0 {
+				// 下标直接跟在下标之后，如 a[0][1]
+			} else {
+				return nil, fmt.Errorf("empty field name at position %d", start+1)
+			}
+		} else {
+			segments = append(segments, pathSegment{key: key})
+		}
+
+		// 下标
+		for i < len(src) && src[i] == '[' {
+			end := i + 1
+			for end < len(src) && src[end] != ']' {
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("missing ']' at position %d", i+1)
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(string(src[i+1 : end])))
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index '%s' at position %d", string(src[i+1:end]), i+2)
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+			i = end + 1
+		}
+
+		if i < len(src) {
+			if src[i] != '.' {
+				return nil, fmt.Errorf("unexpected '%c' at position %d", src[i], i+1)
+			}
+			i++
+			if i == len(src) {
+				return nil, fmt.Errorf("empty field name at position %d", i+1)
+			}
+		}
+	}
+	return segments, nil
+}
+
+// ===== 语法树 =====
+
+// conditionNode 条件表达式语法树节点
+type conditionNode interface {
+	position() int
+}
+
+// literalNode 字面量（字符串、数字、布尔、null、裸字符串）
+type literalNode struct {
+	pos   int
+	value interface{}
+}
+
+// variableNode 变量引用 ${variable}、${step_id.field}、${input.a.b[0]}
+type variableNode struct {
+	pos      int
+	path     string
+	segments []pathSegment
+}
+
+// arrayNode 数组字面量 ["A", "B"]
+type arrayNode struct {
+	pos      int
+	elements []conditionNode
+}
+
+// callNode 函数调用 len(${a})
+type callNode struct {
+	pos  int
+	name string
+	args []conditionNode
+}
+
+// negNode 取负
+type negNode struct {
+	pos     int
+	operand conditionNode
+}
+
+// arithNode 算术运算 + - * / %
+type arithNode struct {
+	pos         int
+	op          string
+	left, right conditionNode
+}
+
+// notNode 逻辑取反
+type notNode struct {
+	pos     int
+	operand conditionNode
+}
+
+// logicalNode 逻辑运算 && ||
+type logicalNode struct {
+	pos         int
+	op          tokenKind
+	left, right conditionNode
+}
+
+// compareNode 比较运算 == != > < >= <= in
+type compareNode struct {
+	pos         int
+	op          string
+	left, right conditionNode
+}
+
+func (n *literalNode) position() int  { return n.pos }
+func (n *variableNode) position() int { return n.pos }
+func (n *notNode) position() int      { return n.pos }
+func (n *logicalNode) position() int  { return n.pos }
+func (n *compareNode) position() int  { return n.pos }
+func (n *arrayNode) position() int    { return n.pos }
+func (n *callNode) position() int     { return n.pos }
+func (n *negNode) position() int      { return n.pos }
+func (n *arithNode) position() int    { return n.pos }
+
+// ===== 语法分析 =====
+
+// 语法（优先级从低到高）：
+//
+//	expr           := or
+//	or             := and ( "||" and )*
+//	and            := unary ( "&&" unary )*
+//	unary          := "!" unary | comparison
+//	comparison     := additive ( ("==" | "!=" | ">" | "<" | ">=" | "<=" | "in") additive )?
+//	additive       := multiplicative ( ("+" | "-") multiplicative )*
+//	multiplicative := negation ( ("*" | "/" | "%") negation )*
+//	negation       := "-" negation | primary
+//	primary        := "(" expr ")" | "[" ( expr ( "," expr )* )? "]" | identifier "(" ( expr ( "," expr )* )? ")"
+//	                | variable | string | number | true | false | null | identifier
+//
+// 注意 "!" 的优先级低于比较运算，!${a} == 1 等价于 !(${a} == 1)，与旧版行为保持一致
+type conditionParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseCondition 解析条件表达式为语法树
+func parseCondition(condition string) (conditionNode, error) {
+	tokens, err := tokenize(condition)
+	if err != nil {
+		return nil, err
+	}
+	p := &conditionParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, syntaxError(tok.pos, "unexpected %s", describeToken(tok))
+	}
+	return node, nil
+}
+
+// compileCondition 解析并类型检查条件表达式
+func compileCondition(condition string) (conditionNode, error) {
+	node, err := parseCondition(condition)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := checkTypes(node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (p *conditionParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *conditionParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *conditionParser) parseOr() (conditionNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		op := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{pos: op.pos, op: tokenOr, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (conditionNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		op := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{pos: op.pos, op: tokenAnd, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseUnary() (conditionNode, error) {
+	if p.peek().kind == tokenNot {
+		op := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{pos: op.pos, operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *conditionParser) parseComparison() (conditionNode, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := p.peek(); tok.kind {
+	case tokenEq, tokenNeq, tokenGt, tokenLt, tokenGte, tokenLte, tokenIn:
+		p.next()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{pos: tok.pos, op: tok.text, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAdditive() (conditionNode, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenPlus || p.peek().kind == tokenMinus {
+		op := p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithNode{pos: op.pos, op: op.text, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseMultiplicative() (conditionNode, error) {
+	left, err := p.parseNegation()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenStar || p.peek().kind == tokenSlash || p.peek().kind == tokenPercent {
+		op := p.next()
+		right, err := p.parseNegation()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithNode{pos: op.pos, op: op.text, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseNegation() (conditionNode, error) {
+	if p.peek().kind == tokenMinus {
+		op := p.next()
+		operand, err := p.parseNegation()
+		if err != nil {
+			return nil, err
+		}
+		// 数字字面量直接取负
+		if lit, ok := operand.(*literalNode); ok {
+			if num, ok := lit.value.(float64); ok {
+				return &literalNode{pos: op.pos, value: -num}, nil
+			}
+		}
+		return &negNode{pos: op.pos, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *conditionParser) parsePrimary() (conditionNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, syntaxError(closing.pos, "expected ')' to close '(' at position %d, got %s", tok.pos+1, describeToken(closing))
+		}
+		return node, nil
+	case tokenLBracket:
+		elements, err := p.parseList(tokenRBracket, tok)
+		if err != nil {
+			return nil, err
+		}
+		return &arrayNode{pos: tok.pos, elements: elements}, nil
+	case tokenVariable:
+		segments, _ := parseVariablePath(tok.text)
+		return &variableNode{pos: tok.pos, path: tok.text, segments: segments}, nil
+	case tokenIdent:
+		if p.peek().kind == tokenLParen {
+			open := p.next()
+			args, err := p.parseList(tokenRParen, open)
+			if err != nil {
+				return nil, err
+			}
+			return &callNode{pos: tok.pos, name: tok.text, args: args}, nil
+		}
+		return &literalNode{pos: tok.pos, value: tok.text}, nil
+	case tokenString:
+		return &literalNode{pos: tok.pos, value: tok.text}, nil
+	case tokenNumber:
+		num, _ := strconv.ParseFloat(tok.text, 64)
+		return &literalNode{pos: tok.pos, value: num}, nil
+	case tokenTrue:
+		return &literalNode{pos: tok.pos, value: true}, nil
+	case tokenFalse:
+		return &literalNode{pos: tok.pos, value: false}, nil
+	case tokenNull:
+		return &literalNode{pos: tok.pos, value: nil}, nil
+	default:
+		return nil, syntaxError(tok.pos, "expected value, got %s", describeToken(tok))
+	}
+}
+
+// parseList 解析逗号分隔的表达式列表，直到 closing
+func (p *conditionParser) parseList(closing tokenKind, open token) ([]conditionNode, error) {
+	var items []conditionNode
+	if p.peek().kind == closing {
+		p.next()
+		return items, nil
+	}
+	for {
+		item, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		tok := p.next()
+		if tok.kind == closing {
+			return items, nil
+		}
+		if tok.kind != tokenComma {
+			return nil, syntaxError(tok.pos, "expected ',' or %s to close %s at position %d, got %s", closing, open.kind, open.pos+1, describeToken(tok))
+		}
+	}
+}
+
+func describeToken(tok token) string {
+	switch tok.kind {
+	case tokenIdent, tokenNumber:
+		return fmt.Sprintf("'%s'", tok.text)
+	case tokenString:
+		return fmt.Sprintf("string %q", tok.text)
+	case tokenVariable:
+		return fmt.Sprintf("variable ${%s}", tok.text)
+	default:
+		return tok.kind.String()
+	}
+}
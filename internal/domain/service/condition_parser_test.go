@@ -0,0 +1,210 @@
+package domain_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+)
+
+func newTestEvaluator(input string) *ConditionEvaluator {
+	instance := &instance_aggregate.WorkflowInstance{Input: json.RawMessage(input), InitiatorID: 7}
+	return NewConditionEvaluator(context.Background(), instance, nil)
+}
+
+func TestConditionPrecedence(t *testing.T) {
+	e := newTestEvaluator(`{"a": 2}`)
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{`true || false && false`, true},
+		{`(true || false) && false`, false},
+		{`1 + 2 * 3 == 7`, true},
+		{`(1 + 2) * 3 == 9`, true},
+		{`10 - 4 - 3 == 3`, true},
+		{`7 % 4 + 1 == 4`, true},
+		{`-${a} + 5 == 3`, true},
+		{`${a} * 1.5 > 2.9`, true},
+		{`!${a} == 1`, true},
+		{`!(${a} == 2) || ${a} >= 3`, false},
+		{`!true && false || true`, true},
+		{``, true},
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition)
+		if err != nil {
+			t.Errorf("Evaluate(%q) error = %v", tt.condition, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+}
+
+// formatNode 以前缀形式输出语法树，用于断言运算符的结合方式
+func formatNode(n conditionNode) string {
+	switch n := n.(type) {
+	case *literalNode:
+		return fmt.Sprintf("%v", n.value)
+	case *variableNode:
+		return "${" + n.path + "}"
+	case *notNode:
+		return "(! " + formatNode(n.operand) + ")"
+	case *negNode:
+		return "(- " + formatNode(n.operand) + ")"
+	case *logicalNode:
+		op := "&&"
+		if n.op == tokenOr {
+			op = "||"
+		}
+		return "(" + op + " " + formatNode(n.left) + " " + formatNode(n.right) + ")"
+	case *compareNode:
+		return "(" + n.op + " " + formatNode(n.left) + " " + formatNode(n.right) + ")"
+	case *arithNode:
+		return "(" + n.op + " " + formatNode(n.left) + " " + formatNode(n.right) + ")"
+	default:
+		return fmt.Sprintf("%T", n)
+	}
+}
+
+// TestParseConditionNotPrecedence 固定 "!" 的优先级：低于比较和算术运算，高于 && 和 ||
+func TestParseConditionNotPrecedence(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      string
+	}{
+		{`!${a} == 1`, `(! (== ${a} 1))`},
+		{`!${a} + 1 > 2`, `(! (> (+ ${a} 1) 2))`},
+		{`!${a} && ${b}`, `(&& (! ${a}) ${b})`},
+		{`true || !false && false`, `(|| true (&& (! false) false))`},
+		{`!!${a}`, `(! (! ${a}))`},
+		{`!(${a}) == 1`, `(! (== ${a} 1))`},
+		{`-${a} == 1`, `(== (- ${a}) 1)`},
+	}
+	for _, tt := range tests {
+		node, err := parseCondition(tt.condition)
+		if err != nil {
+			t.Errorf("parseCondition(%q) error = %v", tt.condition, err)
+			continue
+		}
+		if got := formatNode(node); got != tt.want {
+			t.Errorf("parseCondition(%q) = %s, want %s", tt.condition, got, tt.want)
+		}
+	}
+}
+
+func TestConditionInOperator(t *testing.T) {
+	e := newTestEvaluator(`{"level": "A", "tags": ["x", "y"], "amount": 3}`)
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{`${level} in ["A", "B"]`, true},
+		{`${level} in ["B", "C"]`, false},
+		{`"x" in ${tags}`, true},
+		{`"z" in ${tags}`, false},
+		{`${amount} in [1, 2, 3]`, true},
+		{`'ab' in 'cabd'`, true},
+		{`${level} in null`, false},
+		{`!(${level} in [])`, true},
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition)
+		if err != nil {
+			t.Errorf("Evaluate(%q) error = %v", tt.condition, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+}
+
+func TestConditionNestedPaths(t *testing.T) {
+	e := newTestEvaluator(`{"case": {"level": "A", "files": [{"name": "a.pdf"}, {"name": "b.pdf"}]}, "matrix": [[1, 2], [3, 4]]}`)
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{`${input.case.level} == 'A'`, true},
+		{`${input.case.files[1].name} == 'b.pdf'`, true},
+		{`${input.matrix[1][0]} == 3`, true},
+		{`len(${input.case.files}) == 2`, true},
+		{`${initiator} == 7`, true},
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.condition)
+		if err != nil {
+			t.Errorf("Evaluate(%q) error = %v", tt.condition, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+
+	for _, condition := range []string{
+		`${input.case.files[5].name} == 'a.pdf'`,
+		`${input.case.level.code} == 'A'`,
+		`${input.case.level[0]} == 'A'`,
+		`${input.missing} == 1`,
+		`${missing} == 1`,
+	} {
+		if _, err := e.Evaluate(condition); err == nil {
+			t.Errorf("Evaluate(%q) expected error", condition)
+		}
+	}
+}
+
+func TestConditionSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		condition string
+		pos       int
+	}{
+		{`${a} = 1`, 6},
+		{`${a} & 1`, 6},
+		{`(${a} == 1`, 11},
+		{`${a} == `, 9},
+		{`'abc`, 1},
+		{`${} == 1`, 1},
+		{`${a == 1`, 1},
+		{`${a} == 1 2`, 11},
+		{`[1, 2 == 1`, 11},
+		{`${a} # 1`, 6},
+	}
+	for _, tt := range tests {
+		err := ValidateCondition(tt.condition)
+		var syntaxErr *ConditionSyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("ValidateCondition(%q) error = %v, want syntax error", tt.condition, err)
+			continue
+		}
+		if syntaxErr.Pos != tt.pos {
+			t.Errorf("ValidateCondition(%q) position = %d, want %d (%v)", tt.condition, syntaxErr.Pos, tt.pos, err)
+		}
+	}
+}
+
+func TestConditionTypeErrors(t *testing.T) {
+	for _, condition := range []string{
+		`len(1) > 0`,
+		`[1] > 2`,
+		`true + 1 == 2`,
+		`${a} in 1`,
+		`unknown(${a})`,
+		`contains(${a})`,
+		`matches(${a}, '[')`,
+		`date('not a date') > now()`,
+	} {
+		err := ValidateCondition(condition)
+		var typeErr *ConditionTypeError
+		if !errors.As(err, &typeErr) {
+			t.Errorf("ValidateCondition(%q) error = %v, want type error", condition, err)
+		}
+	}
+}
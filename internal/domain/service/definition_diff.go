@@ -0,0 +1,103 @@
+package domain_service
+
+import (
+	"encoding/json"
+	command "jxt-evidence-system/process-management/internal/application/command"
+	"reflect"
+	"sort"
+)
+
+// CompareDefinitions 比较两个工作流定义，步骤按ID匹配（并行任务与普通步骤一并比较）
+func (s *WorkflowDomainService) CompareDefinitions(from, to *WorkflowDefinitionStruct) *command.WorkflowVersionDiff {
+	diff := &command.WorkflowVersionDiff{
+		ChangedFields: []string{},
+		AddedSteps:    []string{},
+		RemovedSteps:  []string{},
+		ChangedSteps:  []command.StepChange{},
+	}
+
+	if from.Name != to.Name {
+		diff.ChangedFields = append(diff.ChangedFields, "name")
+	}
+	if from.Description != to.Description {
+		diff.ChangedFields = append(diff.ChangedFields, "description")
+	}
+	if !reflect.DeepEqual(stepIDs(from.Steps), stepIDs(to.Steps)) {
+		diff.ChangedFields = append(diff.ChangedFields, "stepOrder")
+	}
+
+	fromSteps := flattenSteps(from.Steps)
+	toSteps := flattenSteps(to.Steps)
+
+	for _, id := range sortedStepIDs(fromSteps) {
+		if _, ok := toSteps[id]; !ok {
+			diff.RemovedSteps = append(diff.RemovedSteps, id)
+		}
+	}
+	for _, id := range sortedStepIDs(toSteps) {
+		fromStep, ok := fromSteps[id]
+		if !ok {
+			diff.AddedSteps = append(diff.AddedSteps, id)
+			continue
+		}
+		if fields := changedStepFields(fromStep, toSteps[id]); len(fields) > 0 {
+			diff.ChangedSteps = append(diff.ChangedSteps, command.StepChange{StepID: id, Fields: fields})
+		}
+	}
+	return diff
+}
+
+func stepIDs(steps []StepDefinition) []string {
+	ids := make([]string, 0, len(steps))
+	for _, step := range steps {
+		ids = append(ids, step.ID)
+	}
+	return ids
+}
+
+// flattenSteps 按ID展开步骤及其并行任务
+func flattenSteps(steps []StepDefinition) map[string]StepDefinition {
+	result := make(map[string]StepDefinition)
+	for _, step := range steps {
+		result[step.ID] = step
+		for id, task := range flattenSteps(step.ParallelTasks) {
+			result[id] = task
+		}
+	}
+	return result
+}
+
+func sortedStepIDs(steps map[string]StepDefinition) []string {
+	ids := make([]string, 0, len(steps))
+	for id := range steps {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// changedStepFields 返回两个步骤间发生变化的 JSON 字段名，并行任务单独比较不计入父步骤
+func changedStepFields(from, to StepDefinition) []string {
+	from.ParallelTasks, to.ParallelTasks = nil, nil
+	fromFields := stepFields(from)
+	toFields := stepFields(to)
+
+	var fields []string
+	for name, value := range toFields {
+		if !reflect.DeepEqual(fromFields[name], value) {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func stepFields(step StepDefinition) map[string]interface{} {
+	fields := make(map[string]interface{})
+	data, err := json.Marshal(step)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
@@ -0,0 +1,334 @@
+package domain_service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 步骤类型
+const (
+	StepTypeUserTask = "userTask" // 用户任务
+	StepTypeProcess  = "process"  // 自动化步骤
+	StepTypeParallel = "parallel" // 并行步骤
+	StepTypeComplete = "complete" // 结束步骤
+
+	StepTypeSubProcess = "subProcess" // 子流程：启动被引用工作流的子实例并等待其结束
+
+	StepTypeExclusiveGateway = "exclusiveGateway" // 排他网关：执行第一个条件满足的分支
+	StepTypeInclusiveGateway = "inclusiveGateway" // 包容网关：执行所有条件满足的分支
+	StepTypeParallelGateway  = "parallelGateway"  // 并行网关：执行全部分支
+)
+
+// 定义校验错误码
+const (
+	DefinitionErrInvalidJSON     = "invalid_json"
+	DefinitionErrNoSteps         = "no_steps"
+	DefinitionErrMissingID       = "missing_id"
+	DefinitionErrDuplicateID     = "duplicate_id"
+	DefinitionErrUnknownType     = "unknown_type"
+	DefinitionErrUnknownStep     = "unknown_step"
+	DefinitionErrEmptyParallel   = "empty_parallel"
+	DefinitionErrEmptyGateway    = "empty_gateway"
+	DefinitionErrInvalidCond     = "invalid_condition"
+	DefinitionErrInvalidTimeout  = "invalid_timeout_policy"
+	DefinitionErrInvalidMulti    = "invalid_multi_instance"
+	DefinitionErrInvalidReject   = "invalid_reject_policy"
+	DefinitionErrInvalidSubProc  = "invalid_sub_process"
+	DefinitionErrInvalidAssign   = "invalid_assignee"
+	DefinitionErrInvalidDelegate = "invalid_delegate"
+	DefinitionErrUnreachable     = "unreachable_step"
+	DefinitionErrNoExit          = "no_exit"
+)
+
+// DefinitionError 工作流定义校验错误
+type DefinitionError struct {
+	Path    string `json:"path"`    // JSON 路径，如 $.steps[2].nextSteps[0]
+	Code    string `json:"code"`    // 错误码
+	Message string `json:"message"` // 错误描述
+}
+
+// DefinitionErrors 校验错误列表
+type DefinitionErrors []DefinitionError
+
+func (e DefinitionErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, item := range e {
+		messages = append(messages, fmt.Sprintf("%s: %s", item.Path, item.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// DefinitionValidator 工作流定义结构校验器
+type DefinitionValidator struct {
+	stepTypes     map[string]bool // 顶层步骤允许的类型
+	parallelTypes map[string]bool // 并行任务允许的类型
+}
+
+// NewDefinitionValidator 创建定义校验器
+func NewDefinitionValidator() *DefinitionValidator {
+	return &DefinitionValidator{
+		stepTypes: map[string]bool{
+			StepTypeUserTask: true,
+			StepTypeProcess:  true,
+			StepTypeParallel: true,
+			StepTypeComplete: true,
+
+			StepTypeSubProcess: true,
+
+			StepTypeExclusiveGateway: true,
+			StepTypeInclusiveGateway: true,
+			StepTypeParallelGateway:  true,
+		},
+		parallelTypes: map[string]bool{
+			StepTypeUserTask: true,
+			StepTypeProcess:  true,
+		},
+	}
+}
+
+// Validate 解析并校验定义 JSON
+func (v *DefinitionValidator) Validate(raw string) (*WorkflowDefinitionStruct, DefinitionErrors) {
+	definition, err := ParseDefinition(raw)
+	if err != nil {
+		return nil, err
+	}
+	return definition, v.ValidateDefinition(definition)
+}
+
+// ParseDefinition 解析定义 JSON，仅校验 JSON 语法和字段类型
+func ParseDefinition(raw string) (*WorkflowDefinitionStruct, DefinitionErrors) {
+	var definition WorkflowDefinitionStruct
+	if err := json.Unmarshal([]byte(raw), &definition); err != nil {
+		path := "$"
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			path = jsonFieldPath(typeErr.Field)
+		}
+		return nil, DefinitionErrors{{Path: path, Code: DefinitionErrInvalidJSON, Message: err.Error()}}
+	}
+	return &definition, nil
+}
+
+// jsonFieldPath 将 encoding/json 的字段路径（steps.0.id）转换为 JSON 路径（$.steps[0].id）
+func jsonFieldPath(field string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, part := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(part); err == nil {
+			b.WriteString("[" + part + "]")
+		} else {
+			b.WriteString("." + part)
+		}
+	}
+	return b.String()
+}
+
+// ValidateDefinition 校验已解析的定义
+func (v *DefinitionValidator) ValidateDefinition(definition *WorkflowDefinitionStruct) DefinitionErrors {
+	errs := DefinitionErrors{}
+	if len(definition.Steps) == 0 {
+		return append(errs, DefinitionError{Path: "$.steps", Code: DefinitionErrNoSteps, Message: "workflow has no steps"})
+	}
+
+	// 顶层步骤ID用于 nextSteps 引用，并行任务ID只参与唯一性检查
+	topLevel := make(map[string]int)
+	seen := make(map[string]string)
+	for i, step := range definition.Steps {
+		path := fmt.Sprintf("$.steps[%d]", i)
+		errs = v.validateStep(errs, step, path, v.stepTypes, seen)
+		if step.ID != "" {
+			if _, ok := topLevel[step.ID]; !ok {
+				topLevel[step.ID] = i
+			}
+		}
+		for j, task := range step.ParallelTasks {
+			errs = v.validateStep(errs, task, fmt.Sprintf("%s.parallelTasks[%d]", path, j), v.parallelTypes, seen)
+		}
+		if step.Type == StepTypeParallel && len(step.ParallelTasks) == 0 {
+			errs = append(errs, DefinitionError{Path: path + ".parallelTasks", Code: DefinitionErrEmptyParallel, Message: fmt.Sprintf("parallel step %s has no parallelTasks", step.ID)})
+		}
+		if IsGateway(&step) && len(step.NextSteps) == 0 {
+			errs = append(errs, DefinitionError{Path: path + ".nextSteps", Code: DefinitionErrEmptyGateway, Message: fmt.Sprintf("gateway %s has no nextSteps", step.ID)})
+		}
+	}
+
+	// 引用检查
+	for i, step := range definition.Steps {
+		path := fmt.Sprintf("$.steps[%d]", i)
+		for k, next := range step.NextSteps {
+			if _, ok := topLevel[next]; !ok {
+				errs = append(errs, DefinitionError{Path: fmt.Sprintf("%s.nextSteps[%d]", path, k), Code: DefinitionErrUnknownStep, Message: fmt.Sprintf("next step %s not found", next)})
+			}
+		}
+		if step.OnTimeout != nil && step.OnTimeout.Action == TimeoutActionJump {
+			if _, ok := topLevel[step.OnTimeout.StepID]; !ok {
+				errs = append(errs, DefinitionError{Path: path + ".onTimeout.stepId", Code: DefinitionErrUnknownStep, Message: fmt.Sprintf("timeout jump target %s not found", step.OnTimeout.StepID)})
+			}
+		}
+		if step.Reject != nil {
+			for k, target := range step.Reject.Targets {
+				targetPath := fmt.Sprintf("%s.reject.targets[%d]", path, k)
+				if j, ok := topLevel[target]; !ok {
+					errs = append(errs, DefinitionError{Path: targetPath, Code: DefinitionErrUnknownStep, Message: fmt.Sprintf("reject target %s not found", target)})
+				} else if target == step.ID || definition.Steps[j].Type != StepTypeUserTask {
+					errs = append(errs, DefinitionError{Path: targetPath, Code: DefinitionErrInvalidReject, Message: fmt.Sprintf("reject target %s must be another %s step", target, StepTypeUserTask)})
+				}
+			}
+		}
+	}
+
+	return append(errs, v.validateFlow(definition, topLevel)...)
+}
+
+// validateStep 校验单个步骤（或并行任务）自身的字段
+func (v *DefinitionValidator) validateStep(errs DefinitionErrors, step StepDefinition, path string, allowedTypes map[string]bool, seen map[string]string) DefinitionErrors {
+	if step.ID == "" {
+		errs = append(errs, DefinitionError{Path: path + ".id", Code: DefinitionErrMissingID, Message: "step id is required"})
+	} else if first, ok := seen[step.ID]; ok {
+		errs = append(errs, DefinitionError{Path: path + ".id", Code: DefinitionErrDuplicateID, Message: fmt.Sprintf("step id %s is already used at %s", step.ID, first)})
+	} else {
+		seen[step.ID] = path
+	}
+
+	if !allowedTypes[step.Type] {
+		errs = append(errs, DefinitionError{Path: path + ".type", Code: DefinitionErrUnknownType, Message: fmt.Sprintf("unknown step type '%s'", step.Type)})
+	}
+
+	if err := ValidateCondition(step.Condition); err != nil {
+		errs = append(errs, DefinitionError{Path: path + ".condition", Code: DefinitionErrInvalidCond, Message: err.Error()})
+	}
+
+	if step.OnTimeout != nil {
+		switch step.OnTimeout.Action {
+		case "", TimeoutActionNotify, TimeoutActionApprove, TimeoutActionReject, TimeoutActionJump:
+		case TimeoutActionEscalate:
+			if step.OnTimeout.Assignee == "" {
+				errs = append(errs, DefinitionError{Path: path + ".onTimeout.assignee", Code: DefinitionErrInvalidTimeout, Message: "escalate requires an assignee"})
+			}
+		default:
+			errs = append(errs, DefinitionError{Path: path + ".onTimeout.action", Code: DefinitionErrInvalidTimeout, Message: fmt.Sprintf("unknown timeout action '%s'", step.OnTimeout.Action)})
+		}
+	}
+
+	if config, err := ParseMultiInstance(&step); err != nil {
+		errs = append(errs, DefinitionError{Path: path + ".params.multiInstance", Code: DefinitionErrInvalidMulti, Message: err.Error()})
+	} else if config != nil && step.Type != StepTypeUserTask {
+		errs = append(errs, DefinitionError{Path: path + ".params.multiInstance", Code: DefinitionErrInvalidMulti, Message: fmt.Sprintf("multiInstance is only supported on %s steps", StepTypeUserTask)})
+	}
+
+	if assignee, ok := step.Params["assignee"].(string); ok && IsAssigneeExpression(assignee) {
+		if err := validateAssigneeExpression(assignee); err != nil {
+			errs = append(errs, DefinitionError{Path: path + ".params.assignee", Code: DefinitionErrInvalidAssign, Message: err.Error()})
+		}
+	}
+
+	if step.Reject != nil && step.Type != StepTypeUserTask {
+		errs = append(errs, DefinitionError{Path: path + ".reject", Code: DefinitionErrInvalidReject, Message: fmt.Sprintf("reject policy is only supported on %s steps", StepTypeUserTask)})
+	}
+
+	if step.MaxDelegateHops < 0 {
+		errs = append(errs, DefinitionError{Path: path + ".maxDelegateHops", Code: DefinitionErrInvalidDelegate, Message: "maxDelegateHops must not be negative"})
+	}
+	if (step.AllowDelegate != nil || step.MaxDelegateHops != 0) && step.Type != StepTypeUserTask {
+		errs = append(errs, DefinitionError{Path: path + ".allowDelegate", Code: DefinitionErrInvalidDelegate, Message: fmt.Sprintf("delegation settings are only supported on %s steps", StepTypeUserTask)})
+	}
+	return validateSubProcess(errs, step, path)
+}
+
+// validateFlow 检查不可达步骤和无法结束的循环
+func (v *DefinitionValidator) validateFlow(definition *WorkflowDefinitionStruct, topLevel map[string]int) DefinitionErrors {
+	steps := definition.Steps
+	edges, exits := flowGraph(definition, topLevel)
+
+	// 从第一步出发的可达步骤
+	reachable := make([]bool, len(steps))
+	queue := []int{0}
+	reachable[0] = true
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, j := range edges[i] {
+			if !reachable[j] {
+				reachable[j] = true
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	// 能到达结束点的步骤（反向传播）
+	canExit := make([]bool, len(steps))
+	copy(canExit, exits)
+	for changed := true; changed; {
+		changed = false
+		for i := range steps {
+			if canExit[i] {
+				continue
+			}
+			for _, j := range edges[i] {
+				if canExit[j] {
+					canExit[i] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	errs := DefinitionErrors{}
+	for i, step := range steps {
+		path := fmt.Sprintf("$.steps[%d]", i)
+		if !reachable[i] {
+			errs = append(errs, DefinitionError{Path: path, Code: DefinitionErrUnreachable, Message: fmt.Sprintf("step %s is unreachable from the first step", step.ID)})
+		} else if !canExit[i] {
+			errs = append(errs, DefinitionError{Path: path, Code: DefinitionErrNoExit, Message: fmt.Sprintf("step %s is in a cycle with no exit", step.ID)})
+		}
+	}
+	return errs
+}
+
+// flowGraph 计算顶层步骤之间的流转边，以及执行到各步骤后流程是否可能结束
+// 流转规则与 FindNextStep 一致：有 nextSteps 时按其跳转，否则顺序执行，带条件的后续步骤可能被跳过；
+// 网关的每个 nextSteps 都是一条边，排他/包容网关没有满足条件的分支时实例失败，不视为结束
+func flowGraph(definition *WorkflowDefinitionStruct, topLevel map[string]int) ([][]int, []bool) {
+	steps := definition.Steps
+	edges := make([][]int, len(steps))
+	exits := make([]bool, len(steps))
+
+	for i, step := range steps {
+		if step.Type == StepTypeComplete {
+			exits[i] = true
+			continue
+		}
+		if len(step.NextSteps) > 0 {
+			allConditional := true
+			for _, next := range step.NextSteps {
+				j, ok := topLevel[next]
+				if !ok {
+					continue
+				}
+				edges[i] = append(edges[i], j)
+				if steps[j].Condition == "" {
+					allConditional = false
+				}
+			}
+			// 所有分支条件都不满足时流程结束
+			exits[i] = allConditional && !IsGateway(&step)
+			continue
+		}
+
+		j := i + 1
+		for ; j < len(steps); j++ {
+			edges[i] = append(edges[i], j)
+			if steps[j].Condition == "" {
+				break
+			}
+		}
+		if j >= len(steps) {
+			exits[i] = true
+		}
+	}
+	return edges, exits
+}
@@ -0,0 +1,127 @@
+package domain_service
+
+import (
+	"strings"
+	"testing"
+)
+
+// expectDefinitionError 校验定义只产生一个指定路径和错误码的错误
+func expectDefinitionError(t *testing.T, raw, path, code string) {
+	t.Helper()
+	_, errs := NewDefinitionValidator().Validate(raw)
+	if len(errs) != 1 {
+		t.Fatalf("Validate() errors = %+v, want one %s at %s", errs, code, path)
+	}
+	if errs[0].Path != path || errs[0].Code != code {
+		t.Errorf("Validate() error = %s %s, want %s %s", errs[0].Path, errs[0].Code, path, code)
+	}
+}
+
+func TestValidateDefinitionAcceptsValidFlow(t *testing.T) {
+	definition, errs := NewDefinitionValidator().Validate(`{"steps":[` +
+		`{"id":"apply","type":"userTask","timeout":60,"onTimeout":{"action":"jump","stepId":"end"},"nextSteps":["fast","review"]},` +
+		`{"id":"fast","type":"process","condition":"${amount} < 100","nextSteps":["end"]},` +
+		`{"id":"review","type":"parallel","parallelTasks":[{"id":"legal","type":"userTask"},{"id":"finance","type":"process"}]},` +
+		`{"id":"end","type":"complete"}]}`)
+	if len(errs) != 0 {
+		t.Fatalf("Validate() errors = %+v", errs)
+	}
+	if definition == nil || len(definition.Steps) != 4 {
+		t.Errorf("Validate() definition = %+v, want 4 steps", definition)
+	}
+}
+
+func TestValidateDefinitionStructureErrors(t *testing.T) {
+	tests := []struct {
+		name, raw, path, code string
+	}{
+		{"no steps", `{"steps":[]}`, "$.steps", DefinitionErrNoSteps},
+		{"field type", `{"steps":[{"id":1}]}`, "$.steps[0].id", DefinitionErrInvalidJSON},
+		{"missing id", `{"steps":[{"type":"userTask"},{"id":"end","type":"complete"}]}`, "$.steps[0].id", DefinitionErrMissingID},
+		{"duplicate id", `{"steps":[{"id":"a","type":"userTask"},{"id":"a","type":"complete"}]}`, "$.steps[1].id", DefinitionErrDuplicateID},
+		{"unknown type", `{"steps":[{"id":"a","type":"script"},{"id":"end","type":"complete"}]}`, "$.steps[0].type", DefinitionErrUnknownType},
+		{"parallel task type", `{"steps":[{"id":"p","type":"parallel","parallelTasks":[{"id":"t","type":"complete"}]},{"id":"end","type":"complete"}]}`, "$.steps[0].parallelTasks[0].type", DefinitionErrUnknownType},
+		{"empty parallel", `{"steps":[{"id":"p","type":"parallel"},{"id":"end","type":"complete"}]}`, "$.steps[0].parallelTasks", DefinitionErrEmptyParallel},
+		{"unknown next step", `{"steps":[{"id":"a","type":"userTask","nextSteps":["missing","end"]},{"id":"end","type":"complete"}]}`, "$.steps[0].nextSteps[0]", DefinitionErrUnknownStep},
+		{"invalid condition", `{"steps":[{"id":"a","type":"userTask"},{"id":"b","type":"userTask","condition":"${x} >"},{"id":"end","type":"complete"}]}`, "$.steps[1].condition", DefinitionErrInvalidCond},
+		{"escalate without assignee", `{"steps":[{"id":"a","type":"userTask","timeout":60,"onTimeout":{"action":"escalate"}},{"id":"end","type":"complete"}]}`, "$.steps[0].onTimeout.assignee", DefinitionErrInvalidTimeout},
+		{"unknown timeout action", `{"steps":[{"id":"a","type":"userTask","timeout":60,"onTimeout":{"action":"retry"}},{"id":"end","type":"complete"}]}`, "$.steps[0].onTimeout.action", DefinitionErrInvalidTimeout},
+		{"unknown jump target", `{"steps":[{"id":"a","type":"userTask","timeout":60,"onTimeout":{"action":"jump","stepId":"missing"}},{"id":"end","type":"complete"}]}`, "$.steps[0].onTimeout.stepId", DefinitionErrUnknownStep},
+		{"unreachable step", `{"steps":[{"id":"a","type":"userTask","nextSteps":["end"]},{"id":"orphan","type":"userTask"},{"id":"end","type":"complete"}]}`, "$.steps[1]", DefinitionErrUnreachable},
+		{"cycle without exit", `{"steps":[{"id":"a","type":"userTask","nextSteps":["b","end"]},{"id":"b","type":"userTask","nextSteps":["b"]},{"id":"end","type":"complete"}]}`, "$.steps[1]", DefinitionErrNoExit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectDefinitionError(t, tt.raw, tt.path, tt.code)
+		})
+	}
+}
+
+func TestParseDefinitionOnlyChecksSyntax(t *testing.T) {
+	if _, errs := ParseDefinition(`{"steps":[{"id":"a","type":"unknown","nextSteps":["missing"]}]}`); len(errs) != 0 {
+		t.Errorf("ParseDefinition() errors = %+v, want none for a structurally invalid definition", errs)
+	}
+	_, errs := ParseDefinition(`{"steps":[`)
+	if len(errs) != 1 || errs[0].Path != "$" || errs[0].Code != DefinitionErrInvalidJSON {
+		t.Errorf("ParseDefinition() errors = %+v, want one invalid_json at $", errs)
+	}
+}
+
+func TestDefinitionErrorsMessage(t *testing.T) {
+	_, errs := NewDefinitionValidator().Validate(`{"steps":[{"id":"a","type":"script","nextSteps":["missing"]}]}`)
+	message := errs.Error()
+	for _, want := range []string{"$.steps[0].type: unknown step type 'script'", "$.steps[0].nextSteps[0]: next step missing not found"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("Error() = %q, want it to contain %q", message, want)
+		}
+	}
+}
+
+func TestValidateDefinitionGateways(t *testing.T) {
+	expectDefinitionError(t, `{"steps":[`+
+		`{"id":"split","type":"parallelGateway","nextSteps":["a","b"]},`+
+		`{"id":"a","type":"userTask","nextSteps":["join"]},`+
+		`{"id":"b","type":"userTask","nextSteps":["join"]},`+
+		`{"id":"join","type":"parallelGateway","nextSteps":["route"]},`+
+		`{"id":"route","type":"exclusiveGateway"},`+
+		`{"id":"end","type":"complete"}]}`,
+		"$.steps[4].nextSteps", DefinitionErrEmptyGateway)
+}
+
+func TestValidateDefinitionMultiInstance(t *testing.T) {
+	expectDefinitionError(t, `{"steps":[`+
+		`{"id":"review","type":"userTask","params":{"multiInstance":{"assignees":"${input.reviewers}","completion":"ratio:1.5"}}},`+
+		`{"id":"end","type":"complete"}]}`,
+		"$.steps[0].params.multiInstance", DefinitionErrInvalidMulti)
+}
+
+func TestValidateDefinitionRejectTargets(t *testing.T) {
+	expectDefinitionError(t, `{"steps":[`+
+		`{"id":"apply","type":"userTask"},`+
+		`{"id":"review","type":"userTask","reject":{"targets":["apply","missing"],"toInitiator":true}},`+
+		`{"id":"end","type":"complete"}]}`,
+		"$.steps[1].reject.targets[1]", DefinitionErrUnknownStep)
+}
+
+func TestValidateDefinitionSubProcess(t *testing.T) {
+	expectDefinitionError(t, `{"steps":[`+
+		`{"id":"apply","type":"userTask"},`+
+		`{"id":"custody","type":"subProcess","subProcess":{"input":{"caseId":"${input.caseId}"}}},`+
+		`{"id":"end","type":"complete"}]}`,
+		"$.steps[1].subProcess.workflow", DefinitionErrInvalidSubProc)
+}
+
+func TestValidateDefinitionAssigneeExpressions(t *testing.T) {
+	expectDefinitionError(t, `{"steps":[`+
+		`{"id":"review","type":"userTask","params":{"assignee":"role:法制员@org:${input.orgId}"}},`+
+		`{"id":"approve","type":"userTask","params":{"assignee":"orgHead:parent(${input.orgId}"}},`+
+		`{"id":"end","type":"complete"}]}`,
+		"$.steps[1].params.assignee", DefinitionErrInvalidAssign)
+}
+
+func TestValidateDefinitionDelegation(t *testing.T) {
+	expectDefinitionError(t, `{"steps":[`+
+		`{"id":"review","type":"userTask","allowDelegate":true,"maxDelegateHops":-1},`+
+		`{"id":"end","type":"complete"}]}`,
+		"$.steps[0].maxDelegateHops", DefinitionErrInvalidDelegate)
+}
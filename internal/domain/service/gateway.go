@@ -0,0 +1,82 @@
+package domain_service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+)
+
+// IsGateway 判断步骤是否为网关
+func IsGateway(step *StepDefinition) bool {
+	switch step.Type {
+	case StepTypeExclusiveGateway, StepTypeInclusiveGateway, StepTypeParallelGateway:
+		return true
+	}
+	return false
+}
+
+// IsJoinGateway 判断网关是否为汇聚网关（有多条流入边）
+// 排他网关只做路由，任一分支到达即继续，不做汇聚
+func (s *WorkflowDomainService) IsJoinGateway(step *StepDefinition, definition *WorkflowDefinitionStruct) bool {
+	if step.Type != StepTypeInclusiveGateway && step.Type != StepTypeParallelGateway {
+		return false
+	}
+	return s.CountIncoming(step.ID, definition) > 1
+}
+
+// CountIncoming 统计指向步骤的流转边数量
+func (s *WorkflowDomainService) CountIncoming(stepID string, definition *WorkflowDefinitionStruct) int {
+	topLevel := make(map[string]int, len(definition.Steps))
+	for i, step := range definition.Steps {
+		if _, ok := topLevel[step.ID]; !ok {
+			topLevel[step.ID] = i
+		}
+	}
+	target, ok := topLevel[stepID]
+	if !ok {
+		return 0
+	}
+
+	edges, _ := flowGraph(definition, topLevel)
+	count := 0
+	for _, targets := range edges {
+		for _, j := range targets {
+			if j == target {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// SelectBranches 计算网关需要执行的分支，分支条件写在目标步骤上
+// 排他网关返回第一个条件满足的分支，包容网关返回所有条件满足的分支，并行网关返回全部分支
+func (s *WorkflowDomainService) SelectBranches(ctx context.Context, gateway *StepDefinition, definition *WorkflowDefinitionStruct, instance *instance_aggregate.WorkflowInstance) ([]*StepDefinition, error) {
+	vars := s.NewVariableSnapshot(ctx, instance)
+	branches := make([]*StepDefinition, 0, len(gateway.NextSteps))
+	for _, nextStepID := range gateway.NextSteps {
+		nextStep := s.FindStepByID(nextStepID, definition)
+		if nextStep == nil {
+			return nil, fmt.Errorf("gateway %s: next step not found: %s", gateway.ID, nextStepID)
+		}
+
+		if gateway.Type != StepTypeParallelGateway && nextStep.Condition != "" {
+			if !s.EvaluateConditionWithSnapshot(nextStep.Condition, instance, vars) {
+				log.Printf("[EngineService] Gateway %s branch condition not met for %s: %s", gateway.ID, nextStep.ID, nextStep.Condition)
+				continue
+			}
+		}
+
+		branches = append(branches, nextStep)
+		if gateway.Type == StepTypeExclusiveGateway {
+			break
+		}
+	}
+
+	if len(branches) == 0 {
+		return nil, fmt.Errorf("gateway %s: no branch condition satisfied", gateway.ID)
+	}
+	return branches, nil
+}
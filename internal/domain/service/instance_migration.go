@@ -0,0 +1,69 @@
+package domain_service
+
+import (
+	"fmt"
+	command "jxt-evidence-system/process-management/internal/application/command"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	"sort"
+)
+
+// ValidateStepMapping 校验迁移计划中的步骤映射，目标步骤必须存在于目标版本
+func (s *WorkflowDomainService) ValidateStepMapping(mapping map[string]string, target *WorkflowDefinitionStruct) error {
+	fromKeys := make([]string, 0, len(mapping))
+	for from := range mapping {
+		fromKeys = append(fromKeys, from)
+	}
+	sort.Strings(fromKeys)
+
+	for _, from := range fromKeys {
+		if to := mapping[from]; s.FindStepOrParallelTaskByID(to, target) == nil {
+			return fmt.Errorf("step mapping %s -> %s: target step not found", from, to)
+		}
+	}
+	return nil
+}
+
+// PlanTaskMigration 为实例尚未处理的任务（待处理，以及顺序会签、前加签中等待轮到的任务）计算目标步骤
+// 映射中未列出的步骤按相同ID映射；找不到目标步骤的任务记入错误列表
+func (s *WorkflowDomainService) PlanTaskMigration(tasks []*task_aggregate.Task, mapping map[string]string, target *WorkflowDefinitionStruct) ([]command.TaskMigration, []string) {
+	plan := []command.TaskMigration{}
+	problems := []string{}
+	for _, t := range tasks {
+		if !t.IsOpen() {
+			continue
+		}
+		toKey, ok := mapping[t.TaskKey]
+		if !ok {
+			toKey = t.TaskKey
+		}
+		if s.FindStepOrParallelTaskByID(toKey, target) == nil {
+			problems = append(problems, fmt.Sprintf("%s task %s (step %s) has no target step", t.Status, t.TaskID.String(), t.TaskKey))
+			continue
+		}
+		plan = append(plan, command.TaskMigration{TaskID: t.TaskID, FromKey: t.TaskKey, ToKey: toKey})
+	}
+	return plan, problems
+}
+
+// PlanTokenMigration 为实例未结束的令牌计算目标步骤，规则与 PlanTaskMigration 相同
+// 分支令牌的 ForkStepID 同样按映射转换，保证汇聚时能找到分支步骤
+func (s *WorkflowDomainService) PlanTokenMigration(tokens []*token_aggregate.ExecutionToken, mapping map[string]string, target *WorkflowDefinitionStruct) ([]command.TokenMigration, []string) {
+	plan := []command.TokenMigration{}
+	problems := []string{}
+	for _, t := range tokens {
+		if !t.IsLive() {
+			continue
+		}
+		toStepID, ok := mapping[t.StepID]
+		if !ok {
+			toStepID = t.StepID
+		}
+		if s.FindStepOrParallelTaskByID(toStepID, target) == nil {
+			problems = append(problems, fmt.Sprintf("token %s (step %s) has no target step", t.TokenID.String(), t.StepID))
+			continue
+		}
+		plan = append(plan, command.TokenMigration{TokenID: t.TokenID, FromStepID: t.StepID, ToStepID: toStepID})
+	}
+	return plan, problems
+}
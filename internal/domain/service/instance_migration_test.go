@@ -0,0 +1,113 @@
+package domain_service
+
+import (
+	"strings"
+	"testing"
+
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+func newMigrationTask(key string, taskStatus status.TaskStatus) *task_aggregate.Task {
+	task := task_aggregate.NewTask(valueobject.NewInstanceID(), valueobject.NewWorkflowID())
+	task.TaskKey = key
+	task.Status = taskStatus
+	return task
+}
+
+func TestValidateStepMapping(t *testing.T) {
+	s := NewWorkflowDomainService(nil)
+	target := &WorkflowDefinitionStruct{Steps: []StepDefinition{
+		{ID: "review2"},
+		{ID: "sign", ParallelTasks: []StepDefinition{{ID: "legal"}}},
+	}}
+
+	if err := s.ValidateStepMapping(map[string]string{"review": "review2", "finance": "legal"}, target); err != nil {
+		t.Errorf("ValidateStepMapping() error = %v", err)
+	}
+	err := s.ValidateStepMapping(map[string]string{"review": "review2", "archive": "missing"}, target)
+	if err == nil || !strings.Contains(err.Error(), "archive -> missing") {
+		t.Errorf("ValidateStepMapping() error = %v, want missing target for archive", err)
+	}
+}
+
+func TestPlanTaskMigration(t *testing.T) {
+	s := NewWorkflowDomainService(nil)
+	target := &WorkflowDefinitionStruct{Steps: []StepDefinition{
+		{ID: "review2"},
+		{ID: "archive"},
+	}}
+	mapped := newMigrationTask("review", status.TaskStatusPending)
+	sameID := newMigrationTask("archive", status.TaskStatusPending)
+	done := newMigrationTask("apply", status.TaskStatusCompleted)
+
+	plan, problems := s.PlanTaskMigration([]*task_aggregate.Task{mapped, sameID, done}, map[string]string{"review": "review2"}, target)
+	if len(problems) != 0 {
+		t.Fatalf("PlanTaskMigration() problems = %v", problems)
+	}
+	want := map[valueobject.TaskID]string{mapped.TaskID: "review2", sameID.TaskID: "archive"}
+	if len(plan) != len(want) {
+		t.Fatalf("PlanTaskMigration() planned %d tasks, want %d", len(plan), len(want))
+	}
+	for _, m := range plan {
+		if m.ToKey != want[m.TaskID] {
+			t.Errorf("task %s mapped to %q, want %q", m.TaskID.String(), m.ToKey, want[m.TaskID])
+		}
+	}
+}
+
+func TestPlanTaskMigrationReportsUnmappedTask(t *testing.T) {
+	s := NewWorkflowDomainService(nil)
+	target := &WorkflowDefinitionStruct{Steps: []StepDefinition{{ID: "end"}}}
+	pending := newMigrationTask("review", status.TaskStatusPending)
+
+	plan, problems := s.PlanTaskMigration([]*task_aggregate.Task{pending}, nil, target)
+	if len(plan) != 0 {
+		t.Errorf("PlanTaskMigration() plan = %+v, want empty", plan)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0], "pending task") {
+		t.Errorf("PlanTaskMigration() problems = %v, want one pending task problem", problems)
+	}
+}
+
+func TestPlanTaskMigrationIncludesWaitingTasks(t *testing.T) {
+	s := NewWorkflowDomainService(nil)
+	target := &WorkflowDefinitionStruct{Steps: []StepDefinition{
+		{ID: "review2", Type: StepTypeUserTask},
+		{ID: "end", Type: StepTypeComplete},
+	}}
+	pending := newMigrationTask("review", status.TaskStatusPending)
+	waiting := newMigrationTask("review", status.TaskStatusWaiting)
+	done := newMigrationTask("apply", status.TaskStatusCompleted)
+
+	plan, problems := s.PlanTaskMigration([]*task_aggregate.Task{pending, waiting, done}, map[string]string{"review": "review2"}, target)
+	if len(problems) != 0 {
+		t.Fatalf("PlanTaskMigration() problems = %v", problems)
+	}
+	if len(plan) != 2 {
+		t.Fatalf("PlanTaskMigration() planned %d tasks, want 2 (pending and waiting)", len(plan))
+	}
+	for _, m := range plan {
+		if m.TaskID == done.TaskID {
+			t.Errorf("completed task %s should not be migrated", m.TaskID.String())
+		}
+		if m.ToKey != "review2" {
+			t.Errorf("task %s mapped to %q, want review2", m.TaskID.String(), m.ToKey)
+		}
+	}
+}
+
+func TestPlanTaskMigrationReportsUnmappedWaitingTask(t *testing.T) {
+	s := NewWorkflowDomainService(nil)
+	target := &WorkflowDefinitionStruct{Steps: []StepDefinition{{ID: "end", Type: StepTypeComplete}}}
+	waiting := newMigrationTask("countersign", status.TaskStatusWaiting)
+
+	plan, problems := s.PlanTaskMigration([]*task_aggregate.Task{waiting}, nil, target)
+	if len(plan) != 0 {
+		t.Errorf("PlanTaskMigration() plan = %+v, want empty", plan)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0], "waiting task") {
+		t.Errorf("PlanTaskMigration() problems = %v, want one waiting task problem", problems)
+	}
+}
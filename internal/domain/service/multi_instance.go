@@ -0,0 +1,284 @@
+package domain_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// 会签方式
+const (
+	MultiInstanceParallel   = "parallel"   // 并行会签，所有人同时处理
+	MultiInstanceSequential = "sequential" // 顺序会签，按处理人顺序依次处理
+)
+
+// 会签完成规则
+const (
+	CompletionAll   = "all"   // 全部通过
+	CompletionAny   = "any"   // 任一通过
+	CompletionRatio = "ratio" // 通过比例达到阈值，如 ratio:0.6
+	CompletionCount = "count" // 通过人数达到阈值，如 count:2
+)
+
+// MultiInstanceConfig 会签配置，对应 userTask 的 params.multiInstance
+type MultiInstanceConfig struct {
+	Assignees  interface{} `json:"assignees"`  // 处理人列表，支持 ${input.reviewers}、"1,2,3" 或数组
+	Mode       string      `json:"mode"`       // parallel, sequential，默认 parallel
+	Completion string      `json:"completion"` // all, any, ratio:0.6, count:2，默认 all
+}
+
+// MultiInstanceTally 会签投票统计
+type MultiInstanceTally struct {
+	Total    int // 参与会签的任务数（不含已取消）
+	Approved int
+	Rejected int
+	Open     int // 尚未处理的任务数
+}
+
+// ParseMultiInstance 读取步骤的会签配置，未配置时返回 nil
+func ParseMultiInstance(step *StepDefinition) (*MultiInstanceConfig, error) {
+	raw, ok := step.Params["multiInstance"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var config MultiInstanceConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("multiInstance must be an object: %v", err)
+	}
+
+	if config.Assignees == nil {
+		return nil, fmt.Errorf("multiInstance requires assignees")
+	}
+	if config.Mode == "" {
+		config.Mode = MultiInstanceParallel
+	}
+	if config.Mode != MultiInstanceParallel && config.Mode != MultiInstanceSequential {
+		return nil, fmt.Errorf("unknown multiInstance mode '%s'", config.Mode)
+	}
+	if config.Completion == "" {
+		config.Completion = CompletionAll
+	}
+	if _, _, err := parseCompletion(config.Completion); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// parseCompletion 解析完成规则，返回规则类型和阈值
+func parseCompletion(rule string) (string, float64, error) {
+	kind, arg, hasArg := strings.Cut(rule, ":")
+	switch kind {
+	case CompletionAll, CompletionAny:
+		if hasArg {
+			return "", 0, fmt.Errorf("completion rule '%s' takes no argument", kind)
+		}
+		return kind, 0, nil
+	case CompletionRatio:
+		ratio, err := strconv.ParseFloat(arg, 64)
+		if err != nil || ratio <= 0 || ratio > 1 {
+			return "", 0, fmt.Errorf("invalid completion ratio '%s', expected a number in (0, 1]", arg)
+		}
+		return kind, ratio, nil
+	case CompletionCount:
+		count, err := strconv.Atoi(arg)
+		if err != nil || count <= 0 {
+			return "", 0, fmt.Errorf("invalid completion count '%s', expected a positive integer", arg)
+		}
+		return kind, float64(count), nil
+	}
+	return "", 0, fmt.Errorf("unknown completion rule '%s'", rule)
+}
+
+// Required 计算会签通过所需的同意人数，阈值超过参与人数时按全部通过处理
+func (c *MultiInstanceConfig) Required(total int) int {
+	kind, value, err := parseCompletion(c.Completion)
+	if err != nil {
+		return total
+	}
+	required := total
+	switch kind {
+	case CompletionAny:
+		required = 1
+	case CompletionRatio:
+		required = int(math.Ceil(value * float64(total)))
+	case CompletionCount:
+		required = int(value)
+	}
+	if required > total {
+		required = total
+	}
+	if required < 1 {
+		required = 1
+	}
+	return required
+}
+
+// Decide 根据统计结果判定会签是否结束
+// 同意人数达到要求时通过；剩余任务全部同意也无法达到要求时驳回
+func (t MultiInstanceTally) Decide(required int) (decided bool, passed bool) {
+	if t.Approved >= required {
+		return true, true
+	}
+	if t.Approved+t.Open < required {
+		return true, false
+	}
+	return false, false
+}
+
+// TallyMultiInstance 统计任务组的投票情况
+// 成员任务的加签任务尚未处理完时，该成员按未处理统计
+func TallyMultiInstance(group []*task_aggregate.Task) MultiInstanceTally {
+	signing := make(map[valueobject.TaskID]bool)
+	for _, t := range group {
+		if !t.SourceTaskID.IsEmpty() && t.IsOpen() {
+			signing[t.SourceTaskID] = true
+		}
+	}
+
+	var tally MultiInstanceTally
+	for _, t := range group {
+		if !t.SourceTaskID.IsEmpty() {
+			continue
+		}
+		switch {
+		case t.IsOpen() || (signing[t.TaskID] && t.Status == status.TaskStatusCompleted):
+			tally.Open++
+		case t.Status == status.TaskStatusCompleted:
+			tally.Approved++
+		case t.Status == status.TaskStatusRejected:
+			tally.Rejected++
+		default:
+			continue
+		}
+		tally.Total++
+	}
+	return tally
+}
+
+// FindTaskGroup 从实例任务中筛选同一任务组的任务，包括成员任务加签产生的任务
+func (s *WorkflowDomainService) FindTaskGroup(tasks []*task_aggregate.Task, groupID valueobject.TaskGroupID) []*task_aggregate.Task {
+	return findTaskGroup(tasks, groupID)
+}
+
+func findTaskGroup(tasks []*task_aggregate.Task, groupID valueobject.TaskGroupID) []*task_aggregate.Task {
+	members := make(map[valueobject.TaskID]bool)
+	for _, t := range tasks {
+		if t.GroupID.Equals(groupID) {
+			members[t.TaskID] = true
+		}
+	}
+	var group []*task_aggregate.Task
+	for _, t := range tasks {
+		if members[t.TaskID] || members[t.SourceTaskID] {
+			group = append(group, t)
+		}
+	}
+	return group
+}
+
+// ResolveAssignees 解析会签处理人列表，去重并保持顺序
+func (s *WorkflowDomainService) ResolveAssignees(ctx context.Context, value interface{}, instance *instance_aggregate.WorkflowInstance) ([]int, error) {
+	if expr, ok := value.(string); ok {
+		expr = strings.TrimSpace(expr)
+		if strings.HasPrefix(expr, "${") && strings.HasSuffix(expr, "}") {
+			path := strings.TrimSpace(expr[2 : len(expr)-1])
+			segments, err := parseVariablePath(path)
+			if err != nil {
+				return nil, fmt.Errorf("invalid assignees reference %s: %v", expr, err)
+			}
+			evaluator := NewConditionEvaluatorWithSnapshot(s.NewVariableSnapshot(ctx, instance))
+			if value, err = evaluator.resolveVariable(path, segments); err != nil {
+				return nil, fmt.Errorf("failed to resolve assignees %s: %v", expr, err)
+			}
+		}
+	}
+
+	var items []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case string:
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				items = append(items, part)
+			}
+		}
+	default:
+		items = []interface{}{v}
+	}
+
+	assignees := make([]int, 0, len(items))
+	seen := make(map[int]bool, len(items))
+	for _, item := range items {
+		var assignee int
+		switch v := item.(type) {
+		case float64:
+			assignee = int(v)
+		case int:
+			assignee = v
+		case string:
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("invalid assignee '%s'", v)
+			}
+			assignee = n
+		default:
+			return nil, fmt.Errorf("invalid assignee %v", item)
+		}
+		if !seen[assignee] {
+			seen[assignee] = true
+			assignees = append(assignees, assignee)
+		}
+	}
+	if len(assignees) == 0 {
+		return nil, fmt.Errorf("multiInstance assignees resolved to an empty list")
+	}
+	return assignees, nil
+}
+
+// multiInstanceOutputs 最近一次会签的投票统计，按步骤ID索引
+// 同一步骤以最近创建的任务为准，该任务属于任务组时统计整个任务组
+func multiInstanceOutputs(tasks []*task_aggregate.Task) map[string]map[string]interface{} {
+	latest := make(map[string]*task_aggregate.Task)
+	for _, t := range tasks {
+		if !t.SourceTaskID.IsEmpty() {
+			continue // 加签任务不改变步骤的会签结果
+		}
+		if prev, ok := latest[t.TaskKey]; ok && prev.CreatedAt.After(t.CreatedAt) {
+			continue
+		}
+		latest[t.TaskKey] = t
+	}
+
+	outputs := make(map[string]map[string]interface{})
+	for key, last := range latest {
+		if last.GroupID.IsEmpty() {
+			continue
+		}
+		members := 0
+		for _, t := range tasks {
+			if t.GroupID.Equals(last.GroupID) {
+				members++
+			}
+		}
+		tally := TallyMultiInstance(findTaskGroup(tasks, last.GroupID))
+		outputs[key] = map[string]interface{}{
+			"approvedCount": tally.Approved,
+			"rejectedCount": tally.Rejected,
+			"totalCount":    members,
+		}
+	}
+	return outputs
+}
@@ -0,0 +1,79 @@
+package domain_service
+
+import (
+	"fmt"
+
+	task_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/task"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+	"jxt-evidence-system/process-management/shared/common/status"
+)
+
+// RejectPolicy 步骤驳回策略，为空时只能驳回到上一个已完成的步骤
+type RejectPolicy struct {
+	Targets     []string `json:"targets"`     // 允许指定驳回到的步骤ID
+	ToInitiator bool     `json:"toInitiator"` // 允许直接驳回到发起人
+}
+
+// AllowsTarget 策略是否允许驳回到指定步骤
+func (p *RejectPolicy) AllowsTarget(stepID string) bool {
+	if p == nil {
+		return false
+	}
+	for _, target := range p.Targets {
+		if target == stepID {
+			return true
+		}
+	}
+	return false
+}
+
+// FindRejectTarget 查找驳回要回到的任务
+// 驳回到发起人时为实例的第一个任务，指定目标步骤时为该步骤最近完成的任务，否则为上一个已完成的任务；
+// 只在当前令牌及其祖先令牌（onPath）上的任务中查找，并行分支中驳回不会回到其他分支
+func (s *WorkflowDomainService) FindRejectTarget(tasks []*task_aggregate.Task, current *task_aggregate.Task, step *StepDefinition, onPath map[valueobject.TokenID]bool) (*task_aggregate.Task, error) {
+	candidates := make([]*task_aggregate.Task, 0, len(tasks))
+	for _, t := range tasks {
+		// 引入令牌之前创建的任务没有令牌，视为在根令牌上
+		if t.TokenID.IsEmpty() || onPath[t.TokenID] {
+			candidates = append(candidates, t)
+		}
+	}
+
+	switch {
+	case current.RejectToInitiator:
+		if step.Reject == nil || !step.Reject.ToInitiator {
+			return nil, fmt.Errorf("%w: step %s does not allow rejecting to the initiator", errors_.ErrInvalidRejectTarget, step.ID)
+		}
+		var first *task_aggregate.Task
+		for _, t := range candidates {
+			if t.SourceTaskID.IsEmpty() && (first == nil || t.CreatedAt.Before(first.CreatedAt)) {
+				first = t
+			}
+		}
+		if first == nil || first.TaskKey == current.TaskKey || first.Status != status.TaskStatusCompleted {
+			return nil, fmt.Errorf("%w: initiator step has not been completed", errors_.ErrInvalidRejectTarget)
+		}
+		return first, nil
+
+	case current.RejectTarget != "":
+		if !step.Reject.AllowsTarget(current.RejectTarget) {
+			return nil, fmt.Errorf("%w: step %s does not allow rejecting to %s", errors_.ErrInvalidRejectTarget, step.ID, current.RejectTarget)
+		}
+		for i := len(candidates) - 1; i >= 0; i-- {
+			t := candidates[i]
+			if t.TaskKey == current.RejectTarget && t.TaskKey != current.TaskKey && t.SourceTaskID.IsEmpty() &&
+				t.Status == status.TaskStatusCompleted && t.CompletedAt != nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: step %s has not been completed", errors_.ErrInvalidRejectTarget, current.RejectTarget)
+
+	default:
+		previous := s.FindPreviousCompletedTask(candidates, current)
+		if previous == nil {
+			return nil, fmt.Errorf("cannot reject first task, no previous completed task found")
+		}
+		return previous, nil
+	}
+}
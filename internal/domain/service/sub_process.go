@@ -0,0 +1,67 @@
+package domain_service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
+)
+
+// SubProcessConfig 子流程步骤配置
+// 启动被引用工作流的子实例，父实例在该步骤等待子实例结束
+type SubProcessConfig struct {
+	Workflow string            `json:"workflow"` // 被引用的工作流名称
+	Version  int               `json:"version"`  // 子实例绑定的版本号，为0时使用当前发布版本
+	Input    map[string]string `json:"input"`    // 子实例输入变量 -> 父实例中的表达式，如 ${input.caseId}
+	Output   map[string]string `json:"output"`   // 子流程步骤输出变量 -> 子实例中的表达式，结束后写入父实例
+}
+
+// MapVariables 在实例的变量（输入和各步骤输出）上对映射中的表达式求值
+func (s *WorkflowDomainService) MapVariables(ctx context.Context, mapping map[string]string, instance *instance_aggregate.WorkflowInstance) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(mapping))
+	if len(mapping) == 0 {
+		return result, nil
+	}
+	evaluator := NewConditionEvaluatorWithSnapshot(s.NewVariableSnapshot(ctx, instance))
+	for name, expr := range mapping {
+		value, err := evaluator.Value(expr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to map variable %s: %w", name, err)
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+// validateSubProcess 校验子流程步骤配置
+func validateSubProcess(errs DefinitionErrors, step StepDefinition, path string) DefinitionErrors {
+	if step.Type != StepTypeSubProcess {
+		if step.SubProcess != nil {
+			errs = append(errs, DefinitionError{Path: path + ".subProcess", Code: DefinitionErrInvalidSubProc, Message: fmt.Sprintf("subProcess is only supported on %s steps", StepTypeSubProcess)})
+		}
+		return errs
+	}
+	if step.SubProcess == nil || step.SubProcess.Workflow == "" {
+		return append(errs, DefinitionError{Path: path + ".subProcess.workflow", Code: DefinitionErrInvalidSubProc, Message: "subProcess step requires a workflow name"})
+	}
+	if step.SubProcess.Version < 0 {
+		errs = append(errs, DefinitionError{Path: path + ".subProcess.version", Code: DefinitionErrInvalidSubProc, Message: "subProcess version must not be negative"})
+	}
+	for _, field := range []struct {
+		name    string
+		mapping map[string]string
+	}{{"input", step.SubProcess.Input}, {"output", step.SubProcess.Output}} {
+		names := make([]string, 0, len(field.mapping))
+		for name := range field.mapping {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if _, err := compileCondition(field.mapping[name]); err != nil {
+				errs = append(errs, DefinitionError{Path: fmt.Sprintf("%s.subProcess.%s.%s", path, field.name, name), Code: DefinitionErrInvalidSubProc, Message: err.Error()})
+			}
+		}
+	}
+	return errs
+}
@@ -1,6 +1,7 @@
 package domain_service
 
 import (
+	"context"
 	"encoding/json"
 	command "jxt-evidence-system/process-management/internal/application/command"
 	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
@@ -9,34 +10,109 @@ import (
 	"jxt-evidence-system/process-management/internal/domain/valueobject"
 	"jxt-evidence-system/process-management/shared/common/status"
 	"log"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // WorkflowDomainService 工作流领域服务
 // 负责工作流相关的领域逻辑，不涉及应用协调
 type WorkflowDomainService struct {
-	taskRepo task_repository.TaskRepository
+	taskRepo   task_repository.TaskRepository
+	conditions *ConditionCache      // 已编译条件表达式缓存
+	validator  *DefinitionValidator // 定义结构校验器
 }
 
 func NewWorkflowDomainService(taskRepo task_repository.TaskRepository) *WorkflowDomainService {
 	return &WorkflowDomainService{
-		taskRepo: taskRepo,
+		taskRepo:   taskRepo,
+		conditions: NewConditionCache(),
+		validator:  NewDefinitionValidator(),
 	}
 }
 
+// ValidateDefinition 解析并校验工作流定义，返回解析结果和全部校验错误
+func (s *WorkflowDomainService) ValidateDefinition(raw string) (*WorkflowDefinitionStruct, DefinitionErrors) {
+	return s.validator.Validate(raw)
+}
+
 // StepDefinition 步骤定义
 type StepDefinition struct {
-	ID            string                 `json:"id"`
-	Type          string                 `json:"type"`
-	Name          string                 `json:"name"`
-	Description   string                 `json:"description"`
-	Condition     string                 `json:"condition"` // 执行条件
-	Timeout       int                    `json:"timeout"`
-	Retries       int                    `json:"retries"`
-	Params        map[string]interface{} `json:"params"`
-	NextSteps     []string               `json:"nextSteps"`     // 下一步步骤ID列表（支持并行）
-	ParallelTasks []StepDefinition       `json:"parallelTasks"` // 并行任务列表
+	ID              string                 `json:"id"`
+	Type            string                 `json:"type"`
+	Name            string                 `json:"name"`
+	Description     string                 `json:"description"`
+	Condition       string                 `json:"condition"`       // 执行条件
+	Timeout         int                    `json:"timeout"`         // 超时时间（秒），大于0时启用超时定时器
+	OnTimeout       *TimeoutPolicy         `json:"onTimeout"`       // 超时策略，为空时仅提醒处理人
+	Retries         int                    `json:"retries"`         // 自动化步骤失败后的最大重试次数
+	RetryBackoff    *RetryPolicy           `json:"retryBackoff"`    // 重试退避策略，为空时使用默认值
+	Reject          *RejectPolicy          `json:"reject"`          // 驳回策略，为空时只能驳回到上一个已完成的步骤
+	SubProcess      *SubProcessConfig      `json:"subProcess"`      // 子流程配置，仅 subProcess 步骤使用
+	AllowDelegate   *bool                  `json:"allowDelegate"`   // 是否允许转办，为空时允许
+	MaxDelegateHops int                    `json:"maxDelegateHops"` // 原处理人之后最多转办的次数，0 表示不限
+	Params          map[string]interface{} `json:"params"`
+	NextSteps       []string               `json:"nextSteps"`     // 下一步步骤ID列表（支持并行）
+	ParallelTasks   []StepDefinition       `json:"parallelTasks"` // 并行任务列表
+}
+
+// DelegateAllowed 步骤是否允许转办
+func (s *StepDefinition) DelegateAllowed() bool {
+	return s.AllowDelegate == nil || *s.AllowDelegate
+}
+
+// 超时动作
+const (
+	TimeoutActionNotify   = "notify"   // 提醒当前处理人
+	TimeoutActionApprove  = "approve"  // 自动通过
+	TimeoutActionReject   = "reject"   // 自动驳回
+	TimeoutActionEscalate = "escalate" // 升级给其他处理人
+	TimeoutActionJump     = "jump"     // 跳转到指定步骤
+)
+
+// 默认重试退避参数
+const (
+	DefaultRetryInitialInterval = 5   // 首次重试间隔（秒）
+	DefaultRetryMultiplier      = 2.0 // 间隔倍数
+	DefaultRetryMaxInterval     = 300 // 最大重试间隔（秒）
+)
+
+// RetryPolicy 自动化步骤重试退避策略（指数退避）
+type RetryPolicy struct {
+	InitialInterval int     `json:"initialInterval"` // 首次重试间隔（秒）
+	Multiplier      float64 `json:"multiplier"`      // 每次重试间隔的倍数
+	MaxInterval     int     `json:"maxInterval"`     // 最大重试间隔（秒）
+}
+
+// Backoff 计算第 attempt 次执行失败后到下一次重试的等待时间，attempt 从1开始
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	initial, multiplier, maxInterval := DefaultRetryInitialInterval, DefaultRetryMultiplier, DefaultRetryMaxInterval
+	if p != nil {
+		if p.InitialInterval > 0 {
+			initial = p.InitialInterval
+		}
+		if p.Multiplier >= 1 {
+			multiplier = p.Multiplier
+		}
+		if p.MaxInterval > 0 {
+			maxInterval = p.MaxInterval
+		}
+	}
+
+	interval := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if interval > float64(maxInterval) {
+		interval = float64(maxInterval)
+	}
+	return time.Duration(interval * float64(time.Second))
+}
+
+// TimeoutPolicy 步骤超时策略
+type TimeoutPolicy struct {
+	Action   string `json:"action"`   // notify, approve, reject, escalate, jump
+	Assignee string `json:"assignee"` // escalate 时的新处理人，支持 ${variable}
+	StepID   string `json:"stepId"`   // jump 时的目标步骤ID
+	Comment  string `json:"comment"`  // 超时处理时写入任务的备注
 }
 
 // WorkflowDefinitionStruct 工作流定义结构
@@ -98,8 +174,8 @@ func (s *WorkflowDomainService) BuildTaskHistories(tasks []*task_aggregate.Task)
 	return taskHistories
 }
 
-// buildTaskHistories 构建实例任务列表，用于展示实例详情
-func (s *WorkflowDomainService) BuildInstanceDetail(tasks []*task_aggregate.Task) []command.TaskHistoryItem {
+// buildTaskHistories 构建实例任务列表和加签记录，用于展示实例详情
+func (s *WorkflowDomainService) BuildInstanceDetail(tasks []*task_aggregate.Task, histories []*task_aggregate.TaskHistory) []command.TaskHistoryItem {
 	var taskHistories []command.TaskHistoryItem
 
 	for _, t := range tasks {
@@ -124,8 +200,13 @@ func (s *WorkflowDomainService) BuildInstanceDetail(tasks []*task_aggregate.Task
 			resultText = string(t.Result)
 		}
 
-		if t.Status == status.TaskStatusPending {
+		switch t.Status {
+		case status.TaskStatusPending:
 			resultText = "待处理"
+		case status.TaskStatusWaiting:
+			resultText = "等待中"
+		case status.TaskStatusCancelled:
+			resultText = "已取消"
 		}
 
 		completedAtStr := ""
@@ -144,10 +225,52 @@ func (s *WorkflowDomainService) BuildInstanceDetail(tasks []*task_aggregate.Task
 			Output:      output,
 			CompletedAt: completedAtStr,
 			CreatedAt:   createdAtStr,
+
+			SignPosition: t.SignPosition,
 		})
 
 	}
 
+	// 加签、流程跳转、挂起、恢复和取消记录
+	actionResults := map[string]string{
+		"add_sign":        "加签",
+		"jump":            "跳转",
+		"suspend":         "挂起",
+		"resume":          "恢复",
+		"cancel_instance": "取消",
+	}
+	taskKeys := make(map[valueobject.TaskID]string, len(tasks))
+	for _, t := range tasks {
+		taskKeys[t.TaskID] = t.TaskKey
+	}
+	for _, h := range histories {
+		result, ok := actionResults[h.Action]
+		if !ok {
+			continue
+		}
+		var output map[string]interface{}
+		if err := json.Unmarshal(h.Output, &output); err != nil || output == nil {
+			output = make(map[string]interface{})
+		}
+		taskKey := taskKeys[h.TaskID]
+		if h.Action == "jump" {
+			taskKey, _ = output["to"].(string)
+		}
+		position, _ := output["position"].(string)
+		assignee, _ := strconv.Atoi(h.Assignee)
+		taskHistories = append(taskHistories, command.TaskHistoryItem{
+			TaskName:     h.TaskName,
+			TaskKey:      taskKey,
+			Assignee:     assignee,
+			Result:       result,
+			Comment:      h.Comment,
+			Output:       output,
+			CreatedAt:    h.CreatedAt.Format("2006-01-02 15:04:05"),
+			Action:       h.Action,
+			SignPosition: position,
+		})
+	}
+
 	// 按创建时间排序
 	for i := 0; i < len(taskHistories); i++ {
 		for j := 0; j < len(taskHistories)-i-1; j++ {
@@ -162,16 +285,18 @@ func (s *WorkflowDomainService) BuildInstanceDetail(tasks []*task_aggregate.Task
 
 // applyStepParamsToTask 从步骤参数设置任务属性
 func (s *WorkflowDomainService) ApplyStepParamsToTask(task *task_aggregate.Task, step *StepDefinition, instance *instance_aggregate.WorkflowInstance) {
-	if step.Params == nil {
-		log.Printf("[WorkflowDomainService] Step params is nil for step: %s", step.Name)
-		return
-	}
 	task.TaskName = step.Name
 	task.TaskKey = step.ID
 	task.Description = step.Description
 	task.TaskType = step.Type
-	// 处理 assignee
-	if assignee, ok := step.Params["assignee"].(string); ok {
+	if step.Params == nil {
+		log.Printf("[WorkflowDomainService] Step params is nil for step: %s", step.Name)
+		return
+	}
+	// 处理 assignee，角色、组织负责人等表达式由引擎通过 AssigneeResolver 解析
+	if assignee, ok := step.Params["assignee"].(string); ok && IsAssigneeExpression(assignee) {
+		log.Printf("[WorkflowDomainService] Assignee expression %s is resolved by the engine", assignee)
+	} else if ok {
 		log.Printf("[WorkflowDomainService] Found assignee param: %s", assignee)
 		resolvedValue := s.resolveVariable(assignee, instance)
 		log.Printf("[WorkflowDomainService] Resolved assignee value: %s", resolvedValue)
@@ -187,6 +312,16 @@ func (s *WorkflowDomainService) ApplyStepParamsToTask(task *task_aggregate.Task,
 		log.Printf("[WorkflowDomainService] No assignee param found in step: %s, params: %v", step.Name, step.Params)
 	}
 
+	// 处理候选人：设置了候选人且未指定处理人时，任务由候选用户或候选组成员认领
+	users := s.resolveCandidateIDs(step.Params["candidateUsers"], instance)
+	groups := s.resolveCandidateIDs(step.Params["candidateGroups"], instance)
+	if len(users) > 0 || len(groups) > 0 {
+		task.SetCandidates(users, groups)
+		if _, ok := step.Params["assignee"]; !ok {
+			task.Assignee = 0
+		}
+	}
+
 	// 处理优先级
 	if priority, ok := step.Params["priority"].(string); ok {
 		switch priority {
@@ -216,6 +351,99 @@ func (s *WorkflowDomainService) ApplyStepParamsToTask(task *task_aggregate.Task,
 	}
 }
 
+// resolveCandidateIDs 解析候选用户或候选组配置，支持数字和 ${variable}
+func (s *WorkflowDomainService) resolveCandidateIDs(value interface{}, instance *instance_aggregate.WorkflowInstance) []int {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case float64:
+			ids = append(ids, int(v))
+		case string:
+			if id, ok := s.ResolveAssignee(v, instance); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// ResolveAssignee 解析处理人配置（支持 ${variable}），返回用户ID
+func (s *WorkflowDomainService) ResolveAssignee(value string, instance *instance_aggregate.WorkflowInstance) (int, bool) {
+	resolvedValue := s.resolveVariable(value, instance)
+	assignee, err := strconv.Atoi(resolvedValue)
+	if err != nil {
+		log.Printf("[WorkflowDomainService] Failed to convert assignee to int: %s (error: %v)", resolvedValue, err)
+		return 0, false
+	}
+	return assignee, true
+}
+
+// ParseInstanceInput 解析实例输入，兼容双重编码的JSON字符串
+func (s *WorkflowDomainService) ParseInstanceInput(instance *instance_aggregate.WorkflowInstance) (map[string]interface{}, error) {
+	return parseInstanceInput(instance.Input)
+}
+
+// BuildStepOutputs 汇总任务的输出，按步骤ID索引，同一步骤以最近创建的任务为准，会签步骤附带投票统计
+func (s *WorkflowDomainService) BuildStepOutputs(tasks []*task_aggregate.Task) map[string]map[string]interface{} {
+	return buildStepOutputs(tasks)
+}
+
+func parseInstanceInput(inputData json.RawMessage) (map[string]interface{}, error) {
+	input := make(map[string]interface{})
+	if len(inputData) == 0 {
+		return input, nil
+	}
+
+	inputStr := string(inputData)
+	if strings.HasPrefix(inputStr, "\"") && strings.HasSuffix(inputStr, "\"") {
+		var tempStr string
+		if err := json.Unmarshal(inputData, &tempStr); err == nil {
+			inputData = []byte(tempStr)
+		}
+	}
+
+	if err := json.Unmarshal(inputData, &input); err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func buildStepOutputs(tasks []*task_aggregate.Task) map[string]map[string]interface{} {
+	outputs := make(map[string]map[string]interface{})
+	latest := make(map[string]time.Time)
+	for _, t := range tasks {
+		if t.Status != status.TaskStatusCompleted || len(t.Output) == 0 {
+			continue
+		}
+		if at, ok := latest[t.TaskKey]; ok && at.After(t.CreatedAt) {
+			continue
+		}
+		var output map[string]interface{}
+		if err := json.Unmarshal(t.Output, &output); err != nil {
+			continue
+		}
+		outputs[t.TaskKey] = output
+		latest[t.TaskKey] = t.CreatedAt
+	}
+
+	// 会签步骤追加投票统计，如 ${step_id.approvedCount}
+	for key, tally := range multiInstanceOutputs(tasks) {
+		output, ok := outputs[key]
+		if !ok {
+			output = make(map[string]interface{})
+			outputs[key] = output
+		}
+		for k, v := range tally {
+			output[k] = v
+		}
+	}
+	return outputs
+}
+
 // buildTaskData 构建任务数据（合并实例输入和历史记录）
 func (s *WorkflowDomainService) BuildTaskData(instance *instance_aggregate.WorkflowInstance, taskHistories []command.TaskHistoryItem, extraData map[string]interface{}) []byte {
 	taskData := make(map[string]interface{})
@@ -243,10 +471,23 @@ func (s *WorkflowDomainService) BuildTaskData(instance *instance_aggregate.Workf
 }
 
 // findPreviousCompletedTask 查找上一个已完成的任务
-func (s *WorkflowDomainService) FindPreviousCompletedTask(tasks []*task_aggregate.Task, currentTaskID valueobject.TaskID) *task_aggregate.Task {
+// 与当前任务同一会签任务组的任务以及由它们加签产生的任务属于同一步骤，不作为上一个任务
+func (s *WorkflowDomainService) FindPreviousCompletedTask(tasks []*task_aggregate.Task, current *task_aggregate.Task) *task_aggregate.Task {
+	sameStep := map[valueobject.TaskID]bool{current.TaskID: true}
+	if !current.GroupID.IsEmpty() {
+		for _, t := range tasks {
+			if t.GroupID.Equals(current.GroupID) {
+				sameStep[t.TaskID] = true
+			}
+		}
+	}
+
 	for i := len(tasks) - 1; i >= 0; i-- {
 		t := tasks[i]
-		if t.TaskID != currentTaskID && t.Status == status.TaskStatusCompleted && t.CompletedAt != nil {
+		if sameStep[t.TaskID] || sameStep[t.SourceTaskID] {
+			continue
+		}
+		if t.Status == status.TaskStatusCompleted && t.CompletedAt != nil {
 			return t
 		}
 	}
@@ -254,7 +495,12 @@ func (s *WorkflowDomainService) FindPreviousCompletedTask(tasks []*task_aggregat
 }
 
 // findNextStep 查找下一个步骤
-func (s *WorkflowDomainService) FindNextStep(currentStep *StepDefinition, definition *WorkflowDefinitionStruct, instance *instance_aggregate.WorkflowInstance) *StepDefinition {
+// 同一次决策中的所有条件共享一个变量快照，步骤输出最多查询一次
+func (s *WorkflowDomainService) FindNextStep(ctx context.Context, currentStep *StepDefinition, definition *WorkflowDefinitionStruct, instance *instance_aggregate.WorkflowInstance) *StepDefinition {
+	return s.findNextStep(currentStep, definition, instance, s.NewVariableSnapshot(ctx, instance))
+}
+
+func (s *WorkflowDomainService) findNextStep(currentStep *StepDefinition, definition *WorkflowDefinitionStruct, instance *instance_aggregate.WorkflowInstance, vars *VariableSnapshot) *StepDefinition {
 	// 优先使用 next_steps 字段（支持条件分支）
 	if len(currentStep.NextSteps) > 0 {
 		// 遍历所有可能的下一步，找到第一个满足条件的
@@ -267,7 +513,7 @@ func (s *WorkflowDomainService) FindNextStep(currentStep *StepDefinition, defini
 
 			// 检查步骤条件
 			if nextStep.Condition != "" {
-				if !s.EvaluateCondition(nextStep.Condition, instance) {
+				if !s.EvaluateConditionWithSnapshot(nextStep.Condition, instance, vars) {
 					log.Printf("[EngineService] Step condition not met for %s: %s", nextStep.ID, nextStep.Condition)
 					continue
 				}
@@ -300,10 +546,10 @@ func (s *WorkflowDomainService) FindNextStep(currentStep *StepDefinition, defini
 
 	// 检查步骤条件
 	if nextStep.Condition != "" {
-		if !s.EvaluateCondition(nextStep.Condition, instance) {
+		if !s.EvaluateConditionWithSnapshot(nextStep.Condition, instance, vars) {
 			log.Printf("[EngineService] Step condition not met: %s, skipping", nextStep.Condition)
 			// 条件不满足，继续查找下一个步骤
-			return s.FindNextStep(nextStep, definition, instance)
+			return s.findNextStep(nextStep, definition, instance, vars)
 		}
 	}
 
@@ -320,15 +566,40 @@ func (s *WorkflowDomainService) FindStepByID(stepID string, definition *Workflow
 	return nil
 }
 
+// FindStepOrParallelTaskByID 根据ID查找步骤，未找到时继续在并行任务中查找
+func (s *WorkflowDomainService) FindStepOrParallelTaskByID(stepID string, definition *WorkflowDefinitionStruct) *StepDefinition {
+	if step := s.FindStepByID(stepID, definition); step != nil {
+		return step
+	}
+	for i := range definition.Steps {
+		for j := range definition.Steps[i].ParallelTasks {
+			if definition.Steps[i].ParallelTasks[j].ID == stepID {
+				return &definition.Steps[i].ParallelTasks[j]
+			}
+		}
+	}
+	return nil
+}
+
 // evaluateCondition 评估条件表达式
-func (s *WorkflowDomainService) EvaluateCondition(condition string, instance *instance_aggregate.WorkflowInstance) bool {
+func (s *WorkflowDomainService) EvaluateCondition(ctx context.Context, condition string, instance *instance_aggregate.WorkflowInstance) bool {
+	return s.EvaluateConditionWithSnapshot(condition, instance, s.NewVariableSnapshot(ctx, instance))
+}
+
+// EvaluateConditionWithSnapshot 使用已有变量快照评估条件表达式，条件语法树取自缓存
+func (s *WorkflowDomainService) EvaluateConditionWithSnapshot(condition string, instance *instance_aggregate.WorkflowInstance, vars *VariableSnapshot) bool {
 	if condition == "" {
 		return true
 	}
 
+	node, err := s.conditions.Get(instance.WorkflowID.String(), condition)
+	if err != nil {
+		log.Printf("[EngineService] Failed to compile condition '%s': %v, defaulting to false", condition, err)
+		return false
+	}
+
 	// 使用条件求值器
-	evaluator := NewConditionEvaluator(instance, s.taskRepo)
-	result, err := evaluator.Evaluate(condition)
+	result, err := NewConditionEvaluatorWithSnapshot(vars).evaluateNode(node)
 	if err != nil {
 		log.Printf("[EngineService] Failed to evaluate condition '%s': %v, defaulting to false", condition, err)
 		return false
@@ -338,6 +609,21 @@ func (s *WorkflowDomainService) EvaluateCondition(condition string, instance *in
 	return result
 }
 
+// NewVariableSnapshot 创建条件求值使用的变量快照
+func (s *WorkflowDomainService) NewVariableSnapshot(ctx context.Context, instance *instance_aggregate.WorkflowInstance) *VariableSnapshot {
+	return NewVariableSnapshot(ctx, instance, s.taskRepo)
+}
+
+// CompileConditions 编译并缓存工作流定义中的全部条件表达式
+func (s *WorkflowDomainService) CompileConditions(workflowID valueobject.WorkflowID, definition *WorkflowDefinitionStruct) error {
+	return s.conditions.Compile(workflowID.String(), definition)
+}
+
+// InvalidateConditions 清除工作流的条件缓存
+func (s *WorkflowDomainService) InvalidateConditions(workflowID valueobject.WorkflowID) {
+	s.conditions.Invalidate(workflowID.String())
+}
+
 // resolveVariable 解析变量
 // 支持 ${variable} 格式的变量替换
 func (s *WorkflowDomainService) resolveVariable(value string, instance *instance_aggregate.WorkflowInstance) string {
@@ -347,6 +633,9 @@ func (s *WorkflowDomainService) resolveVariable(value string, instance *instance
 
 	// 提取变量名
 	varName := strings.TrimSuffix(strings.TrimPrefix(value, "${"), "}")
+	if varName == VariableInitiator && instance.InitiatorID != 0 {
+		return strconv.Itoa(instance.InitiatorID)
+	}
 
 	// 解析实例输入
 	var input map[string]interface{}
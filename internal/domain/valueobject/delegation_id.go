@@ -0,0 +1,173 @@
+package valueobject
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// DelegationID 委托规则ID值对象
+type DelegationID struct {
+	value uuid.UUID
+}
+
+// NewDelegationID 创建新的DelegationID
+// UUID v7 是基于时间戳的，适合数据库索引，时间戳 + 随机数
+func NewDelegationID() DelegationID {
+	return DelegationID{value: uuid.Must(uuid.NewV7())}
+}
+
+// DelegationIDFromString 从字符串创建DelegationID
+func DelegationIDFromString(s string) (DelegationID, error) {
+	if s == "" {
+		return DelegationID{}, nil // 空值对象
+	}
+
+	parsedUUID, err := uuid.Parse(s)
+	if err != nil {
+		return DelegationID{}, fmt.Errorf("invalid DelegationID format: %w", err)
+	}
+
+	return DelegationID{value: parsedUUID}, nil
+}
+
+// DelegationIDFromBytes 从字节数组创建DelegationID（用于数据库扫描）
+func DelegationIDFromBytes(b []byte) (DelegationID, error) {
+	if len(b) == 0 {
+		return DelegationID{}, nil
+	}
+
+	if len(b) != 16 {
+		return DelegationID{}, fmt.Errorf("invalid DelegationID bytes length: expected 16, got %d", len(b))
+	}
+
+	parsedUUID, err := uuid.FromBytes(b)
+	if err != nil {
+		return DelegationID{}, fmt.Errorf("failed to parse DelegationID from bytes: %w", err)
+	}
+
+	return DelegationID{value: parsedUUID}, nil
+}
+
+// String 返回字符串表示
+func (id DelegationID) String() string {
+	if id.IsEmpty() {
+		return ""
+	}
+	return id.value.String()
+}
+
+// IsEmpty 检查是否为空值对象
+func (id DelegationID) IsEmpty() bool {
+	return id.value == uuid.Nil
+}
+
+// Equals 比较两个DelegationID是否相等
+func (id DelegationID) Equals(other DelegationID) bool {
+	return id.value == other.value
+}
+
+// Value 实现driver.Valuer接口，用于数据库存储
+func (id DelegationID) Value() (driver.Value, error) {
+	if id.IsEmpty() {
+		return nil, nil
+	}
+	return id.value[:], nil // 返回16字节数组用于MySQL binary(16)存储
+}
+
+// Scan 实现sql.Scanner接口，用于数据库扫描
+func (id *DelegationID) Scan(value interface{}) error {
+	if value == nil {
+		*id = DelegationID{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		if len(v) == 0 {
+			*id = DelegationID{}
+			return nil
+		}
+		mediaID, err := DelegationIDFromBytes(v)
+		if err != nil {
+			return err
+		}
+		*id = mediaID
+		return nil
+	case string:
+		mediaID, err := DelegationIDFromString(v)
+		if err != nil {
+			return err
+		}
+		*id = mediaID
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into DelegationID", value)
+	}
+}
+
+// MarshalJSON 实现JSON序列化
+func (id DelegationID) MarshalJSON() ([]byte, error) {
+	if id.IsEmpty() {
+		return json.Marshal("")
+	}
+	return json.Marshal(id.String())
+}
+
+// UnmarshalJSON 实现JSON反序列化
+func (id *DelegationID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	mediaID, err := DelegationIDFromString(s)
+	if err != nil {
+		return err
+	}
+
+	*id = mediaID
+	return nil
+}
+
+// ===== URI参数绑定支持 =====
+
+// NewDelegationIDFromString 从字符串创建委托规则ID
+func NewDelegationIDFromString(id string) (DelegationID, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return DelegationID{}, fmt.Errorf("无效的委托规则ID格式: %w", err)
+	}
+	return DelegationID{value: parsedUUID}, nil
+}
+
+// MarshalText 实现 encoding.TextMarshaler 接口
+// 支持GORM查询参数序列化
+func (id DelegationID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler 接口
+// 支持Gin框架的URI参数绑定和GORM查询参数序列化
+func (id *DelegationID) UnmarshalText(text []byte) error {
+	newID, err := NewDelegationIDFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*id = newID
+	return nil
+}
+
+// UnmarshalParam 实现 binding.BindUnmarshaler 接口
+// 支持Gin框架的URI参数绑定（ShouldBindUri）和Query参数绑定
+// 注意：Gin的ShouldBindUri需要此接口才能正确绑定自定义类型
+func (id *DelegationID) UnmarshalParam(param string) error {
+	newID, err := NewDelegationIDFromString(param)
+	if err != nil {
+		return err
+	}
+	*id = newID
+	return nil
+}
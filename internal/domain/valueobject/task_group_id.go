@@ -0,0 +1,173 @@
+package valueobject
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// TaskGroupID 会签任务组ID值对象
+type TaskGroupID struct {
+	value uuid.UUID
+}
+
+// NewTaskGroupID 创建新的TaskGroupID
+// UUID v7 是基于时间戳的，适合数据库索引，时间戳 + 随机数
+func NewTaskGroupID() TaskGroupID {
+	return TaskGroupID{value: uuid.Must(uuid.NewV7())}
+}
+
+// TaskGroupIDFromString 从字符串创建TaskGroupID
+func TaskGroupIDFromString(s string) (TaskGroupID, error) {
+	if s == "" {
+		return TaskGroupID{}, nil // 空值对象
+	}
+
+	parsedUUID, err := uuid.Parse(s)
+	if err != nil {
+		return TaskGroupID{}, fmt.Errorf("invalid TaskGroupID format: %w", err)
+	}
+
+	return TaskGroupID{value: parsedUUID}, nil
+}
+
+// TaskGroupIDFromBytes 从字节数组创建TaskGroupID（用于数据库扫描）
+func TaskGroupIDFromBytes(b []byte) (TaskGroupID, error) {
+	if len(b) == 0 {
+		return TaskGroupID{}, nil
+	}
+
+	if len(b) != 16 {
+		return TaskGroupID{}, fmt.Errorf("invalid TaskGroupID bytes length: expected 16, got %d", len(b))
+	}
+
+	parsedUUID, err := uuid.FromBytes(b)
+	if err != nil {
+		return TaskGroupID{}, fmt.Errorf("failed to parse TaskGroupID from bytes: %w", err)
+	}
+
+	return TaskGroupID{value: parsedUUID}, nil
+}
+
+// String 返回字符串表示
+func (id TaskGroupID) String() string {
+	if id.IsEmpty() {
+		return ""
+	}
+	return id.value.String()
+}
+
+// IsEmpty 检查是否为空值对象
+func (id TaskGroupID) IsEmpty() bool {
+	return id.value == uuid.Nil
+}
+
+// Equals 比较两个TaskGroupID是否相等
+func (id TaskGroupID) Equals(other TaskGroupID) bool {
+	return id.value == other.value
+}
+
+// Value 实现driver.Valuer接口，用于数据库存储
+func (id TaskGroupID) Value() (driver.Value, error) {
+	if id.IsEmpty() {
+		return nil, nil
+	}
+	return id.value[:], nil // 返回16字节数组用于MySQL binary(16)存储
+}
+
+// Scan 实现sql.Scanner接口，用于数据库扫描
+func (id *TaskGroupID) Scan(value interface{}) error {
+	if value == nil {
+		*id = TaskGroupID{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		if len(v) == 0 {
+			*id = TaskGroupID{}
+			return nil
+		}
+		mediaID, err := TaskGroupIDFromBytes(v)
+		if err != nil {
+			return err
+		}
+		*id = mediaID
+		return nil
+	case string:
+		mediaID, err := TaskGroupIDFromString(v)
+		if err != nil {
+			return err
+		}
+		*id = mediaID
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into TaskGroupID", value)
+	}
+}
+
+// MarshalJSON 实现JSON序列化
+func (id TaskGroupID) MarshalJSON() ([]byte, error) {
+	if id.IsEmpty() {
+		return json.Marshal("")
+	}
+	return json.Marshal(id.String())
+}
+
+// UnmarshalJSON 实现JSON反序列化
+func (id *TaskGroupID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	mediaID, err := TaskGroupIDFromString(s)
+	if err != nil {
+		return err
+	}
+
+	*id = mediaID
+	return nil
+}
+
+// ===== URI参数绑定支持 =====
+
+// NewTaskGroupIDFromString 从字符串创建任务组ID
+func NewTaskGroupIDFromString(id string) (TaskGroupID, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return TaskGroupID{}, fmt.Errorf("无效的任务组ID格式: %w", err)
+	}
+	return TaskGroupID{value: parsedUUID}, nil
+}
+
+// MarshalText 实现 encoding.TextMarshaler 接口
+// 支持GORM查询参数序列化
+func (id TaskGroupID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler 接口
+// 支持Gin框架的URI参数绑定和GORM查询参数序列化
+func (id *TaskGroupID) UnmarshalText(text []byte) error {
+	newID, err := NewTaskGroupIDFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*id = newID
+	return nil
+}
+
+// UnmarshalParam 实现 binding.BindUnmarshaler 接口
+// 支持Gin框架的URI参数绑定（ShouldBindUri）和Query参数绑定
+// 注意：Gin的ShouldBindUri需要此接口才能正确绑定自定义类型
+func (id *TaskGroupID) UnmarshalParam(param string) error {
+	newID, err := NewTaskGroupIDFromString(param)
+	if err != nil {
+		return err
+	}
+	*id = newID
+	return nil
+}
@@ -0,0 +1,173 @@
+package valueobject
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// TimerID 定时器ID值对象
+type TimerID struct {
+	value uuid.UUID
+}
+
+// NewTimerID 创建新的TimerID
+// UUID v7 是基于时间戳的，适合数据库索引，时间戳 + 随机数
+func NewTimerID() TimerID {
+	return TimerID{value: uuid.Must(uuid.NewV7())}
+}
+
+// TimerIDFromString 从字符串创建TimerID
+func TimerIDFromString(s string) (TimerID, error) {
+	if s == "" {
+		return TimerID{}, nil // 空值对象
+	}
+
+	parsedUUID, err := uuid.Parse(s)
+	if err != nil {
+		return TimerID{}, fmt.Errorf("invalid TimerID format: %w", err)
+	}
+
+	return TimerID{value: parsedUUID}, nil
+}
+
+// TimerIDFromBytes 从字节数组创建TimerID（用于数据库扫描）
+func TimerIDFromBytes(b []byte) (TimerID, error) {
+	if len(b) == 0 {
+		return TimerID{}, nil
+	}
+
+	if len(b) != 16 {
+		return TimerID{}, fmt.Errorf("invalid TimerID bytes length: expected 16, got %d", len(b))
+	}
+
+	parsedUUID, err := uuid.FromBytes(b)
+	if err != nil {
+		return TimerID{}, fmt.Errorf("failed to parse TimerID from bytes: %w", err)
+	}
+
+	return TimerID{value: parsedUUID}, nil
+}
+
+// String 返回字符串表示
+func (id TimerID) String() string {
+	if id.IsEmpty() {
+		return ""
+	}
+	return id.value.String()
+}
+
+// IsEmpty 检查是否为空值对象
+func (id TimerID) IsEmpty() bool {
+	return id.value == uuid.Nil
+}
+
+// Equals 比较两个TimerID是否相等
+func (id TimerID) Equals(other TimerID) bool {
+	return id.value == other.value
+}
+
+// Value 实现driver.Valuer接口，用于数据库存储
+func (id TimerID) Value() (driver.Value, error) {
+	if id.IsEmpty() {
+		return nil, nil
+	}
+	return id.value[:], nil // 返回16字节数组用于MySQL binary(16)存储
+}
+
+// Scan 实现sql.Scanner接口，用于数据库扫描
+func (id *TimerID) Scan(value interface{}) error {
+	if value == nil {
+		*id = TimerID{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		if len(v) == 0 {
+			*id = TimerID{}
+			return nil
+		}
+		mediaID, err := TimerIDFromBytes(v)
+		if err != nil {
+			return err
+		}
+		*id = mediaID
+		return nil
+	case string:
+		mediaID, err := TimerIDFromString(v)
+		if err != nil {
+			return err
+		}
+		*id = mediaID
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into TimerID", value)
+	}
+}
+
+// MarshalJSON 实现JSON序列化
+func (id TimerID) MarshalJSON() ([]byte, error) {
+	if id.IsEmpty() {
+		return json.Marshal("")
+	}
+	return json.Marshal(id.String())
+}
+
+// UnmarshalJSON 实现JSON反序列化
+func (id *TimerID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	mediaID, err := TimerIDFromString(s)
+	if err != nil {
+		return err
+	}
+
+	*id = mediaID
+	return nil
+}
+
+// ===== URI参数绑定支持 =====
+
+// NewTimerIDFromString 从字符串创建定时器ID
+func NewTimerIDFromString(id string) (TimerID, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return TimerID{}, fmt.Errorf("无效的定时器ID格式: %w", err)
+	}
+	return TimerID{value: parsedUUID}, nil
+}
+
+// MarshalText 实现 encoding.TextMarshaler 接口
+// 支持GORM查询参数序列化
+func (id TimerID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler 接口
+// 支持Gin框架的URI参数绑定和GORM查询参数序列化
+func (id *TimerID) UnmarshalText(text []byte) error {
+	newID, err := NewTimerIDFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*id = newID
+	return nil
+}
+
+// UnmarshalParam 实现 binding.BindUnmarshaler 接口
+// 支持Gin框架的URI参数绑定（ShouldBindUri）和Query参数绑定
+// 注意：Gin的ShouldBindUri需要此接口才能正确绑定自定义类型
+func (id *TimerID) UnmarshalParam(param string) error {
+	newID, err := NewTimerIDFromString(param)
+	if err != nil {
+		return err
+	}
+	*id = newID
+	return nil
+}
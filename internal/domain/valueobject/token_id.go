@@ -0,0 +1,173 @@
+package valueobject
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// TokenID 执行令牌ID值对象
+type TokenID struct {
+	value uuid.UUID
+}
+
+// NewTokenID 创建新的TokenID
+// UUID v7 是基于时间戳的，适合数据库索引，时间戳 + 随机数
+func NewTokenID() TokenID {
+	return TokenID{value: uuid.Must(uuid.NewV7())}
+}
+
+// TokenIDFromString 从字符串创建TokenID
+func TokenIDFromString(s string) (TokenID, error) {
+	if s == "" {
+		return TokenID{}, nil // 空值对象
+	}
+
+	parsedUUID, err := uuid.Parse(s)
+	if err != nil {
+		return TokenID{}, fmt.Errorf("invalid TokenID format: %w", err)
+	}
+
+	return TokenID{value: parsedUUID}, nil
+}
+
+// TokenIDFromBytes 从字节数组创建TokenID（用于数据库扫描）
+func TokenIDFromBytes(b []byte) (TokenID, error) {
+	if len(b) == 0 {
+		return TokenID{}, nil
+	}
+
+	if len(b) != 16 {
+		return TokenID{}, fmt.Errorf("invalid TokenID bytes length: expected 16, got %d", len(b))
+	}
+
+	parsedUUID, err := uuid.FromBytes(b)
+	if err != nil {
+		return TokenID{}, fmt.Errorf("failed to parse TokenID from bytes: %w", err)
+	}
+
+	return TokenID{value: parsedUUID}, nil
+}
+
+// String 返回字符串表示
+func (id TokenID) String() string {
+	if id.IsEmpty() {
+		return ""
+	}
+	return id.value.String()
+}
+
+// IsEmpty 检查是否为空值对象
+func (id TokenID) IsEmpty() bool {
+	return id.value == uuid.Nil
+}
+
+// Equals 比较两个TokenID是否相等
+func (id TokenID) Equals(other TokenID) bool {
+	return id.value == other.value
+}
+
+// Value 实现driver.Valuer接口，用于数据库存储
+func (id TokenID) Value() (driver.Value, error) {
+	if id.IsEmpty() {
+		return nil, nil
+	}
+	return id.value[:], nil // 返回16字节数组用于MySQL binary(16)存储
+}
+
+// Scan 实现sql.Scanner接口，用于数据库扫描
+func (id *TokenID) Scan(value interface{}) error {
+	if value == nil {
+		*id = TokenID{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		if len(v) == 0 {
+			*id = TokenID{}
+			return nil
+		}
+		mediaID, err := TokenIDFromBytes(v)
+		if err != nil {
+			return err
+		}
+		*id = mediaID
+		return nil
+	case string:
+		mediaID, err := TokenIDFromString(v)
+		if err != nil {
+			return err
+		}
+		*id = mediaID
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into TokenID", value)
+	}
+}
+
+// MarshalJSON 实现JSON序列化
+func (id TokenID) MarshalJSON() ([]byte, error) {
+	if id.IsEmpty() {
+		return json.Marshal("")
+	}
+	return json.Marshal(id.String())
+}
+
+// UnmarshalJSON 实现JSON反序列化
+func (id *TokenID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	mediaID, err := TokenIDFromString(s)
+	if err != nil {
+		return err
+	}
+
+	*id = mediaID
+	return nil
+}
+
+// ===== URI参数绑定支持 =====
+
+// NewTokenIDFromString 从字符串创建令牌ID
+func NewTokenIDFromString(id string) (TokenID, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return TokenID{}, fmt.Errorf("无效的令牌ID格式: %w", err)
+	}
+	return TokenID{value: parsedUUID}, nil
+}
+
+// MarshalText 实现 encoding.TextMarshaler 接口
+// 支持GORM查询参数序列化
+func (id TokenID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler 接口
+// 支持Gin框架的URI参数绑定和GORM查询参数序列化
+func (id *TokenID) UnmarshalText(text []byte) error {
+	newID, err := NewTokenIDFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*id = newID
+	return nil
+}
+
+// UnmarshalParam 实现 binding.BindUnmarshaler 接口
+// 支持Gin框架的URI参数绑定（ShouldBindUri）和Query参数绑定
+// 注意：Gin的ShouldBindUri需要此接口才能正确绑定自定义类型
+func (id *TokenID) UnmarshalParam(param string) error {
+	newID, err := NewTokenIDFromString(param)
+	if err != nil {
+		return err
+	}
+	*id = newID
+	return nil
+}
@@ -0,0 +1,85 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	domain_service "jxt-evidence-system/process-management/internal/domain/service"
+	errors "jxt-evidence-system/process-management/shared/common/errors"
+)
+
+// AssigneeResolver 基于用户服务的处理人解析器
+type AssigneeResolver struct {
+	client *UserServiceClient
+}
+
+// NewAssigneeResolver 创建基于用户服务的处理人解析器
+func NewAssigneeResolver(client *UserServiceClient) *AssigneeResolver {
+	return &AssigneeResolver{client: client}
+}
+
+// Resolve 解析处理人表达式
+// 用户服务目前只提供按ID、警号查询用户和按ID、编码、名称查询组织，不支持按角色查询组织成员
+func (r *AssigneeResolver) Resolve(ctx context.Context, expr *domain_service.AssigneeExpression) ([]int, error) {
+	switch expr.Kind {
+	case domain_service.AssigneeKindPoliceNo:
+		user, err := r.client.GetUserByPoliceNo(ctx, expr.PoliceNo)
+		if err != nil {
+			return nil, err
+		}
+		return r.users(expr, user.UserId)
+	case domain_service.AssigneeKindLeaderOf:
+		userID, err := r.client.ResolveUserID(ctx, expr.User)
+		if err != nil {
+			return nil, err
+		}
+		user, err := r.client.GetUserInfo(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return r.orgLeader(ctx, expr, user.OrgID)
+	case domain_service.AssigneeKindOrgHead:
+		orgID, err := r.client.ResolveOrgID(ctx, expr.Org)
+		if err != nil {
+			return nil, err
+		}
+		if expr.Parent {
+			org, err := r.client.GetOrgInfo(ctx, orgID)
+			if err != nil {
+				return nil, err
+			}
+			orgID = org.ParentID
+		}
+		return r.orgLeader(ctx, expr, orgID)
+	case domain_service.AssigneeKindRole:
+		return nil, fmt.Errorf("%w: %s: user service does not support querying members by role", errors.ErrAssigneeNotResolved, expr.String())
+	}
+	return nil, fmt.Errorf("unknown assignee expression kind %q", expr.Kind)
+}
+
+// orgLeader 组织负责人，负责人字段为用户ID或警号
+func (r *AssigneeResolver) orgLeader(ctx context.Context, expr *domain_service.AssigneeExpression, orgID int32) ([]int, error) {
+	if orgID == 0 {
+		return r.users(expr, 0)
+	}
+	org, err := r.client.GetOrgInfo(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org.Leader == "" {
+		return r.users(expr, 0)
+	}
+	leaderID, err := r.client.ResolveUserID(ctx, org.Leader)
+	if err != nil {
+		return nil, err
+	}
+	return r.users(expr, leaderID)
+}
+
+// users 解析结果，用户ID为0时表示没有解析出用户
+func (r *AssigneeResolver) users(expr *domain_service.AssigneeExpression, userID int32) ([]int, error) {
+	if userID == 0 {
+		return nil, fmt.Errorf("%w: %s", errors.ErrAssigneeNotResolved, expr.String())
+	}
+	return []int{int(userID)}, nil
+}
@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"os"
+	"sync"
+
+	"jxt-evidence-system/process-management/internal/application/service/port"
+	"jxt-evidence-system/process-management/shared/common/di"
+
+	"github.com/ChenBigdata421/jxt-core/sdk/pkg/logger"
+)
+
+var (
+	registrations = make([]func(), 0)
+	registerOnce  sync.Once
+)
+
+// RegisterDependencies 负责依赖注入用户服务相关的实例
+func RegisterDependencies() {
+	registerOnce.Do(func() {
+		for _, f := range registrations {
+			f()
+		}
+	})
+}
+
+func init() {
+	registrations = append(registrations,
+		registerUserServiceClientDependencies,
+		registerAssigneeResolverDependencies,
+		registerUserDirectoryDependencies,
+	)
+}
+
+// 用户服务客户端的依赖注入
+// 未配置 USER_SERVICE_ADDR 或连接失败时不提供客户端，依赖用户服务的功能降级
+func registerUserServiceClientDependencies() {
+	if err := di.Provide(func() *UserServiceClient {
+		address := os.Getenv("USER_SERVICE_ADDR")
+		if address == "" {
+			return nil
+		}
+		client, err := NewUserServiceClient(address, os.Getenv("USER_SERVICE_TENANT_ID"))
+		if err != nil {
+			logger.Error("连接用户服务失败，依赖用户服务的功能降级", "error", err)
+			return nil
+		}
+		return client
+	}); err != nil {
+		logger.Fatalf("failed to provide UserServiceClient: %v", err)
+	}
+}
+
+// 处理人解析器的依赖注入，没有用户服务时引擎使用默认的内存解析器
+func registerAssigneeResolverDependencies() {
+	if err := di.Provide(func(client *UserServiceClient) port.AssigneeResolver {
+		if client == nil {
+			return nil
+		}
+		return NewAssigneeResolver(client)
+	}); err != nil {
+		logger.Fatalf("failed to provide AssigneeResolver: %v", err)
+	}
+}
+
+// 用户目录的依赖注入，没有用户服务时不校验用户是否存在
+func registerUserDirectoryDependencies() {
+	if err := di.Provide(func(client *UserServiceClient) port.UserDirectory {
+		if client == nil {
+			return nil
+		}
+		return NewUserDirectory(client)
+	}); err != nil {
+		logger.Fatalf("failed to provide UserDirectory: %v", err)
+	}
+}
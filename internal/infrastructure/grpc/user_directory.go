@@ -0,0 +1,36 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	pb "jxt-evidence-system/process-management/internal/infrastructure/grpc/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UserDirectory 基于用户服务的用户目录
+type UserDirectory struct {
+	client *UserServiceClient
+}
+
+// NewUserDirectory 创建基于用户服务的用户目录
+func NewUserDirectory(client *UserServiceClient) *UserDirectory {
+	return &UserDirectory{client: client}
+}
+
+// UserExists 用户是否存在，用户服务返回 NotFound 时视为不存在
+func (d *UserDirectory) UserExists(ctx context.Context, userID int) (bool, error) {
+	resp, err := d.client.userClient.GetUserById(ctx, &pb.GetUserByIdReq{
+		TenantId: d.client.tenantID,
+		UserId:   int32(userID),
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get user by ID %d: %v", userID, err)
+	}
+	return resp.UserId != 0, nil
+}
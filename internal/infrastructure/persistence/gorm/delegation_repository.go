@@ -0,0 +1,90 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	delegation_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/delegation"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+
+	"gorm.io/gorm"
+)
+
+// delegationRepository 委托规则仓储实现
+type delegationRepository struct {
+	GormRepository
+}
+
+// Save 保存委托规则
+func (r *delegationRepository) Save(ctx context.Context, rule *delegation_aggregate.DelegationRule) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Create(rule).Error
+}
+
+// FindByID 根据ID查找委托规则
+func (r *delegationRepository) FindByID(ctx context.Context, id valueobject.DelegationID) (*delegation_aggregate.DelegationRule, error) {
+	var rule delegation_aggregate.DelegationRule
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).Where("id = ?", id).First(&rule).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors_.ErrDelegationNotFound
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// FindByUserID 查找用户设置的全部委托规则
+func (r *delegationRepository) FindByUserID(ctx context.Context, userID int) ([]*delegation_aggregate.DelegationRule, error) {
+	var rules []*delegation_aggregate.DelegationRule
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("start_at DESC").
+		Find(&rules).Error
+	return rules, err
+}
+
+// FindActive 查找用户在指定时间生效的委托规则
+func (r *delegationRepository) FindActive(ctx context.Context, userID int, at time.Time) ([]*delegation_aggregate.DelegationRule, error) {
+	var rules []*delegation_aggregate.DelegationRule
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).
+		Where("user_id = ? AND start_at <= ? AND end_at > ?", userID, at, at).
+		Order("created_at DESC").
+		Find(&rules).Error
+	return rules, err
+}
+
+// Update 更新委托规则
+func (r *delegationRepository) Update(ctx context.Context, rule *delegation_aggregate.DelegationRule) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Save(rule).Error
+}
+
+// Delete 删除委托规则
+func (r *delegationRepository) Delete(ctx context.Context, id valueobject.DelegationID) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Where("id = ?", id).Delete(&delegation_aggregate.DelegationRule{}).Error
+}
@@ -3,8 +3,12 @@ package persistence
 import (
 	"sync"
 
+	"jxt-evidence-system/process-management/internal/application/service/port"
+	delegation_repository "jxt-evidence-system/process-management/internal/domain/aggregate/delegation/repository"
 	instance_repository "jxt-evidence-system/process-management/internal/domain/aggregate/instance/repository"
 	task_repository "jxt-evidence-system/process-management/internal/domain/aggregate/task/repository"
+	timer_repository "jxt-evidence-system/process-management/internal/domain/aggregate/timer/repository"
+	token_repository "jxt-evidence-system/process-management/internal/domain/aggregate/token/repository"
 	workflow_repository "jxt-evidence-system/process-management/internal/domain/aggregate/workflow/repository"
 	"jxt-evidence-system/process-management/shared/common/di"
 
@@ -43,6 +47,14 @@ func registerWorkflowRepoDependencies() {
 	}
 }
 
+func registerWorkflowVersionRepoDependencies() {
+	if err := di.Provide(func() workflow_repository.WorkflowVersionRepository {
+		return &workflowVersionRepository{}
+	}); err != nil {
+		logger.Fatalf("failed to provide workflowVersionRepository: %v", err)
+	}
+}
+
 func registerTaskRepoDependencies() {
 	if err := di.Provide(func() task_repository.TaskRepository {
 		return &taskRepository{}
@@ -59,11 +71,48 @@ func registerTaskHistoryRepoDependencies() {
 	}
 }
 
+func registerTimerRepoDependencies() {
+	if err := di.Provide(func() timer_repository.TimerRepository {
+		return &timerRepository{}
+	}); err != nil {
+		logger.Fatalf("failed to provide timerRepository: %v", err)
+	}
+}
+
+func registerTokenRepoDependencies() {
+	if err := di.Provide(func() token_repository.TokenRepository {
+		return &tokenRepository{}
+	}); err != nil {
+		logger.Fatalf("failed to provide tokenRepository: %v", err)
+	}
+}
+
+func registerDelegationRepoDependencies() {
+	if err := di.Provide(func() delegation_repository.DelegationRepository {
+		return &delegationRepository{}
+	}); err != nil {
+		logger.Fatalf("failed to provide delegationRepository: %v", err)
+	}
+}
+
+func registerTransactionManagerDependencies() {
+	if err := di.Provide(func() port.TransactionManager {
+		return &transactionManager{}
+	}); err != nil {
+		logger.Fatalf("failed to provide transactionManager: %v", err)
+	}
+}
+
 func init() {
 	registrations = append(registrations,
 		registerWorkflowInstanceRepoDependencies,
 		registerWorkflowRepoDependencies,
+		registerWorkflowVersionRepoDependencies,
 		registerTaskRepoDependencies,
 		registerTaskHistoryRepoDependencies,
+		registerTimerRepoDependencies,
+		registerTokenRepoDependencies,
+		registerDelegationRepoDependencies,
+		registerTransactionManagerDependencies,
 	)
 }
@@ -10,8 +10,14 @@ import (
 
 type GormRepository struct{}
 
+// txContextKey 上下文中保存事务连接的键
+type txContextKey struct{}
+
 // GetDB 从 SDK Runtime 获取数据库连接
 func (e *GormRepository) GetDB(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
 	db := sdk.Runtime.GetTenantDB("*")
 	if db == nil {
 		panic("database not initialized, call database.Setup first")
@@ -20,7 +26,11 @@ func (e *GormRepository) GetDB(ctx context.Context) *gorm.DB {
 }
 
 // GetOrm 获取带上下文的数据库连接（兼容旧代码）
+// 上下文中有事务时返回事务连接
 func (e *GormRepository) GetOrm(ctx context.Context) (*gorm.DB, error) {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx), nil
+	}
 	db := sdk.Runtime.GetTenantDB("*")
 	if db == nil {
 		return nil, fmt.Errorf("database not initialized, call database.Setup first")
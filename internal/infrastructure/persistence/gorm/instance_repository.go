@@ -9,6 +9,7 @@ import (
 	errors_ "jxt-evidence-system/process-management/shared/common/errors"
 	"jxt-evidence-system/process-management/shared/common/global"
 	cQuery "jxt-evidence-system/process-management/shared/common/query"
+	"jxt-evidence-system/process-management/shared/common/status"
 
 	"gorm.io/gorm"
 )
@@ -111,6 +112,34 @@ func (r *workflowInstanceRepository) CountByWorkflowID(ctx context.Context, work
 	return count, err
 }
 
+// FindRunningByWorkflowID 查找工作流下全部运行中的实例
+func (r *workflowInstanceRepository) FindRunningByWorkflowID(ctx context.Context, workflowID valueobject.WorkflowID) ([]*instance_aggregate.WorkflowInstance, error) {
+	var instances []*instance_aggregate.WorkflowInstance
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).
+		Where("workflow_id = ? AND status = ?", workflowID, status.InstanceStatusRunning).
+		Order("created_at ASC").
+		Find(&instances).Error
+	return instances, err
+}
+
+// FindChildren 查找父实例的全部子实例
+func (r *workflowInstanceRepository) FindChildren(ctx context.Context, parentID valueobject.InstanceID) ([]*instance_aggregate.WorkflowInstance, error) {
+	var instances []*instance_aggregate.WorkflowInstance
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).
+		Where("parent_instance_id = ?", parentID).
+		Order("created_at ASC").
+		Find(&instances).Error
+	return instances, err
+}
+
 // GetPage 查找所有实例（支持筛选）
 func (r *workflowInstanceRepository) GetPage(ctx context.Context, query *command.InstancePagedQuery) ([]*instance_aggregate.WorkflowInstance, int, error) {
 	var instances []*instance_aggregate.WorkflowInstance
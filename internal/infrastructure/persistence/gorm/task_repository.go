@@ -44,6 +44,7 @@ func (r *taskRepository) FindByID(ctx context.Context, id valueobject.TaskID) (*
 		Preload("Instance", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "instance_no")
 		}).
+		Preload("Candidates").
 		Where("id = ?", id).First(&task).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -71,6 +72,7 @@ func (r *taskRepository) FindByInstanceID(ctx context.Context, instanceID valueo
 		Preload("Instance", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "instance_no")
 		}).
+		Preload("Candidates").
 		Where("instance_id = ?", instanceID).
 		Find(&tasks).Error
 	for i := range tasks {
@@ -156,7 +158,9 @@ func (r *taskRepository) FindTodoByAssignee(ctx context.Context, assignee int, q
 		Joins("LEFT JOIN workflows ON workflow_tasks.workflow_id = workflows.id").
 		Select("workflow_tasks.*", "workflows.name as worflow_name").
 		Where("(workflow_tasks.assignee = ?)", assignee).
-		Where("workflow_tasks.status IN (?)", status.TaskStatusPending)
+		Where("workflow_tasks.status IN (?)", status.TaskStatusPending).
+		// 挂起实例的任务暂不能处理，不出现在待办中
+		Where("NOT EXISTS (SELECT 1 FROM workflow_instances WHERE workflow_instances.id = workflow_tasks.instance_id AND workflow_instances.status = ?)", status.InstanceStatusSuspended)
 
 	// 按任务名称查询
 	if query.TaskName != "" {
@@ -246,6 +250,110 @@ func (r *taskRepository) FindDoneByAssignee(ctx context.Context, assignee int, q
 	return tasks, int(total), nil
 }
 
+// FindClaimable 查找用户可认领的任务：待处理、未分配处理人，且用户是候选用户或属于候选组
+func (r *taskRepository) FindClaimable(ctx context.Context, userID int, userGroups []int, query *command.ClaimableTaskPagedQuery) ([]*task_aggregate.Task, int, error) {
+	var tasks []*task_aggregate.Task
+	var total int64
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 用户是候选用户，或属于任一候选组
+	candidate := db.Table("workflow_task_candidates").Select("1").
+		Where("workflow_task_candidates.task_id = workflow_tasks.id")
+	if len(userGroups) > 0 {
+		candidate = candidate.Where("(workflow_task_candidates.candidate_type = ? AND workflow_task_candidates.candidate_id = ?) OR (workflow_task_candidates.candidate_type = ? AND workflow_task_candidates.candidate_id IN (?))",
+			task_aggregate.CandidateTypeUser, userID, task_aggregate.CandidateTypeGroup, userGroups)
+	} else {
+		candidate = candidate.Where("workflow_task_candidates.candidate_type = ? AND workflow_task_candidates.candidate_id = ?", task_aggregate.CandidateTypeUser, userID)
+	}
+
+	baseQuery := db.WithContext(ctx).Debug().
+		Preload("Workflow", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "workflow_no", "name")
+		}).
+		Preload("Instance", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "instance_no")
+		}).
+		Preload("Candidates").
+		Joins("LEFT JOIN workflows ON workflow_tasks.workflow_id = workflows.id").
+		Select("workflow_tasks.*", "workflows.name as worflow_name").
+		Where("workflow_tasks.assignee = 0 AND workflow_tasks.claimed_at IS NULL").
+		Where("workflow_tasks.status = ?", status.TaskStatusPending).
+		Where("EXISTS (?)", candidate).
+		// 挂起实例的任务暂不能处理，不出现在待领列表中
+		Where("NOT EXISTS (SELECT 1 FROM workflow_instances WHERE workflow_instances.id = workflow_tasks.instance_id AND workflow_instances.status = ?)", status.InstanceStatusSuspended)
+
+	// 按任务名称查询
+	if query.TaskName != "" {
+		baseQuery = baseQuery.Where("workflow_tasks.task_name LIKE ?", "%"+query.TaskName+"%")
+	}
+
+	// 按工作流名称查询
+	if query.WorkflowName != "" {
+		baseQuery = baseQuery.Where("workflows.name LIKE ?", "%"+query.WorkflowName+"%")
+	}
+
+	// 获取总数
+	if err := baseQuery.Model(&task_aggregate.Task{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 获取分页数据
+	if err := baseQuery.
+		Limit(query.GetPageSize()).
+		Offset((query.GetPageIndex() - 1) * query.GetPageSize()).
+		Order("priority DESC, created_at ASC").
+		Find(&tasks).Error; err != nil {
+		return nil, 0, err
+	}
+	for i := range tasks {
+		tasks[i].WorklowName = tasks[i].Workflow.Name
+		tasks[i].InstaceNo = tasks[i].Instance.InstanceNo
+		tasks[i].WorkflowNo = tasks[i].Workflow.WorkflowNo
+	}
+	return tasks, int(total), nil
+}
+
+// Claim 通过条件更新认领任务，只有一个用户能够成功
+func (r *taskRepository) Claim(ctx context.Context, task *task_aggregate.Task) (bool, error) {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return false, err
+	}
+	result := db.WithContext(ctx).Model(&task_aggregate.Task{}).
+		Where("id = ? AND status = ? AND claimed_at IS NULL AND assignee IN (0, ?)", task.TaskID, status.TaskStatusPending, task.Assignee).
+		Updates(map[string]interface{}{
+			"assignee":   task.Assignee,
+			"claimed_at": task.ClaimedAt,
+			"updated_at": task.UpdatedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Unclaim 通过条件更新放弃认领
+func (r *taskRepository) Unclaim(ctx context.Context, task *task_aggregate.Task, userID int) (bool, error) {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return false, err
+	}
+	result := db.WithContext(ctx).Model(&task_aggregate.Task{}).
+		Where("id = ? AND status = ? AND assignee = ? AND claimed_at IS NOT NULL", task.TaskID, status.TaskStatusPending, userID).
+		Updates(map[string]interface{}{
+			"assignee":   task.Assignee,
+			"claimed_at": nil,
+			"updated_at": task.UpdatedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // GetPage 查找所有任务（支持筛选）
 func (r *taskRepository) GetPage(ctx context.Context, query *command.TaskPagedQuery) ([]*task_aggregate.Task, int, error) {
 	var tasks []*task_aggregate.Task
@@ -286,8 +394,8 @@ func (r *taskRepository) Update(ctx context.Context, task *task_aggregate.Task)
 	if err != nil {
 		return err
 	}
-	// 使用 Omit 排除关联对象，避免自动保存 Workflow 导致 JSON 格式错误
-	err = db.WithContext(ctx).Omit("Workflow", "Instance").Save(task).Error
+	// 使用 Omit 排除关联对象，避免自动保存 Workflow 导致 JSON 格式错误；候选人创建后不再变更
+	err = db.WithContext(ctx).Omit("Workflow", "Instance", "Candidates").Save(task).Error
 	return err
 }
 
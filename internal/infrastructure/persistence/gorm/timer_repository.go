@@ -0,0 +1,146 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	timer_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/timer"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+	"jxt-evidence-system/process-management/shared/common/status"
+
+	"gorm.io/gorm"
+)
+
+// timerRepository 定时器仓储实现
+type timerRepository struct {
+	GormRepository
+}
+
+// Save 保存定时器
+func (r *timerRepository) Save(ctx context.Context, timer *timer_aggregate.Timer) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Create(timer).Error
+}
+
+// FindByID 根据ID查找定时器
+func (r *timerRepository) FindByID(ctx context.Context, id valueobject.TimerID) (*timer_aggregate.Timer, error) {
+	var timer timer_aggregate.Timer
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).Where("id = ?", id).First(&timer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors_.ErrTimerNotFound
+		}
+		return nil, err
+	}
+	return &timer, nil
+}
+
+// FindDue 查找到期且未触发的定时器
+func (r *timerRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]*timer_aggregate.Timer, error) {
+	var timers []*timer_aggregate.Timer
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).
+		Where("status = ? AND due_at <= ?", status.TimerStatusPending, now).
+		Order("due_at ASC").
+		Limit(limit).
+		Find(&timers).Error
+	return timers, err
+}
+
+// Claim 通过条件更新抢占定时器，只有一个调度器能够成功
+func (r *timerRepository) Claim(ctx context.Context, id valueobject.TimerID) (bool, error) {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	result := db.WithContext(ctx).Model(&timer_aggregate.Timer{}).
+		Where("id = ? AND status = ?", id, status.TimerStatusPending).
+		Updates(map[string]interface{}{
+			"status":     status.TimerStatusFired,
+			"fired_at":   now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Update 更新定时器
+func (r *timerRepository) Update(ctx context.Context, timer *timer_aggregate.Timer) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Save(timer).Error
+}
+
+// CancelByTaskID 取消任务下所有待触发的定时器
+func (r *timerRepository) CancelByTaskID(ctx context.Context, taskID valueobject.TaskID) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Model(&timer_aggregate.Timer{}).
+		Where("task_id = ? AND status = ?", taskID, status.TimerStatusPending).
+		Updates(map[string]interface{}{
+			"status":     status.TimerStatusCancelled,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// CancelByInstanceID 取消实例下所有待触发（含挂起暂停）的定时器
+func (r *timerRepository) CancelByInstanceID(ctx context.Context, instanceID valueobject.InstanceID) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Model(&timer_aggregate.Timer{}).
+		Where("instance_id = ? AND status IN ?", instanceID, []status.TimerStatus{status.TimerStatusPending, status.TimerStatusPaused}).
+		Updates(map[string]interface{}{
+			"status":     status.TimerStatusCancelled,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// PauseByInstanceID 暂停实例下所有待触发的定时器，调度器不再扫描
+func (r *timerRepository) PauseByInstanceID(ctx context.Context, instanceID valueobject.InstanceID) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Model(&timer_aggregate.Timer{}).
+		Where("instance_id = ? AND status = ?", instanceID, status.TimerStatusPending).
+		Updates(map[string]interface{}{
+			"status":     status.TimerStatusPaused,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// ResumeByInstanceID 恢复实例下暂停的定时器，触发时间顺延挂起的时长
+func (r *timerRepository) ResumeByInstanceID(ctx context.Context, instanceID valueobject.InstanceID, shift time.Duration) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Model(&timer_aggregate.Timer{}).
+		Where("instance_id = ? AND status = ?", instanceID, status.TimerStatusPaused).
+		Updates(map[string]interface{}{
+			"status":     status.TimerStatusPending,
+			"due_at":     gorm.Expr("due_at + make_interval(secs => ?)", shift.Seconds()),
+			"updated_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,108 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	token_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/token"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+	"jxt-evidence-system/process-management/shared/common/status"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tokenRepository 执行令牌仓储实现
+type tokenRepository struct {
+	GormRepository
+}
+
+// Save 保存令牌
+func (r *tokenRepository) Save(ctx context.Context, token *token_aggregate.ExecutionToken) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Create(token).Error
+}
+
+// FindByID 根据ID查找令牌
+func (r *tokenRepository) FindByID(ctx context.Context, id valueobject.TokenID) (*token_aggregate.ExecutionToken, error) {
+	var token token_aggregate.ExecutionToken
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).Where("id = ?", id).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors_.ErrTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindByIDForUpdate 根据ID查找令牌并锁定该行（SELECT ... FOR UPDATE）
+func (r *tokenRepository) FindByIDForUpdate(ctx context.Context, id valueobject.TokenID) (*token_aggregate.ExecutionToken, error) {
+	var token token_aggregate.ExecutionToken
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors_.ErrTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindByInstanceID 查找实例的所有令牌
+func (r *tokenRepository) FindByInstanceID(ctx context.Context, instanceID valueobject.InstanceID) ([]*token_aggregate.ExecutionToken, error) {
+	var tokens []*token_aggregate.ExecutionToken
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).Where("instance_id = ?", instanceID).Order("created_at ASC").Find(&tokens).Error
+	return tokens, err
+}
+
+// FindChildren 查找子令牌
+func (r *tokenRepository) FindChildren(ctx context.Context, parentID valueobject.TokenID) ([]*token_aggregate.ExecutionToken, error) {
+	var tokens []*token_aggregate.ExecutionToken
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).Where("parent_id = ?", parentID).Order("created_at ASC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Update 更新令牌
+func (r *tokenRepository) Update(ctx context.Context, token *token_aggregate.ExecutionToken) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Save(token).Error
+}
+
+// CloseByInstanceID 结束实例下所有未结束的令牌
+func (r *tokenRepository) CloseByInstanceID(ctx context.Context, instanceID valueobject.InstanceID, tokenStatus status.TokenStatus) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Model(&token_aggregate.ExecutionToken{}).
+		Where("instance_id = ? AND status IN ?", instanceID, []status.TokenStatus{status.TokenStatusActive, status.TokenStatusForked, status.TokenStatusWaiting}).
+		Updates(map[string]interface{}{
+			"status":     tokenStatus,
+			"updated_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,27 @@
+package persistence
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// transactionManager 基于 gorm 的事务管理器
+// 事务连接保存在上下文中，仓储通过 GetOrm 取得同一个事务
+type transactionManager struct {
+	GormRepository
+}
+
+// Transaction 在事务中执行 fn，fn 返回错误时回滚；已在事务中时直接加入外层事务
+func (m *transactionManager) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	db, err := m.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
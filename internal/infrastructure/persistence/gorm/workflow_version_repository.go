@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+
+	workflow_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/workflow"
+	"jxt-evidence-system/process-management/internal/domain/valueobject"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+
+	"gorm.io/gorm"
+)
+
+// workflowVersionRepository 工作流版本仓储实现
+type workflowVersionRepository struct {
+	GormRepository
+}
+
+// Save 保存工作流版本
+func (r *workflowVersionRepository) Save(ctx context.Context, version *workflow_aggregate.WorkflowVersion) error {
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Create(version).Error
+}
+
+// FindByVersion 根据版本号查找工作流版本
+func (r *workflowVersionRepository) FindByVersion(ctx context.Context, workflowID valueobject.WorkflowID, version int) (*workflow_aggregate.WorkflowVersion, error) {
+	var v workflow_aggregate.WorkflowVersion
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).Where("workflow_id = ? AND version = ?", workflowID, version).First(&v).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors_.ErrWorkflowVersionNotFound
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// FindLatest 查找最近发布的版本
+func (r *workflowVersionRepository) FindLatest(ctx context.Context, workflowID valueobject.WorkflowID) (*workflow_aggregate.WorkflowVersion, error) {
+	var v workflow_aggregate.WorkflowVersion
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).Where("workflow_id = ?", workflowID).Order("version DESC").First(&v).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors_.ErrWorkflowVersionNotFound
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// FindByWorkflowID 列出工作流的全部版本
+func (r *workflowVersionRepository) FindByWorkflowID(ctx context.Context, workflowID valueobject.WorkflowID) ([]*workflow_aggregate.WorkflowVersion, error) {
+	var versions []*workflow_aggregate.WorkflowVersion
+	db, err := r.GetOrm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = db.WithContext(ctx).Where("workflow_id = ?", workflowID).Order("version DESC").Find(&versions).Error
+	return versions, err
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"jxt-evidence-system/process-management/internal/application/command"
+	"jxt-evidence-system/process-management/internal/application/service/port"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
+	"jxt-evidence-system/process-management/shared/common/global"
+	"jxt-evidence-system/process-management/shared/common/restapi"
+
+	jwtuser "github.com/ChenBigdata421/jxt-core/sdk/pkg/jwtauth/user"
+	"github.com/ChenBigdata421/jxt-core/sdk/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// DelegationHandler 委托规则HTTP处理器
+type DelegationHandler struct {
+	restapi.RestApi
+	delegationService port.DelegationService
+}
+
+// CreateDelegation 创建委托规则，委托人为当前用户
+func (h *DelegationHandler) CreateDelegation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	var cmd command.CreateDelegationCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		logger.Error("绑定创建委托规则命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	cmd.UserID = int(jwtuser.GetUserId(c))
+
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	id, err := h.delegationService.CreateDelegation(ctx, &cmd)
+	if err != nil {
+		logger.Error("创建委托规则失败", "error", err)
+		switch {
+		case errors.Is(err, errors_.ErrInvalidDelegation):
+			h.Error(c, http.StatusBadRequest, err, "受托人不能为空或自己，结束时间必须晚于开始时间")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "创建委托规则失败")
+		}
+		return
+	}
+
+	h.OK(c, gin.H{"id": id}, "创建委托规则成功")
+}
+
+// GetDelegations 我的委托规则
+func (h *DelegationHandler) GetDelegations(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	rules, err := h.delegationService.GetDelegationsByUser(ctx, int(jwtuser.GetUserId(c)))
+	if err != nil {
+		h.Error(c, http.StatusInternalServerError, err, "查询委托规则失败")
+		return
+	}
+
+	h.OK(c, rules, "查询成功")
+}
+
+// GetDelegation 获取委托规则
+func (h *DelegationHandler) GetDelegation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	var cmd command.GetDelegationCommand
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		logger.Error("绑定获取委托规则命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	rule, err := h.delegationService.GetDelegationByID(ctx, cmd.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, errors_.ErrDelegationNotFound):
+			h.Error(c, http.StatusNotFound, err, "委托规则不存在")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "获取委托规则失败")
+		}
+		return
+	}
+
+	h.OK(c, rule, "获取委托规则成功")
+}
+
+// UpdateDelegation 修改委托规则（仅限委托人）
+func (h *DelegationHandler) UpdateDelegation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	var cmd command.UpdateDelegationCommand
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		logger.Error("绑定修改委托规则命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		logger.Error("绑定修改委托规则命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	cmd.UserID = int(jwtuser.GetUserId(c))
+
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	if err := h.delegationService.UpdateDelegation(ctx, &cmd); err != nil {
+		logger.Error("修改委托规则失败", "error", err)
+		switch {
+		case errors.Is(err, errors_.ErrDelegationNotFound):
+			h.Error(c, http.StatusNotFound, err, "委托规则不存在")
+		case errors.Is(err, errors_.ErrUnauthorized):
+			h.Error(c, http.StatusForbidden, err, "只能修改自己的委托规则")
+		case errors.Is(err, errors_.ErrInvalidDelegation):
+			h.Error(c, http.StatusBadRequest, err, "受托人不能为空或自己，结束时间必须晚于开始时间")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "修改委托规则失败")
+		}
+		return
+	}
+
+	h.OK(c, nil, "修改委托规则成功")
+}
+
+// DeleteDelegation 删除委托规则（仅限委托人）
+func (h *DelegationHandler) DeleteDelegation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	var cmd command.DeleteDelegationCommand
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		logger.Error("绑定删除委托规则命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	cmd.UserID = int(jwtuser.GetUserId(c))
+
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	if err := h.delegationService.DeleteDelegation(ctx, &cmd); err != nil {
+		logger.Error("删除委托规则失败", "error", err)
+		switch {
+		case errors.Is(err, errors_.ErrDelegationNotFound):
+			h.Error(c, http.StatusNotFound, err, "委托规则不存在")
+		case errors.Is(err, errors_.ErrUnauthorized):
+			h.Error(c, http.StatusForbidden, err, "只能删除自己的委托规则")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "删除委托规则失败")
+		}
+		return
+	}
+
+	h.OK(c, nil, "删除委托规则成功")
+}
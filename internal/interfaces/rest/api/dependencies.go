@@ -30,6 +30,7 @@ func init() {
 		registerWorkflowApiDependencies,
 		registerInstanceApiDependencies,
 		registerTaskApiDependencies,
+		registerDelegationApiDependencies,
 		registerWebSocketApiDependencies,
 	)
 }
@@ -56,6 +57,17 @@ func registerTaskApiDependencies() {
 	}
 }
 
+func registerDelegationApiDependencies() {
+	err := di.Provide(func(delegationService port.DelegationService) *DelegationHandler {
+		return &DelegationHandler{
+			delegationService: delegationService,
+		}
+	})
+	if err != nil {
+		logger.Fatalf("Failed to provide DelegationHandler: %v", err)
+	}
+}
+
 func registerWorkflowApiDependencies() {
 	err := di.Provide(func(
 		workflowService port.WorkflowService,
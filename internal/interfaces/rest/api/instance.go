@@ -2,11 +2,13 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	"jxt-evidence-system/process-management/internal/application/command"
 	"jxt-evidence-system/process-management/internal/application/service/port"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
 	"jxt-evidence-system/process-management/shared/common/global"
 	"jxt-evidence-system/process-management/shared/common/restapi"
 
@@ -21,7 +23,13 @@ type InstanceHandler struct {
 	instanceService port.InstanceService
 }
 
-// CancelInstance 取消工作流实例（将状态标记为取消）
+// instanceOperatorRoles 允许人工干预实例运行（如跳转）的角色
+var instanceOperatorRoles = map[string]bool{
+	"admin":    true,
+	"operator": true,
+}
+
+// CancelInstance 取消工作流实例，未处理的任务一并取消
 func (h *InstanceHandler) CancelInstance(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 20*time.Second)
 	defer cancel()
@@ -32,11 +40,24 @@ func (h *InstanceHandler) CancelInstance(c *gin.Context) {
 		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
 		return
 	}
+	if err := c.ShouldBindQuery(&cmd); err != nil {
+		logger.Error("绑定取消工作流实例命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	cmd.SetUpdateBy(jwtuser.GetUserId(c))
 
 	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
 	if err := h.instanceService.CancelInstance(ctx, &cmd); err != nil {
 		logger.Error("取消工作流实例失败", "error", err)
-		h.Error(c, http.StatusInternalServerError, err, "取消工作流实例失败")
+		switch {
+		case errors.Is(err, errors_.ErrInstanceNotFound):
+			h.Error(c, http.StatusNotFound, err, "工作流实例不存在")
+		case errors.Is(err, errors_.ErrCannotCancelCompletedInstance):
+			h.Error(c, http.StatusBadRequest, err, "实例已结束，不能取消")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "取消工作流实例失败")
+		}
 		return
 	}
 
@@ -54,6 +75,7 @@ func (h *InstanceHandler) StartInstance(c *gin.Context) {
 		return
 	}
 	userID := jwtuser.GetUserId(c)
+	cmd.InitiatorID = int(userID)
 	ctx = context.WithValue(ctx, global.UserIDKey, int(userID))
 	// 设置租户ID（单租户模式使用默认租户 "*"）
 	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
@@ -107,6 +129,157 @@ func (h *InstanceHandler) GetInstanceDetail(c *gin.Context) {
 	h.OK(c, dto, "获取工作流实例详情成功")
 }
 
+// JumpInstance 将实例跳转到指定步骤（仅限运维角色）
+func (h *InstanceHandler) JumpInstance(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 20*time.Second)
+	defer cancel()
+
+	if !instanceOperatorRoles[jwtuser.GetRoleName(c)] {
+		h.Error(c, http.StatusForbidden, errors_.ErrUnauthorized, "只有运维人员可以跳转实例")
+		return
+	}
+
+	var cmd command.JumpInstanceCommand
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		logger.Error("绑定实例跳转命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		logger.Error("绑定实例跳转命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	cmd.SetUpdateBy(jwtuser.GetUserId(c))
+
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	result, err := h.instanceService.JumpInstance(ctx, &cmd)
+	if err != nil {
+		logger.Error("实例跳转失败", "error", err)
+		switch {
+		case errors.Is(err, errors_.ErrInstanceNotFound):
+			h.Error(c, http.StatusNotFound, err, "工作流实例不存在")
+		case errors.Is(err, errors_.ErrJumpTargetNotFound):
+			h.Error(c, http.StatusBadRequest, err, "跳转目标步骤不存在")
+		case errors.Is(err, errors_.ErrInvalidInstanceStatusTransition):
+			h.Error(c, http.StatusBadRequest, err, "实例当前状态不能跳转")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "实例跳转失败")
+		}
+		return
+	}
+
+	h.OK(c, result, "实例跳转成功")
+}
+
+// SuspendInstance 挂起运行中的实例（仅限运维角色）
+func (h *InstanceHandler) SuspendInstance(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 20*time.Second)
+	defer cancel()
+
+	if !instanceOperatorRoles[jwtuser.GetRoleName(c)] {
+		h.Error(c, http.StatusForbidden, errors_.ErrUnauthorized, "只有运维人员可以挂起实例")
+		return
+	}
+
+	var cmd command.SuspendInstanceCommand
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		logger.Error("绑定挂起实例命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		logger.Error("绑定挂起实例命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	cmd.SetUpdateBy(jwtuser.GetUserId(c))
+
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	if err := h.instanceService.SuspendInstance(ctx, &cmd); err != nil {
+		logger.Error("挂起实例失败", "error", err)
+		switch {
+		case errors.Is(err, errors_.ErrInstanceNotFound):
+			h.Error(c, http.StatusNotFound, err, "工作流实例不存在")
+		case errors.Is(err, errors_.ErrInvalidInstanceStatusTransition):
+			h.Error(c, http.StatusBadRequest, err, "只有运行中的实例可以挂起")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "挂起实例失败")
+		}
+		return
+	}
+
+	h.OK(c, nil, "挂起实例成功")
+}
+
+// ResumeInstance 恢复挂起的实例（仅限运维角色）
+func (h *InstanceHandler) ResumeInstance(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 20*time.Second)
+	defer cancel()
+
+	if !instanceOperatorRoles[jwtuser.GetRoleName(c)] {
+		h.Error(c, http.StatusForbidden, errors_.ErrUnauthorized, "只有运维人员可以恢复实例")
+		return
+	}
+
+	var cmd command.ResumeInstanceCommand
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		logger.Error("绑定恢复实例命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		logger.Error("绑定恢复实例命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	cmd.SetUpdateBy(jwtuser.GetUserId(c))
+
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	if err := h.instanceService.ResumeInstance(ctx, &cmd); err != nil {
+		logger.Error("恢复实例失败", "error", err)
+		switch {
+		case errors.Is(err, errors_.ErrInstanceNotFound):
+			h.Error(c, http.StatusNotFound, err, "工作流实例不存在")
+		case errors.Is(err, errors_.ErrInvalidInstanceStatusTransition):
+			h.Error(c, http.StatusBadRequest, err, "只有挂起的实例可以恢复")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "恢复实例失败")
+		}
+		return
+	}
+
+	h.OK(c, nil, "恢复实例成功")
+}
+
+// GetInstanceTokens 获取实例的执行令牌
+func (h *InstanceHandler) GetInstanceTokens(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 20*time.Second)
+	defer cancel()
+
+	var cmd command.GetInstanceCommand
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		logger.Error("绑定获取实例执行令牌命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	tokens, err := h.instanceService.GetInstanceTokens(ctx, cmd.ID)
+	if err != nil {
+		if errors.Is(err, errors_.ErrInstanceNotFound) {
+			h.Error(c, http.StatusNotFound, err, "工作流实例不存在")
+			return
+		}
+		h.Error(c, http.StatusInternalServerError, err, "获取实例执行令牌失败")
+		return
+	}
+	h.OK(c, tokens, "获取实例执行令牌成功")
+}
+
 // ListInstances 列出工作流实例
 func (h *InstanceHandler) GetInstancesByWorkflow(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
@@ -152,6 +325,29 @@ func (h *InstanceHandler) GetPage(c *gin.Context) {
 	h.PageOK(c, instances, int(total), query.GetPageIndex(), query.GetPageSize(), "查询成功")
 }
 
+// GetStartedInstances 我发起的工作流实例，附带状态和当前步骤
+func (h *InstanceHandler) GetStartedInstances(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	var query command.InstancePagedQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		h.GetLogger(c).Error(err.Error())
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+	query.InitiatorID = int(jwtuser.GetUserId(c))
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	instances, total, err := h.instanceService.GetStartedInstances(ctx, &query)
+	if err != nil {
+		h.Error(c, http.StatusInternalServerError, err, "查询我发起的实例失败")
+		return
+	}
+
+	h.PageOK(c, instances, int(total), query.GetPageIndex(), query.GetPageSize(), "查询成功")
+}
+
 // DeleteInstance 删除工作流实例
 func (h *InstanceHandler) DeleteInstance(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 20*time.Second)
@@ -2,12 +2,15 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	"jxt-evidence-system/process-management/internal/application/command"
 	"jxt-evidence-system/process-management/internal/application/service/port"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
 	"jxt-evidence-system/process-management/shared/common/global"
+	authhandler "jxt-evidence-system/process-management/shared/common/middleware/handler"
 	"jxt-evidence-system/process-management/shared/common/status"
 
 	"jxt-evidence-system/process-management/shared/common/restapi"
@@ -142,6 +145,141 @@ func (h *TaskHandler) GetTodoTasks(c *gin.Context) {
 	h.PageOK(c, tasks, int(total), query.GetPageIndex(), query.GetPageSize(), "查询成功")
 }
 
+// GetClaimableTasks 查询待领任务：当前用户作为候选用户或候选组成员可以认领的任务
+func (h *TaskHandler) GetClaimableTasks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	userID := jwtuser.GetUserId(c)
+	if userID == 0 {
+		logger.Error("获取用户ID失败")
+		h.Error(c, http.StatusUnauthorized, nil, "获取当前用户ID失败")
+		return
+	}
+
+	var query command.ClaimableTaskPagedQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		h.GetLogger(c).Error(err.Error())
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	tasks, total, err := h.taskService.GetClaimableTasks(ctx, userID, userGroups(c), &query)
+	if err != nil {
+		logger.Error("查询待领任务失败", "error", err)
+		h.Error(c, http.StatusInternalServerError, err, "查询待领任务失败")
+		return
+	}
+
+	h.PageOK(c, tasks, int(total), query.GetPageIndex(), query.GetPageSize(), "查询成功")
+}
+
+// ClaimTask 认领任务
+func (h *TaskHandler) ClaimTask(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	userID := jwtuser.GetUserId(c)
+	if userID == 0 {
+		logger.Error("获取用户ID失败")
+		h.Error(c, http.StatusInternalServerError, nil, "认领任务失败")
+		return
+	}
+
+	var cmd command.ClaimTaskCommand
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		logger.Error("绑定认领任务命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	cmd.UserID = int(userID)
+	cmd.UserGroups = userGroups(c)
+
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	if err := h.taskService.ClaimTask(ctx, &cmd); err != nil {
+		logger.Error("认领任务失败", "error", err)
+		switch {
+		case errors.Is(err, errors_.ErrTaskNotFound):
+			h.Error(c, http.StatusNotFound, err, "任务不存在")
+		case errors.Is(err, errors_.ErrTaskAlreadyClaimed):
+			h.Error(c, http.StatusConflict, err, "任务已被他人认领")
+		case errors.Is(err, errors_.ErrTaskNotClaimable):
+			h.Error(c, http.StatusForbidden, err, "不是任务的候选人，不能认领")
+		case errors.Is(err, errors_.ErrTaskNotPending):
+			h.Error(c, http.StatusBadRequest, err, "任务已处理，不能认领")
+		case errors.Is(err, errors_.ErrInstanceSuspended):
+			h.Error(c, http.StatusBadRequest, err, "流程已挂起，暂不能处理任务")
+		case errors.Is(err, errors_.ErrInstanceNotRunning):
+			h.Error(c, http.StatusBadRequest, err, "流程已结束，不能处理任务")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "认领任务失败")
+		}
+		return
+	}
+
+	h.OK(c, nil, "认领任务成功")
+}
+
+// UnclaimTask 放弃认领
+func (h *TaskHandler) UnclaimTask(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	userID := jwtuser.GetUserId(c)
+	if userID == 0 {
+		logger.Error("获取用户ID失败")
+		h.Error(c, http.StatusInternalServerError, nil, "放弃认领失败")
+		return
+	}
+
+	var cmd command.UnclaimTaskCommand
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		logger.Error("绑定放弃认领命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&cmd); err != nil {
+			logger.Error("绑定放弃认领命令的参数失败", "error", err)
+			h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+			return
+		}
+	}
+	cmd.UserID = int(userID)
+
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	if err := h.taskService.UnclaimTask(ctx, &cmd); err != nil {
+		logger.Error("放弃认领失败", "error", err)
+		switch {
+		case errors.Is(err, errors_.ErrTaskNotFound):
+			h.Error(c, http.StatusNotFound, err, "任务不存在")
+		case errors.Is(err, errors_.ErrUnauthorized):
+			h.Error(c, http.StatusForbidden, err, "只有认领人可以放弃认领")
+		case errors.Is(err, errors_.ErrTaskNotClaimed), errors.Is(err, errors_.ErrTaskNotPending):
+			h.Error(c, http.StatusBadRequest, err, "任务未被认领")
+		case errors.Is(err, errors_.ErrInstanceSuspended):
+			h.Error(c, http.StatusBadRequest, err, "流程已挂起，暂不能处理任务")
+		case errors.Is(err, errors_.ErrInstanceNotRunning):
+			h.Error(c, http.StatusBadRequest, err, "流程已结束，不能处理任务")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "放弃认领失败")
+		}
+		return
+	}
+
+	h.OK(c, nil, "放弃认领成功")
+}
+
+// userGroups 当前用户所属的组，候选组按组织机构匹配
+func userGroups(c *gin.Context) []int {
+	if orgID := authhandler.GetUserOrgID(c); orgID != 0 {
+		return []int{orgID}
+	}
+	return nil
+}
+
 // GetDoneTasks 查询已办任务
 func (h *TaskHandler) GetDoneTasks(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
@@ -206,7 +344,18 @@ func (h *TaskHandler) CompleteTask(c *gin.Context) {
 	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
 	if err := h.taskService.CompleteTask(ctx, &cmd); err != nil {
 		logger.Error("完成任务失败", "error", err)
-		h.Error(c, http.StatusInternalServerError, err, "完成任务失败")
+		switch {
+		case errors.Is(err, errors_.ErrInvalidRejectTarget):
+			h.Error(c, http.StatusBadRequest, err, "驳回目标无效")
+		case errors.Is(err, errors_.ErrCannotResubmitDirectly):
+			h.Error(c, http.StatusBadRequest, err, "任务不能直接提交回驳回的步骤")
+		case errors.Is(err, errors_.ErrInstanceSuspended):
+			h.Error(c, http.StatusBadRequest, err, "流程已挂起，暂不能处理任务")
+		case errors.Is(err, errors_.ErrInstanceNotRunning):
+			h.Error(c, http.StatusBadRequest, err, "流程已结束，不能处理任务")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "完成任务失败")
+		}
 		return
 	}
 
@@ -242,7 +391,16 @@ func (h *TaskHandler) ApproveTask(c *gin.Context) {
 	ctx = context.WithValue(ctx, global.UserIDKey, int(userID))
 	if err := h.taskService.CompleteTask(ctx, &cmd); err != nil {
 		logger.Error("批准任务失败", "error", err)
-		h.Error(c, http.StatusInternalServerError, err, "批准任务失败")
+		switch {
+		case errors.Is(err, errors_.ErrCannotResubmitDirectly):
+			h.Error(c, http.StatusBadRequest, err, "任务不能直接提交回驳回的步骤")
+		case errors.Is(err, errors_.ErrInstanceSuspended):
+			h.Error(c, http.StatusBadRequest, err, "流程已挂起，暂不能处理任务")
+		case errors.Is(err, errors_.ErrInstanceNotRunning):
+			h.Error(c, http.StatusBadRequest, err, "流程已结束，不能处理任务")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "批准任务失败")
+		}
 		return
 	}
 
@@ -279,7 +437,16 @@ func (h *TaskHandler) RejectTask(c *gin.Context) {
 	ctx = context.WithValue(ctx, global.UserIDKey, int(userID))
 	if err := h.taskService.CompleteTask(ctx, &cmd); err != nil {
 		logger.Error("驳回任务失败", "error", err)
-		h.Error(c, http.StatusInternalServerError, err, "驳回任务失败")
+		switch {
+		case errors.Is(err, errors_.ErrInvalidRejectTarget):
+			h.Error(c, http.StatusBadRequest, err, "驳回目标无效")
+		case errors.Is(err, errors_.ErrInstanceSuspended):
+			h.Error(c, http.StatusBadRequest, err, "流程已挂起，暂不能处理任务")
+		case errors.Is(err, errors_.ErrInstanceNotRunning):
+			h.Error(c, http.StatusBadRequest, err, "流程已结束，不能处理任务")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "驳回任务失败")
+		}
 		return
 	}
 
@@ -314,13 +481,135 @@ func (h *TaskHandler) DelegateTask(c *gin.Context) {
 	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
 	if err := h.taskService.DelegateTask(ctx, &cmd); err != nil {
 		logger.Error("转办任务失败", "error", err)
-		h.Error(c, http.StatusInternalServerError, err, "转办任务失败")
+		switch {
+		case errors.Is(err, errors_.ErrTaskNotFound):
+			h.Error(c, http.StatusNotFound, err, "任务不存在")
+		case errors.Is(err, errors_.ErrDelegateUserNotFound):
+			h.Error(c, http.StatusNotFound, err, "转办目标用户不存在")
+		case errors.Is(err, errors_.ErrUnauthorized):
+			h.Error(c, http.StatusForbidden, err, "只有任务处理人可以转办")
+		case errors.Is(err, errors_.ErrDelegateNotAllowed):
+			h.Error(c, http.StatusForbidden, err, "该步骤不允许转办")
+		case errors.Is(err, errors_.ErrInvalidDelegateTarget):
+			h.Error(c, http.StatusBadRequest, err, "转办目标无效")
+		case errors.Is(err, errors_.ErrDelegationCycle):
+			h.Error(c, http.StatusBadRequest, err, "不能转办给已转办过的用户")
+		case errors.Is(err, errors_.ErrDelegationLimitExceeded):
+			h.Error(c, http.StatusBadRequest, err, "超过最大转办次数")
+		case errors.Is(err, errors_.ErrTaskNotPending):
+			h.Error(c, http.StatusBadRequest, err, "任务已处理，不能转办")
+		case errors.Is(err, errors_.ErrInstanceSuspended):
+			h.Error(c, http.StatusBadRequest, err, "流程已挂起，暂不能处理任务")
+		case errors.Is(err, errors_.ErrInstanceNotRunning):
+			h.Error(c, http.StatusBadRequest, err, "流程已结束，不能处理任务")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "转办任务失败")
+		}
 		return
 	}
 
 	h.OK(c, nil, "转办任务成功")
 }
 
+// AddSigner 加签
+func (h *TaskHandler) AddSigner(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	userID := jwtuser.GetUserId(c)
+	if userID == 0 {
+		logger.Error("获取用户ID失败")
+		h.Error(c, http.StatusInternalServerError, nil, "加签失败")
+		return
+	}
+
+	var cmd command.AddSignerCommand
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		logger.Error("绑定加签命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		logger.Error("绑定加签命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	cmd.UserID = int(userID)
+
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	task, err := h.taskService.AddSigner(ctx, &cmd)
+	if err != nil {
+		logger.Error("加签失败", "error", err)
+		switch {
+		case errors.Is(err, errors_.ErrTaskNotFound):
+			h.Error(c, http.StatusNotFound, err, "任务不存在")
+		case errors.Is(err, errors_.ErrUnauthorized):
+			h.Error(c, http.StatusForbidden, err, "只有任务处理人可以加签")
+		case errors.Is(err, errors_.ErrInvalidSigner), errors.Is(err, errors_.ErrInvalidSignPosition), errors.Is(err, errors_.ErrTaskNotPending):
+			h.Error(c, http.StatusBadRequest, err, "加签参数错误")
+		case errors.Is(err, errors_.ErrInstanceSuspended):
+			h.Error(c, http.StatusBadRequest, err, "流程已挂起，暂不能处理任务")
+		case errors.Is(err, errors_.ErrInstanceNotRunning):
+			h.Error(c, http.StatusBadRequest, err, "流程已结束，不能处理任务")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "加签失败")
+		}
+		return
+	}
+
+	h.OK(c, task, "加签成功")
+}
+
+// WithdrawTask 撤回任务
+func (h *TaskHandler) WithdrawTask(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	userID := jwtuser.GetUserId(c)
+	if userID == 0 {
+		logger.Error("获取用户ID失败")
+		h.Error(c, http.StatusInternalServerError, nil, "撤回任务失败")
+		return
+	}
+
+	var cmd command.WithdrawTaskCommand
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		logger.Error("绑定撤回任务命令的参数失败", "error", err)
+		h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+		return
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&cmd); err != nil {
+			logger.Error("绑定撤回任务命令的参数失败", "error", err)
+			h.Error(c, http.StatusBadRequest, err, "请求参数错误")
+			return
+		}
+	}
+	cmd.UserID = int(userID)
+
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	if err := h.taskService.WithdrawTask(ctx, &cmd); err != nil {
+		logger.Error("撤回任务失败", "error", err)
+		switch {
+		case errors.Is(err, errors_.ErrTaskNotFound):
+			h.Error(c, http.StatusNotFound, err, "任务不存在")
+		case errors.Is(err, errors_.ErrUnauthorized):
+			h.Error(c, http.StatusForbidden, err, "只有任务处理人可以撤回")
+		case errors.Is(err, errors_.ErrCannotWithdraw):
+			h.Error(c, http.StatusBadRequest, err, "后续任务已处理，无法撤回")
+		case errors.Is(err, errors_.ErrInstanceSuspended):
+			h.Error(c, http.StatusBadRequest, err, "流程已挂起，暂不能处理任务")
+		case errors.Is(err, errors_.ErrInstanceNotRunning):
+			h.Error(c, http.StatusBadRequest, err, "流程已结束，不能处理任务")
+		default:
+			h.Error(c, http.StatusInternalServerError, err, "撤回任务失败")
+		}
+		return
+	}
+
+	h.OK(c, nil, "撤回任务成功")
+}
+
 // GetTaskHistory 获取任务历史
 func (h *TaskHandler) GetTaskHistory(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -9,6 +11,7 @@ import (
 	"jxt-evidence-system/process-management/internal/application/service/port"
 	instance_aggregate "jxt-evidence-system/process-management/internal/domain/aggregate/instance"
 	"jxt-evidence-system/process-management/internal/domain/aggregate/instance/repository"
+	errors_ "jxt-evidence-system/process-management/shared/common/errors"
 	"jxt-evidence-system/process-management/shared/common/global"
 	"jxt-evidence-system/process-management/shared/common/status"
 
@@ -190,6 +193,7 @@ func (h *WorkflowHandler) ActivateWorkflow(c *gin.Context) {
 		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
 		return
 	}
+	cmd.SetUpdateBy(user.GetUserId(c))
 	// 设置租户ID（单租户模式使用默认租户 "*"）
 	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
 	if err := h.workflowService.ActivateWorkflow(ctx, cmd); err != nil {
@@ -200,6 +204,173 @@ func (h *WorkflowHandler) ActivateWorkflow(c *gin.Context) {
 	h.OK(c, nil, "激活工作流成功")
 }
 
+// ValidateWorkflow 校验工作流定义（供设计器使用）
+func (h *WorkflowHandler) ValidateWorkflow(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	cmd := command.ValidateWorkflowCommand{}
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+	errs := h.workflowService.ValidateDefinition(ctx, cmd.Definition)
+
+	h.OK(c, gin.H{
+		"valid":  len(errs) == 0,
+		"errors": errs,
+	}, "校验工作流定义完成")
+}
+
+// GetVersions 获取工作流的版本列表
+func (h *WorkflowHandler) GetVersions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	cmd := command.GetWorkflowVersionsCommand{}
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	versions, err := h.workflowService.GetVersions(ctx, cmd.ID)
+	if err != nil {
+		h.Error(c, http.StatusInternalServerError, err, "获取工作流版本失败")
+		return
+	}
+
+	h.OK(c, versions, "获取工作流版本成功")
+}
+
+// GetVersion 获取工作流的指定版本
+func (h *WorkflowHandler) GetVersion(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	cmd := command.GetWorkflowVersionCommand{}
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	version, err := h.workflowService.GetVersion(ctx, cmd.ID, cmd.Version)
+	if err != nil {
+		h.Error(c, http.StatusInternalServerError, err, "获取工作流版本失败")
+		return
+	}
+
+	h.OK(c, version, "获取工作流版本成功")
+}
+
+// CompareVersions 比较工作流的两个版本
+func (h *WorkflowHandler) CompareVersions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	cmd := command.CompareWorkflowVersionsCommand{}
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+	if err := c.ShouldBindQuery(&cmd); err != nil {
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	diff, err := h.workflowService.CompareVersions(ctx, &cmd)
+	if err != nil {
+		h.Error(c, http.StatusInternalServerError, err, "比较工作流版本失败")
+		return
+	}
+
+	h.OK(c, diff, "比较工作流版本成功")
+}
+
+// MigrateInstances 将运行中的实例迁移到指定版本（支持试运行和批量迁移）
+func (h *WorkflowHandler) MigrateInstances(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	cmd := command.MigrateInstancesCommand{}
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+	cmd.SetUpdateBy(user.GetUserId(c))
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	report, err := h.instanceService.MigrateInstances(ctx, &cmd)
+	if err != nil {
+		if errors.Is(err, errors_.ErrInvalidMigrationPlan) {
+			h.Error(c, http.StatusBadRequest, err, "迁移计划无效")
+			return
+		}
+		h.Error(c, http.StatusInternalServerError, err, "迁移实例失败")
+		return
+	}
+
+	h.OK(c, report, "迁移实例完成")
+}
+
+// ImportBpmn 导入 BPMN 2.0 XML，创建草稿工作流
+func (h *WorkflowHandler) ImportBpmn(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	cmd := command.ImportBpmnCommand{}
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+	cmd.SetCreateBy(user.GetUserId(c))
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	result, err := h.workflowService.ImportBpmn(ctx, &cmd)
+	if err != nil {
+		if errors.Is(err, errors_.ErrInvalidWorkflowDefinition) {
+			h.Error(c, http.StatusBadRequest, err, "BPMN 文件无效")
+			return
+		}
+		h.Error(c, http.StatusInternalServerError, err, "导入BPMN失败")
+		return
+	}
+
+	h.OK(c, result, "导入BPMN成功")
+}
+
+// ExportBpmn 导出工作流为 BPMN 2.0 XML 文件
+func (h *WorkflowHandler) ExportBpmn(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	cmd := command.ExportBpmnCommand{}
+	if err := c.ShouldBindUri(&cmd); err != nil {
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+	if err := c.ShouldBindQuery(&cmd); err != nil {
+		h.Error(c, http.StatusBadRequest, err, "请求参数绑定失败")
+		return
+	}
+	// 设置租户ID（单租户模式使用默认租户 "*"）
+	ctx = context.WithValue(ctx, global.TenantIDKey, "*")
+	data, err := h.workflowService.ExportBpmn(ctx, &cmd)
+	if err != nil {
+		h.Error(c, http.StatusInternalServerError, err, "导出BPMN失败")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.bpmn", cmd.ID.String()))
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+}
+
 // FreezeWorkflow 冻结工作流
 func (h *WorkflowHandler) FreezeWorkflow(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
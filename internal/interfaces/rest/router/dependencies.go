@@ -21,6 +21,7 @@ func init() {
 		registerWorkflowRouter,
 		registerInstanceRouter,
 		registerTaskRouter,
+		registerDelegationRouter,
 	)
 	println("🔧 [DEBUG] dependencies.go init() 完成，routerNoCheckRole 数量:", len(routerNoCheckRole), "routerCheckRole 数量:", len(routerCheckRole))
 }
@@ -67,6 +68,8 @@ func registerWorkflowRouter(v1 *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddl
 				r.POST("", handler.CreateWorkflow)
 				r.GET("", handler.GetPage)
 				r.GET("/all", handler.GetAllWorkflow)
+				r.POST("/validate", handler.ValidateWorkflow)
+				r.POST("/import/bpmn", handler.ImportBpmn)
 				r.GET("/:id", handler.GetWorkflow)
 				r.GET("/name/:name", handler.GetWorkflowByName)
 				r.PUT("/:id", handler.UpdateWorkflow)
@@ -74,6 +77,11 @@ func registerWorkflowRouter(v1 *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddl
 				r.POST("/:id/activate", handler.ActivateWorkflow)
 				r.POST("/:id/freeze", handler.FreezeWorkflow)
 				r.GET("/:id/can-freeze", handler.CheckCanFreeze)
+				r.GET("/:id/versions", handler.GetVersions)
+				r.GET("/:id/versions/compare", handler.CompareVersions)
+				r.GET("/:id/versions/:version", handler.GetVersion)
+				r.POST("/:id/instances/migrate", handler.MigrateInstances)
+				r.GET("/:id/bpmn", handler.ExportBpmn)
 			}
 		} else {
 			logger.Fatal("WorkflowHandler is nil after resolution")
@@ -93,9 +101,14 @@ func registerInstanceRouter(v1 *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddl
 			{
 				r.GET("", handler.GetPage)
 				r.POST("", handler.StartInstance)
+				r.GET("/started", handler.GetStartedInstances)
 				r.GET("/:id", handler.GetInstance)
 				r.GET("/:id/cancel", handler.CancelInstance)
 				r.GET("/:id/detail", handler.GetInstanceDetail)
+				r.GET("/:id/tokens", handler.GetInstanceTokens)
+				r.POST("/:id/jump", handler.JumpInstance)
+				r.POST("/:id/suspend", handler.SuspendInstance)
+				r.POST("/:id/resume", handler.ResumeInstance)
 				r.DELETE("/:id", handler.DeleteInstance)
 				r.GET("/workflow/:workflow_id", handler.GetInstancesByWorkflow)
 			}
@@ -119,11 +132,16 @@ func registerTaskRouter(v1 *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddlewar
 				r.GET("", handler.GetPage)                                             // 查询所有任务
 				r.GET("/todo", handler.GetTodoTasks)                                   // 我的待办
 				r.GET("/done", handler.GetDoneTasks)                                   // 我的已办
+				r.GET("/claimable", handler.GetClaimableTasks)                         // 我的待领
 				r.GET("/:id", handler.GetTask)                                         // 任务详情
+				r.POST("/:id/claim", handler.ClaimTask)                                // 认领任务
+				r.POST("/:id/unclaim", handler.UnclaimTask)                            // 放弃认领
 				r.POST("/:id/complete", handler.CompleteTask)                          // 完成任务
 				r.POST("/:id/approve", handler.ApproveTask)                            // 批准任务
 				r.POST("/:id/reject", handler.RejectTask)                              // 驳回任务
 				r.POST("/:id/delegate", handler.DelegateTask)                          // 转办任务
+				r.POST("/:id/add-signer", handler.AddSigner)                           // 加签
+				r.POST("/:id/withdraw", handler.WithdrawTask)                          // 撤回任务
 				r.DELETE("/:id", handler.DeleteTask)                                   // 删除任务
 				r.GET("/:id/history", handler.GetTaskHistory)                          // 任务历史
 				r.GET("/instance/:instanceId/recent", handler.GetRecentTask)           // 实例最近任务
@@ -139,3 +157,25 @@ func registerTaskRouter(v1 *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddlewar
 		logger.Fatalf("Failed to resolve TaskHandler: %v", err)
 	}
 }
+
+func registerDelegationRouter(v1 *gin.RouterGroup, authMiddleware *jwt.GinJWTMiddleware) {
+	// 通过依赖注入创建API处理器
+	err := di.Invoke(func(handler *api.DelegationHandler) {
+		if handler != nil {
+			r := v1.Group("/delegations").Use(authMiddleware.MiddlewareFunc()).Use(middleware.AuthCheckRole())
+			{
+				r.POST("", handler.CreateDelegation)       // 创建委托规则
+				r.GET("", handler.GetDelegations)          // 我的委托规则
+				r.GET("/:id", handler.GetDelegation)       // 委托规则详情
+				r.PUT("/:id", handler.UpdateDelegation)    // 修改委托规则
+				r.DELETE("/:id", handler.DeleteDelegation) // 删除委托规则
+			}
+		} else {
+			logger.Fatal("DelegationHandler is nil after resolution")
+		}
+	})
+
+	if err != nil {
+		logger.Fatalf("Failed to resolve DelegationHandler: %v", err)
+	}
+}
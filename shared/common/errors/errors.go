@@ -42,4 +42,67 @@ var (
 
 	// ErrInvalidTaskID 无效的任务ID格式
 	ErrInvalidTaskID = errors.New("invalid task ID format")
+
+	// ErrTimerNotFound 定时器不存在
+	ErrTimerNotFound = errors.New("timer not found")
+
+	// ErrTimerNotPending 定时器不在待触发状态
+	ErrTimerNotPending = errors.New("timer is not in pending status")
+
+	// ErrTokenNotFound 执行令牌不存在
+	ErrTokenNotFound = errors.New("execution token not found")
+
+	// ErrWorkflowVersionNotFound 工作流版本不存在
+	ErrWorkflowVersionNotFound = errors.New("workflow version not found")
+
+	// ErrInvalidMigrationPlan 实例迁移计划无效
+	ErrInvalidMigrationPlan = errors.New("invalid instance migration plan")
+
+	// ErrInvalidSignPosition 加签位置无效
+	ErrInvalidSignPosition = errors.New("invalid add-signer position")
+
+	// ErrInvalidSigner 加签人无效（为空、为当前处理人或任务本身由加签产生）
+	ErrInvalidSigner = errors.New("invalid signer")
+
+	// ErrCannotWithdraw 任务不能撤回（后续任务已被处理或流程已继续流转）
+	ErrCannotWithdraw = errors.New("task cannot be withdrawn")
+
+	// ErrInvalidRejectTarget 驳回目标不存在、尚未执行或不在步骤驳回策略允许的范围内
+	ErrInvalidRejectTarget = errors.New("invalid reject target")
+
+	// ErrCannotResubmitDirectly 任务不是由驳回产生的，不能直接提交回驳回的步骤
+	ErrCannotResubmitDirectly = errors.New("task cannot be resubmitted directly")
+
+	// ErrJumpTargetNotFound 跳转目标步骤不在实例绑定的工作流定义中
+	ErrJumpTargetNotFound = errors.New("jump target step not found in workflow definition")
+
+	// ErrInstanceSuspended 实例已挂起，恢复前不能处理任务
+	ErrInstanceSuspended = errors.New("workflow instance is suspended")
+
+	// ErrInstanceNotRunning 实例已结束（完成、失败或取消），不能再处理任务
+	ErrInstanceNotRunning = errors.New("workflow instance is not running")
+
+	// ErrAssigneeNotResolved 处理人表达式没有解析出用户
+	ErrAssigneeNotResolved = errors.New("assignee expression resolved to no user")
+
+	// ErrDelegationNotFound 委托规则不存在
+	ErrDelegationNotFound = errors.New("delegation rule not found")
+
+	// ErrInvalidDelegation 委托规则无效（未指定受托人、委托给自己或生效时间段无效）
+	ErrInvalidDelegation = errors.New("invalid delegation rule")
+
+	// ErrInvalidDelegateTarget 转办目标无效（未指定或转办给自己）
+	ErrInvalidDelegateTarget = errors.New("invalid delegate target")
+
+	// ErrDelegateUserNotFound 转办目标用户不存在
+	ErrDelegateUserNotFound = errors.New("delegate target user not found")
+
+	// ErrDelegationCycle 转办目标已在转办链中，转办会形成环（转回原处理人除外）
+	ErrDelegationCycle = errors.New("delegation would create a cycle")
+
+	// ErrDelegateNotAllowed 步骤不允许转办
+	ErrDelegateNotAllowed = errors.New("step does not allow delegation")
+
+	// ErrDelegationLimitExceeded 转办次数超过步骤允许的最大次数
+	ErrDelegationLimitExceeded = errors.New("delegation hops limit exceeded")
 )
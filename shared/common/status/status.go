@@ -8,6 +8,7 @@ const (
 	InstanceStatusCompleted InstanceStatus = "completed"
 	InstanceStatusFailed    InstanceStatus = "failed"
 	InstanceStatusCancelled InstanceStatus = "cancelled"
+	InstanceStatusSuspended InstanceStatus = "suspended" // 已挂起，任务不能处理，定时器暂停
 )
 
 // TaskStatus 任务状态
@@ -17,6 +18,9 @@ const (
 	TaskStatusPending   TaskStatus = "pending"   // 待处理
 	TaskStatusCompleted TaskStatus = "completed" // 已完成
 	TaskStatusRejected  TaskStatus = "rejected"  // 已驳回
+	TaskStatusFailed    TaskStatus = "failed"    // 执行失败（自动化步骤重试耗尽）
+	TaskStatusWaiting   TaskStatus = "waiting"   // 等待中（顺序会签尚未轮到）
+	TaskStatusCancelled TaskStatus = "cancelled" // 已取消
 )
 
 // TaskPriority 任务优先级
@@ -37,6 +41,36 @@ const (
 	TaskResultCompleted TaskResult = "completed" // 完成
 )
 
+// TimerType 定时器类型
+type TimerType string
+
+const (
+	TimerTypeTimeout TimerType = "timeout" // 步骤超时
+	TimerTypeRetry   TimerType = "retry"   // 自动化步骤重试
+)
+
+// TimerStatus 定时器状态
+type TimerStatus string
+
+const (
+	TimerStatusPending   TimerStatus = "pending"   // 等待触发
+	TimerStatusFired     TimerStatus = "fired"     // 已触发
+	TimerStatusCancelled TimerStatus = "cancelled" // 已取消
+	TimerStatusFailed    TimerStatus = "failed"    // 触发后处理失败
+	TimerStatusPaused    TimerStatus = "paused"    // 实例挂起期间暂停
+)
+
+// TokenStatus 执行令牌状态
+type TokenStatus string
+
+const (
+	TokenStatusActive    TokenStatus = "active"    // 正在执行步骤
+	TokenStatusForked    TokenStatus = "forked"    // 已分支，等待子令牌汇聚
+	TokenStatusWaiting   TokenStatus = "waiting"   // 已到达汇聚网关，等待其他分支
+	TokenStatusCompleted TokenStatus = "completed" // 已结束（分支执行完毕或已汇聚）
+	TokenStatusCancelled TokenStatus = "cancelled" // 已取消
+)
+
 // WorkflowStatus 工作流状态
 type WorkflowStatus string
 
@@ -0,0 +1,140 @@
+package api_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Delegation API Tests", func() {
+	var delegationID string
+	startAt := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	endAt := time.Now().Add(72 * time.Hour).Format(time.RFC3339)
+
+	Describe("POST /api/v1/delegations - 创建委托规则", func() {
+		It("应该成功创建委托规则", func() {
+			payload := map[string]interface{}{
+				"delegateId": GlobalTestData.AdminUserId + 1000,
+				"startAt":    startAt,
+				"endAt":      endAt,
+				"reason":     "休假",
+			}
+
+			body, _ := json.Marshal(payload)
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/delegations", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			result := expectBusinessCode(resp, 200)
+			data, ok := result["data"].(map[string]interface{})
+			Expect(ok).To(BeTrue())
+			delegationID, _ = data["id"].(string)
+			Expect(delegationID).NotTo(BeEmpty())
+			fmt.Printf("✅ 委托规则创建成功 - ID: %s\n", delegationID)
+		})
+
+		It("应该拒绝委托给自己", func() {
+			payload := map[string]interface{}{
+				"delegateId": GlobalTestData.AdminUserId,
+				"startAt":    startAt,
+				"endAt":      endAt,
+			}
+
+			body, _ := json.Marshal(payload)
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/delegations", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 400)
+		})
+
+		It("应该拒绝结束时间早于开始时间", func() {
+			payload := map[string]interface{}{
+				"delegateId": GlobalTestData.AdminUserId + 1000,
+				"startAt":    endAt,
+				"endAt":      startAt,
+			}
+
+			body, _ := json.Marshal(payload)
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/delegations", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 400)
+		})
+	})
+
+	Describe("GET /api/v1/delegations - 我的委托规则", func() {
+		It("应该成功返回委托规则列表", func() {
+			req, _ := http.NewRequest("GET", baseURL+"/api/v1/delegations", nil)
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			result := expectBusinessCode(resp, 200)
+			Expect(result["data"]).NotTo(BeNil())
+		})
+	})
+
+	Describe("PUT /api/v1/delegations/:id - 修改委托规则", func() {
+		It("应该返回404当委托规则不存在", func() {
+			payload := map[string]interface{}{
+				"delegateId": GlobalTestData.AdminUserId + 1000,
+				"startAt":    startAt,
+				"endAt":      endAt,
+			}
+
+			body, _ := json.Marshal(payload)
+			req, _ := http.NewRequest("PUT", baseURL+"/api/v1/delegations/01920000-0000-7000-8000-000000000000", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 404)
+		})
+	})
+
+	Describe("DELETE /api/v1/delegations/:id - 删除委托规则", func() {
+		It("应该成功删除自己的委托规则", func() {
+			Expect(delegationID).NotTo(BeEmpty())
+			req, _ := http.NewRequest("DELETE", baseURL+"/api/v1/delegations/"+delegationID, nil)
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 200)
+		})
+	})
+})
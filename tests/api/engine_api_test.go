@@ -0,0 +1,565 @@
+package api_tests
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// 定时器每 5 秒扫描一次，依赖定时器的场景最多等待 20 秒
+const timerWait = 20 * time.Second
+
+var _ = Describe("Workflow Engine API Tests", func() {
+
+	Describe("步骤超时", func() {
+		It("应该在超时后按策略自动通过并继续流转", func() {
+			workflowID := createActiveWorkflow("超时自动通过", `{"steps":[`+
+				`{"id":"review","name":"审核","type":"userTask","timeout":1,"onTimeout":{"action":"approve","comment":"超时自动通过"},"params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+
+			review := pendingTask(instanceID, "review")
+			reviewID := review["taskId"].(string)
+			Expect(pendingTimers(reviewID)).To(BeEquivalentTo(1))
+
+			Eventually(func() string { return instanceStatus(instanceID) }, timerWait, time.Second).Should(Equal("completed"))
+
+			review = tasksOf(instanceID, "review", "")[0]
+			Expect(review["status"]).To(Equal("completed"))
+			Expect(review["result"]).To(Equal("approved"))
+			Expect(review["comment"]).To(Equal("超时自动通过"))
+			Expect(pendingTimers(reviewID)).To(BeZero())
+		})
+
+		It("应该在任务按时处理后取消超时定时器", func() {
+			workflowID := createActiveWorkflow("按时处理", `{"steps":[`+
+				`{"id":"review","name":"审核","type":"userTask","timeout":3600,"params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+
+			review := pendingTask(instanceID, "review")
+			Expect(pendingTimers(review["taskId"].(string))).To(BeEquivalentTo(1))
+
+			approveTask(review)
+			Expect(pendingTimers(review["taskId"].(string))).To(BeZero())
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+
+		It("应该在超时跳转前取消其他分支的任务和定时器", func() {
+			workflowID := createActiveWorkflow("超时跳转", `{"steps":[`+
+				`{"id":"collect","name":"会审","type":"parallel","parallelTasks":[`+
+				`{"id":"legal","name":"法制审核","type":"userTask","timeout":1,"onTimeout":{"action":"jump","stepId":"archive"},"params":{"assignee":"1"}},`+
+				`{"id":"finance","name":"财务审核","type":"userTask","timeout":3600,"params":{"assignee":"1"}}],"nextSteps":["end"]},`+
+				`{"id":"archive","name":"归档","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			pendingTask(instanceID, "legal")
+			finance := pendingTask(instanceID, "finance")
+			financeID := finance["taskId"].(string)
+			Expect(pendingTimers(financeID)).To(BeEquivalentTo(1))
+
+			Eventually(func() []map[string]interface{} { return tasksOf(instanceID, "archive", "pending") }, timerWait, time.Second).Should(HaveLen(1))
+
+			Expect(tasksOf(instanceID, "legal", "completed")).To(HaveLen(1))
+			Expect(tasksOf(instanceID, "finance", "cancelled")).To(HaveLen(1))
+			Expect(pendingTimers(financeID)).To(BeZero())
+
+			// 分支令牌已结束，归档完成后流程直接结束，不会等待会审汇合
+			approveTask(pendingTask(instanceID, "archive"))
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+	})
+
+	Describe("自动化步骤重试", func() {
+		It("应该在重试耗尽后将任务和实例置为失败", func() {
+			// setVariables 缺少 params.variables 时每次执行都会失败
+			workflowID := createActiveWorkflow("重试失败", `{"steps":[`+
+				`{"id":"sync","name":"同步","type":"process","retries":1,"retryBackoff":{"initialInterval":1},"params":{"handler":"setVariables"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+
+			sync := pendingTask(instanceID, "sync")
+			syncID := sync["taskId"].(string)
+			Expect(instanceStatus(instanceID)).To(Equal("running"))
+			Expect(historyCount(syncID, "attempt")).To(BeEquivalentTo(1))
+			Expect(pendingTimers(syncID)).To(BeEquivalentTo(1))
+
+			Eventually(func() string { return instanceStatus(instanceID) }, timerWait, time.Second).Should(Equal("failed"))
+
+			Expect(tasksOf(instanceID, "sync", "failed")).To(HaveLen(1))
+			Expect(getInstance(instanceID)["errorMessage"]).To(ContainSubstring("共执行2次"))
+			Expect(historyCount(syncID, "attempt")).To(BeEquivalentTo(2))
+			Expect(pendingTimers(syncID)).To(BeZero())
+			Expect(tasksOf(instanceID, "end", "")).To(BeEmpty())
+		})
+
+		It("应该在不重试的步骤失败时仍然成功启动实例并记录失败", func() {
+			workflowID := createActiveWorkflow("首步失败", `{"steps":[`+
+				`{"id":"sync","name":"同步","type":"process","params":{"handler":"setVariables"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+
+			Expect(instanceStatus(instanceID)).To(Equal("failed"))
+			Expect(getInstance(instanceID)["errorMessage"]).To(ContainSubstring("共执行1次"))
+			failed := tasksOf(instanceID, "sync", "failed")
+			Expect(failed).To(HaveLen(1))
+			Expect(historyCount(failed[0]["taskId"].(string), "attempt")).To(BeEquivalentTo(1))
+		})
+	})
+
+	Describe("网关", func() {
+		definition := `{"steps":[` +
+			`{"id":"apply","name":"申请","type":"userTask","params":{"assignee":"1"},"nextSteps":["split"]},` +
+			`{"id":"split","type":"parallelGateway","nextSteps":["a","b"]},` +
+			`{"id":"a","name":"分支A","type":"userTask","params":{"assignee":"1"},"nextSteps":["join"]},` +
+			`{"id":"b","name":"分支B","type":"userTask","params":{"assignee":"1"},"nextSteps":["join"]},` +
+			`{"id":"join","type":"parallelGateway","nextSteps":["route"]},` +
+			`{"id":"route","type":"exclusiveGateway","nextSteps":["big","small"]},` +
+			`{"id":"big","name":"大额审批","type":"userTask","condition":"${amount} > 100","params":{"assignee":"1"},"nextSteps":["end"]},` +
+			`{"id":"small","name":"小额审批","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},` +
+			`{"id":"end","type":"complete"}]}`
+
+		It("应该在并行分支全部到达后汇聚，再按条件选择排他分支", func() {
+			workflowID := createActiveWorkflow("网关汇聚", definition)
+			instanceID := startInstance(workflowID, map[string]interface{}{"amount": 500})
+
+			approveTask(pendingTask(instanceID, "apply"))
+			a := pendingTask(instanceID, "a")
+			pendingTask(instanceID, "b")
+			tokens := getInstanceTokens(instanceID)
+			Expect(filterByStep(tokens, "forkStepId", "split", "active")).To(HaveLen(2))
+			Expect(filterByStep(tokens, "status", "forked", "")).To(HaveLen(1))
+
+			// 第一个分支到达汇聚网关后等待
+			approveTask(a)
+			tokens = getInstanceTokens(instanceID)
+			Expect(filterByStep(tokens, "stepId", "join", "waiting")).To(HaveLen(1))
+			Expect(filterByStep(tokens, "status", "forked", "")).To(HaveLen(1))
+			Expect(tasksOf(instanceID, "big", "")).To(BeEmpty())
+
+			// 最后一个分支到达后子令牌结束，父令牌越过排他网关
+			approveTask(pendingTask(instanceID, "b"))
+			tokens = getInstanceTokens(instanceID)
+			Expect(filterByStep(tokens, "forkStepId", "split", "completed")).To(HaveLen(2))
+			Expect(filterByStep(tokens, "stepId", "big", "active")).To(HaveLen(1))
+			Expect(tasksOf(instanceID, "big", "pending")).To(HaveLen(1))
+			Expect(tasksOf(instanceID, "small", "")).To(BeEmpty())
+
+			approveTask(pendingTask(instanceID, "big"))
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+
+		It("应该在条件都不满足时走排他网关的无条件分支", func() {
+			workflowID := createActiveWorkflow("网关默认分支", definition)
+			instanceID := startInstance(workflowID, map[string]interface{}{"amount": 50})
+
+			approveTask(pendingTask(instanceID, "apply"))
+			approveTask(pendingTask(instanceID, "a"))
+			approveTask(pendingTask(instanceID, "b"))
+
+			Expect(tasksOf(instanceID, "small", "pending")).To(HaveLen(1))
+			Expect(tasksOf(instanceID, "big", "")).To(BeEmpty())
+		})
+	})
+
+	Describe("执行令牌", func() {
+		It("应该随任务流转移动根令牌，实例结束时令牌一并结束", func() {
+			workflowID := createActiveWorkflow("令牌流转", `{"steps":[`+
+				`{"id":"apply","name":"申请","type":"userTask","params":{"assignee":"1"},"nextSteps":["review"]},`+
+				`{"id":"review","name":"审核","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+
+			tokens := getInstanceTokens(instanceID)
+			Expect(tokens).To(HaveLen(1))
+			Expect(tokens[0]["stepId"]).To(Equal("apply"))
+			Expect(tokens[0]["status"]).To(Equal("active"))
+			Expect(pendingTask(instanceID, "apply")["tokenId"]).To(Equal(tokens[0]["tokenId"]))
+
+			approveTask(pendingTask(instanceID, "apply"))
+			tokens = getInstanceTokens(instanceID)
+			Expect(tokens).To(HaveLen(1))
+			Expect(tokens[0]["stepId"]).To(Equal("review"))
+			Expect(pendingTask(instanceID, "review")["tokenId"]).To(Equal(tokens[0]["tokenId"]))
+
+			approveTask(pendingTask(instanceID, "review"))
+			tokens = getInstanceTokens(instanceID)
+			Expect(tokens).To(HaveLen(1))
+			Expect(tokens[0]["stepId"]).To(Equal("end"))
+			Expect(tokens[0]["status"]).To(Equal("completed"))
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+
+		It("应该为并行步骤的每个任务创建子令牌，全部完成后父令牌继续", func() {
+			workflowID := createActiveWorkflow("并行令牌", `{"steps":[`+
+				`{"id":"collect","name":"会审","type":"parallel","parallelTasks":[`+
+				`{"id":"legal","name":"法制审核","type":"userTask","params":{"assignee":"1"}},`+
+				`{"id":"finance","name":"财务审核","type":"userTask","params":{"assignee":"1"}}],"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+
+			tokens := getInstanceTokens(instanceID)
+			Expect(filterByStep(tokens, "forkStepId", "collect", "active")).To(HaveLen(2))
+			Expect(filterByStep(tokens, "stepId", "collect", "forked")).To(HaveLen(1))
+
+			approveTask(pendingTask(instanceID, "legal"))
+			tokens = getInstanceTokens(instanceID)
+			Expect(filterByStep(tokens, "stepId", "legal", "completed")).To(HaveLen(1))
+			Expect(instanceStatus(instanceID)).To(Equal("running"))
+
+			approveTask(pendingTask(instanceID, "finance"))
+			tokens = getInstanceTokens(instanceID)
+			Expect(filterByStep(tokens, "forkStepId", "collect", "completed")).To(HaveLen(2))
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+	})
+
+	Describe("会签", func() {
+		It("应该在并行会签达到通过人数后取消剩余任务并继续流转", func() {
+			workflowID := createActiveWorkflow("并行会签", `{"steps":[`+
+				`{"id":"review","name":"会签","type":"userTask","timeout":3600,"params":{"multiInstance":{"assignees":"${input.reviewers}","completion":"count:2"}},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, map[string]interface{}{"reviewers": []int{1, 2, 3}})
+
+			reviews := tasksOf(instanceID, "review", "pending")
+			Expect(reviews).To(HaveLen(3))
+
+			approveTask(reviews[0])
+			Expect(instanceStatus(instanceID)).To(Equal("running"))
+			Expect(tasksOf(instanceID, "review", "pending")).To(HaveLen(2))
+
+			approveTask(reviews[1])
+			Expect(tasksOf(instanceID, "review", "completed")).To(HaveLen(2))
+			cancelled := tasksOf(instanceID, "review", "cancelled")
+			Expect(cancelled).To(HaveLen(1))
+			Expect(cancelled[0]["taskId"]).To(Equal(reviews[2]["taskId"]))
+			Expect(pendingTimers(reviews[2]["taskId"].(string))).To(BeZero())
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+
+		It("应该在顺序会签中依次激活下一个处理人", func() {
+			workflowID := createActiveWorkflow("顺序会签", `{"steps":[`+
+				`{"id":"review","name":"会签","type":"userTask","params":{"multiInstance":{"assignees":"1,2","mode":"sequential"}},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+
+			first := pendingTask(instanceID, "review")
+			Expect(first["assignee"]).To(BeEquivalentTo(1))
+			Expect(tasksOf(instanceID, "review", "waiting")).To(HaveLen(1))
+
+			approveTask(first)
+			second := pendingTask(instanceID, "review")
+			Expect(second["assignee"]).To(BeEquivalentTo(2))
+			Expect(tasksOf(instanceID, "review", "waiting")).To(BeEmpty())
+			Expect(instanceStatus(instanceID)).To(Equal("running"))
+
+			approveTask(second)
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+
+		It("应该在无法达到通过人数时驳回并取消剩余任务", func() {
+			workflowID := createActiveWorkflow("会签驳回", `{"steps":[`+
+				`{"id":"apply","name":"申请","type":"userTask","params":{"assignee":"1"},"nextSteps":["review"]},`+
+				`{"id":"review","name":"会签","type":"userTask","params":{"multiInstance":{"assignees":"1,2"}},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			approveTask(pendingTask(instanceID, "apply"))
+
+			reviews := tasksOf(instanceID, "review", "pending")
+			Expect(reviews).To(HaveLen(2))
+			var rejector map[string]interface{}
+			for _, r := range reviews {
+				if r["assignee"] == float64(2) {
+					rejector = r
+				}
+			}
+			Expect(rejector).NotTo(BeNil())
+			result := taskAction(rejector, "reject", map[string]interface{}{"comment": "不同意"}, assigneeToken(rejector))
+			Expect(result["code"]).To(BeEquivalentTo(200), "驳回任务失败: %v", result["msg"])
+
+			Expect(tasksOf(instanceID, "review", "rejected")).To(HaveLen(1))
+			Expect(tasksOf(instanceID, "review", "cancelled")).To(HaveLen(1))
+			Expect(tasksOf(instanceID, "apply", "pending")).To(HaveLen(1))
+			Expect(instanceStatus(instanceID)).To(Equal("running"))
+		})
+	})
+
+	Describe("加签", func() {
+		definition := `{"steps":[` +
+			`{"id":"review","name":"审核","type":"userTask","timeout":3600,"params":{"assignee":"1"},"nextSteps":["end"]},` +
+			`{"id":"end","type":"complete"}]}`
+
+		It("应该在前加签时挂起原任务，加签人处理后交回原处理人", func() {
+			instanceID := startInstance(createActiveWorkflow("前加签", definition), nil)
+			source := pendingTask(instanceID, "review")
+			sourceID := source["taskId"].(string)
+
+			result := taskAction(source, "add-signer", map[string]interface{}{"signerId": 2, "position": "before", "comment": "请先审核"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "加签失败: %v", result["msg"])
+
+			// 原任务等待加签人处理，超时计时交给加签任务
+			Expect(tasksOf(instanceID, "review", "waiting")).To(HaveLen(1))
+			Expect(pendingTimers(sourceID)).To(BeZero())
+			added := pendingTask(instanceID, "review")
+			Expect(added["sourceTaskId"]).To(Equal(sourceID))
+			Expect(added["signPosition"]).To(Equal("before"))
+			Expect(added["assignee"]).To(BeEquivalentTo(2))
+			Expect(pendingTimers(added["taskId"].(string))).To(BeEquivalentTo(1))
+			Expect(historyCount(sourceID, "add_sign")).To(BeEquivalentTo(1))
+
+			approveTask(added)
+			source = pendingTask(instanceID, "review")
+			Expect(source["taskId"]).To(Equal(sourceID))
+			Expect(pendingTimers(sourceID)).To(BeEquivalentTo(1))
+			Expect(instanceStatus(instanceID)).To(Equal("running"))
+
+			approveTask(source)
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+
+		It("应该在后加签时等原处理人通过后再激活加签任务", func() {
+			instanceID := startInstance(createActiveWorkflow("后加签", definition), nil)
+			source := pendingTask(instanceID, "review")
+
+			result := taskAction(source, "add-signer", map[string]interface{}{"signerId": 2, "position": "after"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "加签失败: %v", result["msg"])
+			added := tasksOf(instanceID, "review", "waiting")
+			Expect(added).To(HaveLen(1))
+			Expect(added[0]["signPosition"]).To(Equal("after"))
+			Expect(pendingTimers(added[0]["taskId"].(string))).To(BeZero())
+
+			approveTask(source)
+			after := pendingTask(instanceID, "review")
+			Expect(after["taskId"]).To(Equal(added[0]["taskId"]))
+			Expect(pendingTimers(after["taskId"].(string))).To(BeEquivalentTo(1))
+			Expect(instanceStatus(instanceID)).To(Equal("running"))
+
+			approveTask(after)
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+	})
+
+	Describe("撤回", func() {
+		It("应该在下一步未处理时撤回，下一步已处理后拒绝撤回", func() {
+			workflowID := createActiveWorkflow("撤回", `{"steps":[`+
+				`{"id":"apply","name":"申请","type":"userTask","params":{"assignee":"1"},"nextSteps":["review"]},`+
+				`{"id":"review","name":"审核","type":"userTask","timeout":3600,"params":{"assignee":"2"},"nextSteps":["archive"]},`+
+				`{"id":"archive","name":"归档","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			apply := pendingTask(instanceID, "apply")
+			approveTask(apply)
+			review := pendingTask(instanceID, "review")
+
+			result := taskAction(apply, "withdraw", map[string]interface{}{"comment": "材料有误"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "撤回失败: %v", result["msg"])
+
+			// 下一步任务取消，原任务重新待处理，令牌回到原步骤
+			Expect(tasksOf(instanceID, "review", "cancelled")).To(HaveLen(1))
+			Expect(pendingTimers(review["taskId"].(string))).To(BeZero())
+			Expect(pendingTask(instanceID, "apply")["taskId"]).To(Equal(apply["taskId"]))
+			Expect(filterByStep(getInstanceTokens(instanceID), "stepId", "apply", "active")).To(HaveLen(1))
+			Expect(historyCount(apply["taskId"].(string), "withdraw")).To(BeEquivalentTo(1))
+
+			// 重新提交后下一步处理完成，不能再撤回
+			approveTask(pendingTask(instanceID, "apply"))
+			approveTask(pendingTask(instanceID, "review"))
+			result = taskAction(apply, "withdraw", nil, token)
+			Expect(result["code"]).To(BeEquivalentTo(400))
+			Expect(tasksOf(instanceID, "archive", "pending")).To(HaveLen(1))
+			Expect(tasksOf(instanceID, "apply", "completed")).To(HaveLen(1))
+		})
+	})
+
+	Describe("驳回目标", func() {
+		definition := `{"steps":[` +
+			`{"id":"apply","name":"申请","type":"userTask","params":{"assignee":"2"},"nextSteps":["check"]},` +
+			`{"id":"check","name":"初审","type":"userTask","params":{"assignee":"1"},"nextSteps":["review"]},` +
+			`{"id":"review","name":"复审","type":"userTask","reject":{"targets":["apply"],"toInitiator":true},"params":{"assignee":"1"},"nextSteps":["end"]},` +
+			`{"id":"end","type":"complete"}]}`
+
+		// toReview 启动实例并处理到复审步骤
+		toReview := func(name string) string {
+			instanceID := startInstance(createActiveWorkflow(name, definition), nil)
+			approveTask(pendingTask(instanceID, "apply"))
+			approveTask(pendingTask(instanceID, "check"))
+			return instanceID
+		}
+
+		It("应该驳回到指定的目标步骤，并拒绝策略之外的目标", func() {
+			instanceID := toReview("驳回到指定步骤")
+			review := pendingTask(instanceID, "review")
+
+			result := taskAction(review, "reject", map[string]interface{}{"comment": "退回初审", "targetStepId": "check"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(400))
+			Expect(pendingTask(instanceID, "review")["taskId"]).To(Equal(review["taskId"]))
+
+			result = taskAction(review, "reject", map[string]interface{}{"comment": "退回申请", "targetStepId": "apply"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "驳回失败: %v", result["msg"])
+
+			Expect(tasksOf(instanceID, "review", "rejected")).To(HaveLen(1))
+			apply := pendingTask(instanceID, "apply")
+			Expect(apply["assignee"]).To(BeEquivalentTo(2))
+			Expect(tasksOf(instanceID, "check", "")).To(HaveLen(1))
+			Expect(filterByStep(getInstanceTokens(instanceID), "stepId", "apply", "active")).To(HaveLen(1))
+		})
+
+		It("应该驳回到发起人", func() {
+			instanceID := toReview("驳回到发起人")
+
+			result := taskAction(pendingTask(instanceID, "review"), "reject", map[string]interface{}{"comment": "退回发起人", "toInitiator": true}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "驳回失败: %v", result["msg"])
+
+			apply := pendingTask(instanceID, "apply")
+			Expect(apply["assignee"]).To(BeEquivalentTo(getInstance(instanceID)["initiatorId"]))
+			Expect(tasksOf(instanceID, "check", "")).To(HaveLen(1))
+		})
+
+		It("应该在未指定目标时驳回到上一步", func() {
+			instanceID := toReview("驳回到上一步")
+
+			result := taskAction(pendingTask(instanceID, "review"), "reject", map[string]interface{}{"comment": "退回"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "驳回失败: %v", result["msg"])
+
+			Expect(tasksOf(instanceID, "check", "pending")).To(HaveLen(1))
+			Expect(tasksOf(instanceID, "apply", "pending")).To(BeEmpty())
+		})
+	})
+
+	Describe("子流程", func() {
+		It("应该启动子实例，子实例结束后把输出映射回父实例并继续", func() {
+			childName := fmt.Sprintf("证物封存_%d", time.Now().UnixNano())
+			createNamedWorkflow(childName, `{"steps":[`+
+				`{"id":"seal","name":"封存","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			workflowID := createActiveWorkflow("证物流转", `{"steps":[`+
+				`{"id":"apply","name":"申请","type":"userTask","params":{"assignee":"1"},"nextSteps":["custody"]},`+
+				`{"id":"custody","name":"封存子流程","type":"subProcess","subProcess":{"workflow":"`+childName+`",`+
+				`"input":{"caseId":"${input.caseId}"},"output":{"sealNo":"${seal.sealNo}","caseId":"${input.caseId}"}},"nextSteps":["archive"]},`+
+				`{"id":"archive","name":"归档","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, map[string]interface{}{"caseId": "C-001"})
+			approveTask(pendingTask(instanceID, "apply"))
+
+			// 父实例在子流程步骤等待，子实例收到映射的输入
+			custody := tasksOf(instanceID, "custody", "waiting")
+			Expect(custody).To(HaveLen(1))
+			children := childInstances(instanceID)
+			Expect(children).To(HaveLen(1))
+			child := getInstance(children[0])
+			Expect(child["status"]).To(Equal("running"))
+			Expect(child["parentTaskId"]).To(Equal(custody[0]["taskId"]))
+			Expect(child["input"]).To(Equal(map[string]interface{}{"caseId": "C-001"}))
+
+			seal := pendingTask(children[0], "seal")
+			result := taskAction(seal, "approve", map[string]interface{}{"comment": "已封存", "output": map[string]interface{}{"sealNo": "F-001"}}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "批准任务失败: %v", result["msg"])
+
+			Expect(instanceStatus(children[0])).To(Equal("completed"))
+			custody = tasksOf(instanceID, "custody", "completed")
+			Expect(custody).To(HaveLen(1))
+			Expect(custody[0]["output"]).To(Equal(map[string]interface{}{"sealNo": "F-001", "caseId": "C-001"}))
+			Expect(historyCount(custody[0]["taskId"].(string), "sub_process_complete")).To(BeEquivalentTo(1))
+
+			approveTask(pendingTask(instanceID, "archive"))
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+
+		// createSealWorkflow 创建只有一个封存任务的子流程，返回工作流名称
+		createSealWorkflow := func() string {
+			name := fmt.Sprintf("证物封存_%d", time.Now().UnixNano())
+			createNamedWorkflow(name, `{"steps":[`+
+				`{"id":"seal","name":"封存","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			return name
+		}
+
+		// expectChildCancelled 断言子实例及其待办任务已取消
+		expectChildCancelled := func(instanceID string) {
+			children := childInstances(instanceID)
+			Expect(children).To(HaveLen(1))
+			Expect(instanceStatus(children[0])).To(Equal("cancelled"))
+			Expect(tasksOf(children[0], "seal", "cancelled")).To(HaveLen(1))
+		}
+
+		It("应该在跳转离开子流程步骤时取消子实例", func() {
+			workflowID := createActiveWorkflow("子流程跳转", `{"steps":[`+
+				`{"id":"apply","name":"申请","type":"userTask","params":{"assignee":"1"},"nextSteps":["custody"]},`+
+				`{"id":"custody","name":"封存子流程","type":"subProcess","subProcess":{"workflow":"`+createSealWorkflow()+`"},"nextSteps":["archive"]},`+
+				`{"id":"archive","name":"归档","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			approveTask(pendingTask(instanceID, "apply"))
+			Expect(tasksOf(instanceID, "custody", "waiting")).To(HaveLen(1))
+
+			result := doRequest("POST", "/api/v1/instances/"+instanceID+"/jump", map[string]interface{}{"targetStepId": "archive"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "实例跳转失败: %v", result["msg"])
+
+			Expect(tasksOf(instanceID, "custody", "cancelled")).To(HaveLen(1))
+			expectChildCancelled(instanceID)
+			approveTask(pendingTask(instanceID, "archive"))
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+
+		It("应该在驳回到分支之前时取消另一分支的子实例", func() {
+			workflowID := createActiveWorkflow("子流程驳回", `{"steps":[`+
+				`{"id":"apply","name":"申请","type":"userTask","params":{"assignee":"1"},"nextSteps":["split"]},`+
+				`{"id":"split","type":"parallelGateway","nextSteps":["custody","review"]},`+
+				`{"id":"custody","name":"封存子流程","type":"subProcess","subProcess":{"workflow":"`+createSealWorkflow()+`"},"nextSteps":["join"]},`+
+				`{"id":"review","name":"审核","type":"userTask","reject":{"targets":["apply"]},"params":{"assignee":"1"},"nextSteps":["join"]},`+
+				`{"id":"join","type":"parallelGateway","nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			approveTask(pendingTask(instanceID, "apply"))
+			Expect(tasksOf(instanceID, "custody", "waiting")).To(HaveLen(1))
+
+			result := taskAction(pendingTask(instanceID, "review"), "reject", map[string]interface{}{"comment": "退回申请", "targetStepId": "apply"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "驳回失败: %v", result["msg"])
+
+			Expect(tasksOf(instanceID, "apply", "pending")).To(HaveLen(1))
+			Expect(tasksOf(instanceID, "custody", "cancelled")).To(HaveLen(1))
+			expectChildCancelled(instanceID)
+		})
+
+		It("应该在父实例失败时取消子实例", func() {
+			// sync 未配置 params.variables，执行即失败，且不重试
+			workflowID := createActiveWorkflow("子流程父实例失败", `{"steps":[`+
+				`{"id":"apply","name":"申请","type":"userTask","params":{"assignee":"1"},"nextSteps":["split"]},`+
+				`{"id":"split","type":"parallelGateway","nextSteps":["custody","sync"]},`+
+				`{"id":"custody","name":"封存子流程","type":"subProcess","subProcess":{"workflow":"`+createSealWorkflow()+`"},"nextSteps":["join"]},`+
+				`{"id":"sync","name":"同步","type":"process","params":{"handler":"setVariables"},"nextSteps":["join"]},`+
+				`{"id":"join","type":"parallelGateway","nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			approveTask(pendingTask(instanceID, "apply"))
+
+			Expect(instanceStatus(instanceID)).To(Equal("failed"))
+			Expect(tasksOf(instanceID, "sync", "failed")).To(HaveLen(1))
+			expectChildCancelled(instanceID)
+		})
+	})
+
+	Describe("处理人解析", func() {
+		It("应该按实例变量和发起人确定任务处理人", func() {
+			workflowID := createActiveWorkflow("处理人变量", `{"steps":[`+
+				`{"id":"review","name":"审核","type":"userTask","params":{"assignee":"${reviewer}"},"nextSteps":["confirm"]},`+
+				`{"id":"confirm","name":"确认","type":"userTask","params":{"assignee":"${initiator}"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, map[string]interface{}{"reviewer": "2"})
+
+			review := pendingTask(instanceID, "review")
+			Expect(review["assignee"]).To(BeEquivalentTo(2))
+			// 管理员不是处理人，不能代为处理
+			result := taskAction(review, "approve", map[string]interface{}{"comment": "同意"}, token)
+			Expect(result["code"]).NotTo(BeEquivalentTo(200))
+
+			approveTask(review)
+			confirm := pendingTask(instanceID, "confirm")
+			Expect(confirm["assignee"]).To(BeEquivalentTo(getInstance(instanceID)["initiatorId"]))
+			approveTask(confirm)
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+	})
+})
@@ -1,8 +1,12 @@
 package api_tests
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	. "github.com/onsi/gomega"
 )
@@ -25,3 +29,211 @@ func expectBusinessCodeNotEqual(resp *http.Response, unexpected int) map[string]
 	Expect(body["code"]).NotTo(BeEquivalentTo(unexpected))
 	return body
 }
+
+// doRequest 以指定身份发送请求，返回解码后的响应体
+func doRequest(method, path string, payload interface{}, auth string) map[string]interface{} {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		Expect(err).NotTo(HaveOccurred())
+		body = bytes.NewBuffer(data)
+	}
+	req, err := http.NewRequest(method, baseURL+path, body)
+	Expect(err).NotTo(HaveOccurred())
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", auth)
+
+	resp, err := client.Do(req)
+	Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	return decodeResponseBody(resp)
+}
+
+// createActiveWorkflow 创建并激活工作流，名称追加时间戳避免重名，返回工作流ID
+func createActiveWorkflow(name, definition string) string {
+	return createNamedWorkflow(fmt.Sprintf("%s_%d", name, time.Now().UnixNano()), definition)
+}
+
+// createNamedWorkflow 按给定名称创建并激活工作流，返回工作流ID
+func createNamedWorkflow(name, definition string) string {
+	result := doRequest("POST", "/api/v1/workflows", map[string]interface{}{
+		"name":       name,
+		"definition": definition,
+	}, token)
+	Expect(result["code"]).To(BeEquivalentTo(200), "创建工作流失败: %v", result["msg"])
+	workflowID := result["data"].(map[string]interface{})["id"].(string)
+
+	result = doRequest("POST", "/api/v1/workflows/"+workflowID+"/activate", nil, token)
+	Expect(result["code"]).To(BeEquivalentTo(200), "激活工作流失败: %v", result["msg"])
+	return workflowID
+}
+
+// startInstance 以管理员身份启动实例，返回实例ID
+func startInstance(workflowID string, input map[string]interface{}) string {
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+	result := doRequest("POST", "/api/v1/instances", map[string]interface{}{
+		"id":    workflowID,
+		"input": input,
+	}, token)
+	Expect(result["code"]).To(BeEquivalentTo(200), "启动实例失败: %v", result["msg"])
+	return result["data"].(map[string]interface{})["id"].(string)
+}
+
+// getInstance 查询实例
+func getInstance(instanceID string) map[string]interface{} {
+	result := doRequest("GET", "/api/v1/instances/"+instanceID, nil, token)
+	Expect(result["code"]).To(BeEquivalentTo(200))
+	return result["data"].(map[string]interface{})
+}
+
+// childInstances 查询子流程步骤启动的子实例ID
+func childInstances(instanceID string) []string {
+	var ids []string
+	db.Table("workflow_instances").Where("parent_instance_id = ?", instanceID).Pluck("id", &ids)
+	return ids
+}
+
+// instanceStatus 查询实例状态
+func instanceStatus(instanceID string) string {
+	status, _ := getInstance(instanceID)["status"].(string)
+	return status
+}
+
+// getInstanceTasks 查询实例的全部任务
+func getInstanceTasks(instanceID string) []map[string]interface{} {
+	result := doRequest("GET", "/api/v1/tasks/instance/"+instanceID, nil, token)
+	Expect(result["code"]).To(BeEquivalentTo(200))
+	return toObjects(result["data"])
+}
+
+// getInstanceTokens 查询实例的执行令牌
+func getInstanceTokens(instanceID string) []map[string]interface{} {
+	result := doRequest("GET", "/api/v1/instances/"+instanceID+"/tokens", nil, token)
+	Expect(result["code"]).To(BeEquivalentTo(200))
+	return toObjects(result["data"])
+}
+
+// filterByStep 按步骤和状态筛选任务或令牌，status 为空时不限状态
+func filterByStep(items []map[string]interface{}, field, stepID, status string) []map[string]interface{} {
+	var matched []map[string]interface{}
+	for _, item := range items {
+		if item[field] == stepID && (status == "" || item["status"] == status) {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+// tasksOf 筛选实例中指定步骤、状态的任务
+func tasksOf(instanceID, taskKey, status string) []map[string]interface{} {
+	return filterByStep(getInstanceTasks(instanceID), "taskKey", taskKey, status)
+}
+
+// pendingTask 返回指定步骤唯一的待处理任务
+func pendingTask(instanceID, taskKey string) map[string]interface{} {
+	tasks := tasksOf(instanceID, taskKey, "pending")
+	Expect(tasks).To(HaveLen(1), "步骤 %s 的待处理任务", taskKey)
+	return tasks[0]
+}
+
+// taskAction 以指定身份对任务执行操作（approve、reject、withdraw 等），返回响应体
+func taskAction(task map[string]interface{}, action string, payload map[string]interface{}, auth string) map[string]interface{} {
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	return doRequest("POST", "/api/v1/tasks/"+task["taskId"].(string)+"/"+action, payload, auth)
+}
+
+// userToken 生成普通用户的测试 token
+func userToken(userID int) string {
+	return GenerateTestToken(userID, 2, fmt.Sprintf("user%d", userID), "普通用户", 1)
+}
+
+// assigneeToken 返回任务当前处理人的 token，管理员使用全局 token
+func assigneeToken(task map[string]interface{}) string {
+	assignee, _ := task["assignee"].(float64)
+	if assignee == 0 || assignee == 1 {
+		return token
+	}
+	return userToken(int(assignee))
+}
+
+// approveTask 以任务处理人身份批准任务
+func approveTask(task map[string]interface{}) {
+	result := taskAction(task, "approve", map[string]interface{}{"comment": "同意"}, assigneeToken(task))
+	Expect(result["code"]).To(BeEquivalentTo(200), "批准任务失败: %v", result["msg"])
+}
+
+// claimableTaskIDs 查询用户的待领任务ID
+func claimableTaskIDs(auth string) []interface{} {
+	result := doRequest("GET", "/api/v1/tasks/claimable?pageIndex=1&pageSize=100", nil, auth)
+	Expect(result["code"]).To(BeEquivalentTo(200))
+	page, _ := result["data"].(map[string]interface{})
+	var ids []interface{}
+	for _, task := range toObjects(page["list"]) {
+		ids = append(ids, task["taskId"])
+	}
+	return ids
+}
+
+// pendingTimers 统计任务待触发的定时器数量
+func pendingTimers(taskID string) int64 {
+	return timerCount(taskID, "pending")
+}
+
+// timerCount 统计任务指定状态的定时器数量
+func timerCount(taskID, status string) int64 {
+	var count int64
+	db.Table("workflow_timers").Where("task_id = ? AND status = ?", taskID, status).Count(&count)
+	return count
+}
+
+// timerDueAt 查询任务最近创建的定时器的到期时间
+func timerDueAt(taskID string) time.Time {
+	var dueAt time.Time
+	err := db.Table("workflow_timers").Select("due_at").Where("task_id = ?", taskID).
+		Order("created_at DESC").Limit(1).Row().Scan(&dueAt)
+	Expect(err).NotTo(HaveOccurred())
+	return dueAt
+}
+
+// historyCount 统计任务指定动作的历史记录数量
+func historyCount(taskID, action string) int64 {
+	var count int64
+	db.Table("workflow_task_history").Where("task_id = ? AND action = ?", taskID, action).Count(&count)
+	return count
+}
+
+func toObjects(data interface{}) []map[string]interface{} {
+	list, _ := data.([]interface{})
+	objects := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if object, ok := item.(map[string]interface{}); ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// republishWorkflow 冻结工作流并更新定义后重新激活，发布新版本
+func republishWorkflow(workflowID, definition string) {
+	result := doRequest("GET", "/api/v1/workflows/"+workflowID, nil, token)
+	Expect(result["code"]).To(BeEquivalentTo(200))
+	name := result["data"].(map[string]interface{})["name"]
+
+	result = doRequest("POST", "/api/v1/workflows/"+workflowID+"/freeze", nil, token)
+	Expect(result["code"]).To(BeEquivalentTo(200), "冻结工作流失败: %v", result["msg"])
+
+	result = doRequest("PUT", "/api/v1/workflows/"+workflowID, map[string]interface{}{
+		"name":       name,
+		"definition": definition,
+	}, token)
+	Expect(result["code"]).To(BeEquivalentTo(200), "更新工作流失败: %v", result["msg"])
+
+	result = doRequest("POST", "/api/v1/workflows/"+workflowID+"/activate", nil, token)
+	Expect(result["code"]).To(BeEquivalentTo(200), "激活工作流失败: %v", result["msg"])
+}
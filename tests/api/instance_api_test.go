@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -51,6 +52,22 @@ var _ = Describe("Instance API Tests", func() {
 		})
 	})
 
+	Describe("GET /api/v1/instances/started - 我发起的实例", func() {
+		It("应该成功返回当前用户发起的实例列表", func() {
+			req, _ := http.NewRequest("GET", baseURL+"/api/v1/instances/started?pageIndex=1&pageSize=10", nil)
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			result := expectBusinessCode(resp, 200)
+			Expect(result["data"]).NotTo(BeNil())
+		})
+	})
+
 	Describe("GET /api/v1/instances/:id - 获取实例详情", func() {
 		It("应该返回404当实例不存在", func() {
 			req, _ := http.NewRequest("GET", baseURL+"/api/v1/instances/nonexistent-id", nil)
@@ -79,6 +96,215 @@ var _ = Describe("Instance API Tests", func() {
 		})
 	})
 
+	Describe("GET /api/v1/instances/:id/tokens - 获取实例执行令牌", func() {
+		It("应该返回404当实例不存在", func() {
+			req, _ := http.NewRequest("GET", baseURL+"/api/v1/instances/01920000-0000-7000-8000-000000000000/tokens", nil)
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 404)
+		})
+	})
+
+	Describe("POST /api/v1/instances/:id/jump - 实例跳转", func() {
+		It("应该返回404当实例不存在", func() {
+			payload := map[string]interface{}{
+				"targetStepId": "review",
+				"reason":       "实例卡住，人工跳转",
+			}
+
+			body, _ := json.Marshal(payload)
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/instances/01920000-0000-7000-8000-000000000000/jump", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 404)
+		})
+
+		It("应该返回400当缺少目标步骤", func() {
+			body, _ := json.Marshal(map[string]interface{}{"reason": "缺少目标"})
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/instances/01920000-0000-7000-8000-000000000000/jump", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 400)
+		})
+
+		It("应该取消当前任务和定时器，并在目标步骤创建任务", func() {
+			workflowID := createActiveWorkflow("实例跳转", `{"steps":[`+
+				`{"id":"apply","name":"申请","type":"userTask","timeout":3600,"params":{"assignee":"1"},"nextSteps":["review"]},`+
+				`{"id":"review","name":"审核","type":"userTask","params":{"assignee":"1"},"nextSteps":["archive"]},`+
+				`{"id":"archive","name":"归档","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			apply := pendingTask(instanceID, "apply")
+
+			result := doRequest("POST", "/api/v1/instances/"+instanceID+"/jump", map[string]interface{}{"targetStepId": "missing"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(400))
+			Expect(pendingTask(instanceID, "apply")["taskId"]).To(Equal(apply["taskId"]))
+
+			result = doRequest("POST", "/api/v1/instances/"+instanceID+"/jump", map[string]interface{}{
+				"targetStepId": "archive",
+				"reason":       "材料已线下审核",
+			}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "实例跳转失败: %v", result["msg"])
+			data := result["data"].(map[string]interface{})
+			Expect(data["fromSteps"]).To(Equal([]interface{}{"apply"}))
+			Expect(data["toStepId"]).To(Equal("archive"))
+			Expect(data["cancelledTasks"]).To(Equal([]interface{}{apply["taskId"]}))
+
+			Expect(tasksOf(instanceID, "apply", "cancelled")).To(HaveLen(1))
+			Expect(pendingTimers(apply["taskId"].(string))).To(BeZero())
+			Expect(tasksOf(instanceID, "review", "")).To(BeEmpty())
+			tokens := getInstanceTokens(instanceID)
+			Expect(tokens).To(HaveLen(1))
+			Expect(tokens[0]["stepId"]).To(Equal("archive"))
+			Expect(tokens[0]["status"]).To(Equal("active"))
+
+			approveTask(pendingTask(instanceID, "archive"))
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+	})
+
+	Describe("GET /api/v1/instances/:id/cancel - 取消实例", func() {
+		It("应该返回404当实例不存在", func() {
+			req, _ := http.NewRequest("GET", baseURL+"/api/v1/instances/01920000-0000-7000-8000-000000000000/cancel?reason=withdrawn", nil)
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 404)
+		})
+
+		It("应该关闭全部待办任务、定时器和令牌", func() {
+			workflowID := createActiveWorkflow("取消实例", `{"steps":[`+
+				`{"id":"collect","name":"会审","type":"parallel","parallelTasks":[`+
+				`{"id":"legal","name":"法制审核","type":"userTask","timeout":3600,"params":{"assignee":"1"}},`+
+				`{"id":"finance","name":"财务审核","type":"userTask","timeout":3600,"params":{"assignee":"1"}}],"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			legal := pendingTask(instanceID, "legal")
+			finance := pendingTask(instanceID, "finance")
+
+			result := doRequest("GET", "/api/v1/instances/"+instanceID+"/cancel?reason=withdrawn", nil, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "取消实例失败: %v", result["msg"])
+			Expect(instanceStatus(instanceID)).To(Equal("cancelled"))
+
+			for _, task := range []map[string]interface{}{legal, finance} {
+				taskID := task["taskId"].(string)
+				cancelled := tasksOf(instanceID, task["taskKey"].(string), "cancelled")
+				Expect(cancelled).To(HaveLen(1))
+				Expect(cancelled[0]["comment"]).To(Equal("withdrawn"))
+				Expect(pendingTimers(taskID)).To(BeZero())
+				Expect(timerCount(taskID, "cancelled")).To(BeEquivalentTo(1))
+				Expect(historyCount(taskID, "cancel")).To(BeEquivalentTo(1))
+			}
+			for _, t := range getInstanceTokens(instanceID) {
+				Expect(t["status"]).NotTo(BeElementOf("active", "waiting", "forked"))
+			}
+
+			// 已取消的实例不能再处理任务，也不能重复取消
+			result = taskAction(legal, "approve", map[string]interface{}{"comment": "同意"}, token)
+			Expect(result["code"]).NotTo(BeEquivalentTo(200))
+			result = doRequest("GET", "/api/v1/instances/"+instanceID+"/cancel?reason=withdrawn", nil, token)
+			Expect(result["code"]).To(BeEquivalentTo(400))
+		})
+	})
+
+	Describe("POST /api/v1/instances/:id/suspend - 挂起实例", func() {
+		It("应该返回404当实例不存在", func() {
+			body, _ := json.Marshal(map[string]interface{}{"reason": "案件法律保全"})
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/instances/01920000-0000-7000-8000-000000000000/suspend", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 404)
+		})
+
+		It("应该返回400当缺少挂起原因", func() {
+			body, _ := json.Marshal(map[string]interface{}{})
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/instances/01920000-0000-7000-8000-000000000000/suspend", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 400)
+		})
+	})
+
+	Describe("POST /api/v1/instances/:id/resume - 恢复实例", func() {
+		It("应该返回404当实例不存在", func() {
+			body, _ := json.Marshal(map[string]interface{}{"reason": "法律保全解除"})
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/instances/01920000-0000-7000-8000-000000000000/resume", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 404)
+		})
+
+		It("应该在挂起期间拒绝处理任务，恢复后按挂起时长顺延定时器", func() {
+			workflowID := createActiveWorkflow("挂起恢复", `{"steps":[`+
+				`{"id":"review","name":"审核","type":"userTask","timeout":3600,"params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			review := pendingTask(instanceID, "review")
+			reviewID := review["taskId"].(string)
+			dueAt := timerDueAt(reviewID)
+
+			result := doRequest("POST", "/api/v1/instances/"+instanceID+"/suspend", map[string]interface{}{"reason": "案件法律保全"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "挂起实例失败: %v", result["msg"])
+			Expect(instanceStatus(instanceID)).To(Equal("suspended"))
+			Expect(timerCount(reviewID, "paused")).To(BeEquivalentTo(1))
+			Expect(pendingTimers(reviewID)).To(BeZero())
+
+			result = taskAction(review, "approve", map[string]interface{}{"comment": "同意"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(400))
+			Expect(pendingTask(instanceID, "review")["taskId"]).To(Equal(reviewID))
+
+			time.Sleep(2 * time.Second)
+			result = doRequest("POST", "/api/v1/instances/"+instanceID+"/resume", map[string]interface{}{"reason": "法律保全解除"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "恢复实例失败: %v", result["msg"])
+			Expect(instanceStatus(instanceID)).To(Equal("running"))
+			Expect(timerCount(reviewID, "paused")).To(BeZero())
+			Expect(pendingTimers(reviewID)).To(BeEquivalentTo(1))
+			Expect(timerDueAt(reviewID).Sub(dueAt)).To(BeNumerically(">=", 2*time.Second))
+
+			approveTask(review)
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+	})
+
 	Describe("DELETE /api/v1/instances/:id - 删除实例", func() {
 		It("应该返回404当实例不存在", func() {
 			req, _ := http.NewRequest("DELETE", baseURL+"/api/v1/instances/nonexistent-id", nil)
@@ -84,9 +84,12 @@ var _ = AfterSuite(func() {
 
 	if db != nil {
 		// 清理测试数据（根据时间戳）
+		db.Exec("DELETE FROM execution_tokens WHERE created_at >= ?", testStartTime)
+		db.Exec("DELETE FROM workflow_timers WHERE created_at >= ?", testStartTime)
 		db.Exec("DELETE FROM workflow_task_history WHERE created_at >= ?", testStartTime)
 		db.Exec("DELETE FROM workflow_tasks WHERE created_at >= ?", testStartTime)
 		db.Exec("DELETE FROM workflow_instances WHERE created_at >= ?", testStartTime)
+		db.Exec("DELETE FROM workflow_versions WHERE created_at >= ?", testStartTime)
 		db.Exec("DELETE FROM workflows WHERE created_at >= ?", testStartTime)
 
 		fmt.Printf("✅ 测试数据清理完成\n")
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -118,6 +119,85 @@ var _ = Describe("Task API Tests", func() {
 			Expect(resp.StatusCode).To(Equal(http.StatusOK))
 			expectBusinessCode(resp, 400)
 		})
+
+		It("应该在候选人同时认领时只让一人成功，放弃认领后其他候选人可以认领", func() {
+			workflowID := createActiveWorkflow("认领竞争", `{"steps":[`+
+				`{"id":"review","name":"审核","type":"userTask","params":{"candidateUsers":[2,3]},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			review := pendingTask(instanceID, "review")
+			Expect(review["assignee"]).To(BeEquivalentTo(0))
+			Expect(claimableTaskIDs(userToken(2))).To(ContainElement(review["taskId"]))
+			Expect(claimableTaskIDs(userToken(3))).To(ContainElement(review["taskId"]))
+
+			result := taskAction(review, "claim", nil, userToken(4))
+			Expect(result["code"]).To(BeEquivalentTo(403))
+
+			codes := make(map[int]float64)
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			for _, userID := range []int{2, 3} {
+				wg.Add(1)
+				go func(userID int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					result := taskAction(review, "claim", nil, userToken(userID))
+					mu.Lock()
+					codes[userID], _ = result["code"].(float64)
+					mu.Unlock()
+				}(userID)
+			}
+			wg.Wait()
+
+			winner, loser := 2, 3
+			if codes[3] == 200 {
+				winner, loser = 3, 2
+			}
+			Expect(codes[winner]).To(BeEquivalentTo(200))
+			Expect(codes[loser]).To(BeEquivalentTo(409))
+			review = pendingTask(instanceID, "review")
+			Expect(review["assignee"]).To(BeEquivalentTo(winner))
+			Expect(historyCount(review["taskId"].(string), "claim")).To(BeEquivalentTo(1))
+			Expect(claimableTaskIDs(userToken(loser))).NotTo(ContainElement(review["taskId"]))
+
+			// 只有认领人可以放弃认领，放弃后另一候选人可以认领并处理
+			result = taskAction(review, "unclaim", nil, userToken(loser))
+			Expect(result["code"]).To(BeEquivalentTo(403))
+			result = taskAction(review, "unclaim", map[string]interface{}{"comment": "无暇处理"}, userToken(winner))
+			Expect(result["code"]).To(BeEquivalentTo(200), "放弃认领失败: %v", result["msg"])
+			Expect(pendingTask(instanceID, "review")["assignee"]).To(BeEquivalentTo(0))
+
+			result = taskAction(review, "claim", nil, userToken(loser))
+			Expect(result["code"]).To(BeEquivalentTo(200), "认领失败: %v", result["msg"])
+			approveTask(pendingTask(instanceID, "review"))
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+	})
+
+	Describe("POST /api/v1/tasks/:id/unclaim - 放弃认领", func() {
+		It("应该返回错误当任务ID无效", func() {
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/tasks/nonexistent-id/unclaim", nil)
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 400)
+		})
+
+		It("应该返回404当任务不存在", func() {
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/tasks/01920000-0000-7000-8000-000000000000/unclaim", nil)
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 404)
+		})
 	})
 
 	Describe("POST /api/v1/tasks/:id/complete - 完成任务", func() {
@@ -202,6 +282,97 @@ var _ = Describe("Task API Tests", func() {
 			Expect(resp.StatusCode).To(Equal(http.StatusOK))
 			expectBusinessCode(resp, 400)
 		})
+
+		It("应该记录转办链，拒绝形成环和超过次数的转办，转回原处理人时重置", func() {
+			workflowID := createActiveWorkflow("转办链", `{"steps":[`+
+				`{"id":"review","name":"审核","type":"userTask","maxDelegateHops":2,"params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			review := pendingTask(instanceID, "review")
+			delegate := func(from, to int) map[string]interface{} {
+				return taskAction(review, "delegate", map[string]interface{}{"targetId": to, "comment": "转办"}, userToken(from))
+			}
+
+			result := taskAction(review, "delegate", map[string]interface{}{"targetId": 2, "comment": "转办"}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "转办失败: %v", result["msg"])
+			Expect(delegate(2, 3)["code"]).To(BeEquivalentTo(200))
+
+			review = pendingTask(instanceID, "review")
+			Expect(review["assignee"]).To(BeEquivalentTo(3))
+			Expect(review["originalAssignee"]).To(BeEquivalentTo(1))
+			Expect(review["delegationChain"]).To(Equal([]interface{}{float64(1), float64(2), float64(3)}))
+
+			// 转给链中已有的用户形成环，原处理人之后已转办 2 次达到上限
+			Expect(delegate(3, 2)["code"]).To(BeEquivalentTo(400))
+			Expect(delegate(3, 4)["code"]).To(BeEquivalentTo(400))
+			Expect(delegate(2, 4)["code"]).To(BeEquivalentTo(403))
+			Expect(pendingTask(instanceID, "review")["assignee"]).To(BeEquivalentTo(3))
+
+			Expect(delegate(3, 1)["code"]).To(BeEquivalentTo(200))
+			review = pendingTask(instanceID, "review")
+			Expect(review["assignee"]).To(BeEquivalentTo(1))
+			Expect(review["originalAssignee"]).To(BeEquivalentTo(0))
+			Expect(review["delegationChain"]).To(SatisfyAny(BeNil(), BeEmpty()))
+			Expect(historyCount(review["taskId"].(string), "delegate")).To(BeEquivalentTo(3))
+
+			approveTask(review)
+			Expect(instanceStatus(instanceID)).To(Equal("completed"))
+		})
+
+		It("应该拒绝不允许转办的步骤", func() {
+			workflowID := createActiveWorkflow("禁止转办", `{"steps":[`+
+				`{"id":"review","name":"审核","type":"userTask","allowDelegate":false,"params":{"assignee":"1"},"nextSteps":["end"]},`+
+				`{"id":"end","type":"complete"}]}`)
+			instanceID := startInstance(workflowID, nil)
+			review := pendingTask(instanceID, "review")
+
+			result := taskAction(review, "delegate", map[string]interface{}{"targetId": 2}, token)
+			Expect(result["code"]).To(BeEquivalentTo(403))
+			Expect(pendingTask(instanceID, "review")["assignee"]).To(BeEquivalentTo(1))
+			Expect(historyCount(review["taskId"].(string), "delegate")).To(BeZero())
+		})
+	})
+
+	Describe("POST /api/v1/tasks/:id/add-signer - 加签", func() {
+		It("应该返回404当任务不存在", func() {
+			payload := map[string]interface{}{
+				"signerId": 2,
+				"position": "before",
+				"comment":  "请先审核",
+			}
+
+			body, _ := json.Marshal(payload)
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/tasks/01920000-0000-7000-8000-000000000000/add-signer", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 404)
+		})
+	})
+
+	Describe("POST /api/v1/tasks/:id/withdraw - 撤回任务", func() {
+		It("应该返回404当任务不存在", func() {
+			payload := map[string]interface{}{
+				"comment": "填写有误，撤回修改",
+			}
+
+			body, _ := json.Marshal(payload)
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/tasks/01920000-0000-7000-8000-000000000000/withdraw", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 404)
+		})
 	})
 
 	Describe("DELETE /api/v1/tasks/:id - 删除任务", func() {
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -113,7 +114,150 @@ var _ = Describe("Workflow API Tests", func() {
 		})
 	})
 
+	Describe("POST /api/v1/workflows/validate - 校验工作流定义", func() {
+		It("应该返回定义中的结构错误及其JSON路径", func() {
+			payload := map[string]interface{}{
+				"definition": `{"steps":[{"id":"step1","type":"userTask","nextSteps":["missing"]},{"id":"step1","type":"unknown"}]}`,
+			}
+
+			body, _ := json.Marshal(payload)
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/workflows/validate", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			result := expectBusinessCode(resp, 200)
+			data, ok := result["data"].(map[string]interface{})
+			Expect(ok).To(BeTrue())
+			Expect(data["valid"]).To(Equal(false))
+
+			var paths []string
+			for _, item := range data["errors"].([]interface{}) {
+				paths = append(paths, item.(map[string]interface{})["path"].(string))
+			}
+			Expect(paths).To(ContainElements("$.steps[0].nextSteps[0]", "$.steps[1].id", "$.steps[1].type"))
+		})
+
+		It("应该通过合法的定义", func() {
+			payload := map[string]interface{}{
+				"definition": `{"steps":[{"id":"step1","type":"userTask"},{"id":"end","type":"complete"}]}`,
+			}
+
+			body, _ := json.Marshal(payload)
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/workflows/validate", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			result := expectBusinessCode(resp, 200)
+			data, ok := result["data"].(map[string]interface{})
+			Expect(ok).To(BeTrue())
+			Expect(data["valid"]).To(Equal(true))
+		})
+	})
+
+	Describe("BPMN 导入导出", func() {
+		bpmnXML := `<?xml version="1.0" encoding="UTF-8"?>
+<bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" id="Definitions_1">
+  <bpmn:process id="leave" name="BPMN导入测试流程" isExecutable="true">
+    <bpmn:startEvent id="start"/>
+    <bpmn:userTask id="apply" name="申请"/>
+    <bpmn:exclusiveGateway id="gateway" default="toHr"/>
+    <bpmn:userTask id="manager" name="经理审批"/>
+    <bpmn:userTask id="hr" name="人事审批"/>
+    <bpmn:scriptTask id="script" name="脚本"/>
+    <bpmn:endEvent id="end"/>
+    <bpmn:sequenceFlow id="f1" sourceRef="start" targetRef="apply"/>
+    <bpmn:sequenceFlow id="f2" sourceRef="apply" targetRef="gateway"/>
+    <bpmn:sequenceFlow id="toManager" sourceRef="gateway" targetRef="manager">
+      <bpmn:conditionExpression xsi:type="bpmn:tFormalExpression">${days &gt; 3}</bpmn:conditionExpression>
+    </bpmn:sequenceFlow>
+    <bpmn:sequenceFlow id="toHr" sourceRef="gateway" targetRef="hr"/>
+    <bpmn:sequenceFlow id="f3" sourceRef="manager" targetRef="end"/>
+    <bpmn:sequenceFlow id="f4" sourceRef="hr" targetRef="end"/>
+  </bpmn:process>
+</bpmn:definitions>`
+
+		It("应该导入BPMN并列出不支持的元素，再导出为BPMN", func() {
+			body, _ := json.Marshal(map[string]interface{}{"xml": bpmnXML})
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/workflows/import/bpmn", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			result := expectBusinessCode(resp, 200)
+			data, ok := result["data"].(map[string]interface{})
+			Expect(ok).To(BeTrue())
+			workflowID, _ := data["workflowId"].(string)
+			Expect(workflowID).NotTo(BeEmpty())
+			Expect(data["definition"]).To(ContainSubstring(`"condition":"${days} \u003e 3"`))
+
+			var unsupported []string
+			for _, item := range data["unsupported"].([]interface{}) {
+				unsupported = append(unsupported, item.(map[string]interface{})["elementId"].(string))
+			}
+			Expect(unsupported).To(ContainElement("script"))
+
+			req, _ = http.NewRequest("GET", baseURL+"/api/v1/workflows/"+workflowID+"/bpmn", nil)
+			req.Header.Set("Authorization", token)
+
+			exportResp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer exportResp.Body.Close()
+
+			Expect(exportResp.StatusCode).To(Equal(http.StatusOK))
+			Expect(exportResp.Header.Get("Content-Type")).To(ContainSubstring("application/xml"))
+			exported, _ := io.ReadAll(exportResp.Body)
+			Expect(string(exported)).To(ContainSubstring(`<userTask id="manager" name="经理审批">`))
+			Expect(string(exported)).To(ContainSubstring(`${${days} &gt; 3}`))
+		})
+
+		It("应该拒绝非BPMN文档", func() {
+			body, _ := json.Marshal(map[string]interface{}{"xml": "<root/>", "dryRun": true})
+			req, _ := http.NewRequest("POST", baseURL+"/api/v1/workflows/import/bpmn", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", token)
+
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			expectBusinessCode(resp, 400)
+		})
+	})
+
 	Describe("POST /api/v1/workflows/:id/activate - 激活工作流", func() {
+		It("应该允许保存结构不完整的草稿，但拒绝激活", func() {
+			result := doRequest("POST", "/api/v1/workflows", map[string]interface{}{
+				"name":       fmt.Sprintf("草稿_%d", GinkgoRandomSeed()),
+				"definition": `{"steps":[{"id":"step1","type":"userTask","nextSteps":["missing"]}]}`,
+			}, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "保存草稿失败: %v", result["msg"])
+			draftID := result["data"].(map[string]interface{})["id"].(string)
+
+			result = doRequest("POST", "/api/v1/workflows/"+draftID+"/activate", nil, token)
+			Expect(result["code"]).NotTo(BeEquivalentTo(200))
+
+			result = doRequest("GET", "/api/v1/workflows/"+draftID, nil, token)
+			Expect(result["data"].(map[string]interface{})["status"]).To(Equal("draft"))
+		})
+
 		It("应该返回404当工作流不存在", func() {
 			req, _ := http.NewRequest("POST", baseURL+"/api/v1/workflows/nonexistent-id/activate", nil)
 			req.Header.Set("Authorization", token)
@@ -155,6 +299,65 @@ var _ = Describe("Workflow API Tests", func() {
 		})
 	})
 
+	Describe("GET /api/v1/workflows/:id/versions - 工作流版本", func() {
+		const v1 = `{"steps":[` +
+			`{"id":"draft","name":"起草","type":"userTask","params":{"assignee":"1"},"nextSteps":["review"]},` +
+			`{"id":"review","name":"审核","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},` +
+			`{"id":"end","type":"complete"}]}`
+		const v2 = `{"steps":[` +
+			`{"id":"draft","name":"起草申请","type":"userTask","params":{"assignee":"1"},"nextSteps":["approve"]},` +
+			`{"id":"approve","name":"审批","type":"userTask","params":{"assignee":"1"},"nextSteps":["end"]},` +
+			`{"id":"end","type":"complete"}]}`
+
+		It("应该在定义变化时发布新版本，并让实例按启动时的版本执行", func() {
+			workflowID := createActiveWorkflow("版本发布", v1)
+			first := startInstance(workflowID, nil)
+			Expect(getInstance(first)["workflowVersion"]).To(BeEquivalentTo(1))
+
+			republishWorkflow(workflowID, v2)
+			second := startInstance(workflowID, nil)
+			Expect(getInstance(second)["workflowVersion"]).To(BeEquivalentTo(2))
+
+			result := doRequest("GET", "/api/v1/workflows/"+workflowID+"/versions", nil, token)
+			Expect(result["code"]).To(BeEquivalentTo(200))
+			versions := toObjects(result["data"])
+			Expect(versions).To(HaveLen(2))
+			Expect(versions[0]["version"]).To(BeEquivalentTo(2))
+			Expect(versions[1]["definition"]).To(MatchJSON(v1))
+
+			// 旧实例仍按版本1流转，新实例按版本2流转
+			approveTask(pendingTask(first, "draft"))
+			pendingTask(first, "review")
+			approveTask(pendingTask(second, "draft"))
+			pendingTask(second, "approve")
+
+			// 定义未变化时重新激活不产生新版本
+			republishWorkflow(workflowID, v2)
+			result = doRequest("GET", "/api/v1/workflows/"+workflowID+"/versions", nil, token)
+			Expect(toObjects(result["data"])).To(HaveLen(2))
+			Expect(getInstance(startInstance(workflowID, nil))["workflowVersion"]).To(BeEquivalentTo(2))
+		})
+
+		It("应该按步骤ID比较两个版本", func() {
+			workflowID := createActiveWorkflow("版本比较", v1)
+			republishWorkflow(workflowID, v2)
+
+			result := doRequest("GET", "/api/v1/workflows/"+workflowID+"/versions/compare?from=1&to=2", nil, token)
+			Expect(result["code"]).To(BeEquivalentTo(200), "比较版本失败: %v", result["msg"])
+			diff := result["data"].(map[string]interface{})
+			Expect(diff["addedSteps"]).To(ConsistOf("approve"))
+			Expect(diff["removedSteps"]).To(ConsistOf("review"))
+			Expect(diff["changedFields"]).To(ConsistOf("stepOrder"))
+			changed := toObjects(diff["changedSteps"])
+			Expect(changed).To(HaveLen(1))
+			Expect(changed[0]["stepId"]).To(Equal("draft"))
+			Expect(changed[0]["fields"]).To(ConsistOf("name", "nextSteps"))
+
+			result = doRequest("GET", "/api/v1/workflows/"+workflowID+"/versions/compare?from=1&to=3", nil, token)
+			Expect(result["code"]).NotTo(BeEquivalentTo(200))
+		})
+	})
+
 	Describe("DELETE /api/v1/workflows/:id - 删除工作流", func() {
 		It("应该返回404当工作流不存在", func() {
 			req, _ := http.NewRequest("DELETE", baseURL+"/api/v1/workflows/nonexistent-id", nil)